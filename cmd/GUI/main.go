@@ -1,34 +1,210 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"gioui.org/app"
+	"gongaware.org/gChip8/pkg/achievements"
+	"gongaware.org/gChip8/pkg/cheats"
 	"gongaware.org/gChip8/pkg/chip8"
 	"gongaware.org/gChip8/pkg/gui"
+	"gongaware.org/gChip8/pkg/gui/effects"
+	"gongaware.org/gChip8/pkg/keymap"
+	"gongaware.org/gChip8/pkg/romdb"
+	"gongaware.org/gChip8/pkg/symbols"
+)
+
+var (
+	keymapFile = flag.String("keymap", "", "load key bindings from a json or toml `file`")
+	romdbFile  = flag.String("romdb", "", "load rom settings that override the built in database from `file`")
+	scaleName  = flag.String("scale", "integer", "display scaling `mode`: integer, fit or stretch")
+	fullscreen = flag.Bool("fullscreen", false, "start in fullscreen")
+	showKeypad = flag.Bool("keypad", false, "show a clickable hex keypad beside the display")
+	filterName = flag.String("filter", "none", "anti-flicker `filter`: none, blend, phosphor or display-wait")
+	crt        = flag.Bool("crt", false, "draw the display like a CRT with scanlines, glow and curvature")
+	crtConfig  = flag.String("crt-config", "", "load the CRT filter settings from json `file`, implies -crt")
+	themeName  = flag.String("theme", "", "colour `theme`: classic, green, amber, lcd, high-contrast or xo-chip")
+
+	timingName   = flag.String("timing", "flat", "instruction `timing`: flat runs the rom's tickrate a frame, vip charges COSMAC VIP machine cycles")
+	fontName     = flag.String("font", "chip48", "built in `font`: chip48, vip, dream6800, eti660 or fishnchips")
+	fontFile     = flag.String("font-file", "", "load the digit sprites from a raw 80 or 180 byte `file`")
+	fontLocation = flag.String("font-location", "0", "hex `address` the font is loaded at, 50 is common")
+
+	traceFile   = flag.String("trace", "", "write an execution trace to `file`")
+	traceBinary = flag.Bool("trace-binary", false, "write the trace in the compact binary format")
+	traceRange  = flag.String("trace-range", "", "only trace instructions in the address `range` e.g. 200-2FF")
+	profileFile = flag.String("profile", "", "write a text profile report to `file` on exit")
+	pprofFile   = flag.String("pprof", "", "write a pprof profile to `file` on exit")
+	debugger    = flag.Bool("debugger", false, "open a debugger window with the disassembly, registers, stack and memory")
+	cheatFile   = flag.String("cheats", "", "turn on the rom's enabled cheats from `file` and save the cheat window's changes to it")
+	cheatWindow = flag.Bool("cheat-window", false, "open a window to search memory for values and freeze or poke them")
+	symbolsFile = flag.String("symbols", "", "name addresses in traces, profiles and the debugger with the labels in `file`, the rom's .sym file is used by default")
+
+	coverageFile = flag.String("coverage", "", "write an annotated coverage listing to `file` on exit")
+	coverageHTML = flag.String("coverage-html", "", "write an html coverage report to `file` on exit")
+
+	achievementFile = flag.String("achievements", "", "check the rom's achievements from definition `file` at the end of every frame")
+	unlockLog       = flag.String("achievement-log", "achievements.log", "record unlocked achievements in `file`, they stay unlocked in later sessions")
 )
 
 func main() {
-	program, err := loadRomFile(os.Args[1])
+	flag.Parse()
+	program, err := loadRomFile(flag.Arg(0))
 	if err != nil {
 		panic(err)
 	}
 
 	errChannel := make(chan error)
 
-	system, displayChannel, inputChannel, powerChannel := chip8.New()
-	system.LoadProgram(program)
-	_ = powerChannel //Get rid of error
+	entry, err := lookupRom(program)
+	if err != nil {
+		panic(err)
+	}
+
+	scaleMode, err := gui.ParseScaleMode(*scaleName)
+	if err != nil {
+		panic(err)
+	}
+	filter, err := effects.NewFilter(*filterName)
+	if err != nil {
+		panic(err)
+	}
+	crtSettings, err := loadCRT()
+	if err != nil {
+		panic(err)
+	}
+
+	config, err := configureFont(entry.Config())
+	if err != nil {
+		panic(err)
+	}
+	config.Timing, err = chip8.ParseTiming(*timingName)
+	if err != nil {
+		panic(err)
+	}
+	system, displayChannel, inputChannel, commandChannel, err := chip8.NewWithConfig(config)
+	if err != nil {
+		panic(err)
+	}
+	statusChannel := system.StatusChannel()
+	if err := system.LoadProgram(program); err != nil {
+		panic(err)
+	}
+
+	keys, err := loadKeymap(entry)
+	if err != nil {
+		panic(err)
+	}
+
+	labels, err := loadSymbols(flag.Arg(0))
+	if err != nil {
+		panic(err)
+	}
+
+	var profiler *chip8.Profiler
+	if *profileFile != "" || *pprofFile != "" {
+		profiler = chip8.NewProfiler()
+		profiler.SetSymbols(labels)
+		system.AddObserver(profiler)
+		system.AddFrameObserver(profiler)
+	}
+
+	cheatList, err := loadCheats()
+	if err != nil {
+		panic(err)
+	}
+	if cheatList != nil {
+		if err := system.SetCheats(cheats.Enabled(cheatList.Cheats(romdb.Hash(program)))); err != nil {
+			panic(err)
+		}
+	}
+
+	engine, err := createAchievements(system, program)
+	if err != nil {
+		panic(err)
+	}
+	var messages chan string
+	if engine != nil {
+		system.AddFrameObserver(engine)
+		messages = make(chan string)
+		go func() {
+			for unlock := range engine.Unlocks() {
+				messages <- "achievement " + unlock.Title
+			}
+		}()
+	}
+
+	var snapshots <-chan chip8.Snapshot
+	var debugEvents chan<- chip8.DebugEvent
+	var cheatChannel chan<- []chip8.Cheat
+	if *debugger || *cheatWindow {
+		snapshots, debugEvents = system.DebugChannels()
+	}
+	if *cheatWindow {
+		cheatChannel = system.CheatChannel()
+	}
+
+	var coverage *chip8.Coverage
+	if *coverageFile != "" || *coverageHTML != "" {
+		coverage = chip8.NewCoverage()
+		system.AddObserver(coverage)
+	}
+
+	//Created last so no setup error leaves the file open, closeTrace is on every way out after this
+	tracer, traceOutput, err := createTracer()
+	if err != nil {
+		panic(err)
+	}
+	if tracer != nil {
+		tracer.SetSymbols(labels)
+		system.AddObserver(tracer)
+	}
 
 	go func() {
 		errChannel <- system.Run()
 	}()
 
 	go func() {
-		window := gui.New(displayChannel, inputChannel)
-		err := window.Run()
+		window := gui.New(displayChannel, inputChannel, commandChannel, keys)
+		err := applyColors(&window, entry)
+		if err == nil {
+			window.SetScaleMode(scaleMode)
+			window.SetFilter(filter)
+			window.SetCRT(crtSettings)
+			window.SetStatusChannel(statusChannel)
+			window.SetMessageChannel(messages)
+			window.SetFullscreen(*fullscreen)
+			window.SetKeypad(*showKeypad)
+			if *debugger {
+				window.OpenDebugger(snapshots, debugEvents, labels)
+			}
+			if *cheatWindow {
+				window.OpenCheats(snapshots, cheatChannel, cheatList, *cheatFile, romdb.Hash(program))
+			}
+			err = window.Run()
+		}
+		if runErr := powerOff(commandChannel, displayChannel, errChannel); runErr != nil {
+			log.Print(runErr)
+		}
+		//The trace, profiles and coverage are only read once nothing is running instructions
+		if tracer != nil {
+			closeTrace(tracer, traceOutput)
+		}
+		if profiler != nil {
+			writeProfiles(profiler)
+		}
+		if coverage != nil {
+			writeCoverage(coverage, flag.Arg(0), program)
+		}
+		if engine != nil && engine.Err() != nil {
+			log.Print(engine.Err())
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -37,6 +213,20 @@ func main() {
 	app.Main()
 }
 
+//Stops the system and waits for Run to return so observers aren't written to while their reports are
+//Displays are drained meanwhile, Run can be waiting to send one now the window is gone
+func powerOff(commands chan<- chip8.Command, displays <-chan chip8.Display, runErrors <-chan error) error {
+	for {
+		select {
+		case commands <- chip8.CommandPowerOff:
+			commands = nil
+		case <-displays:
+		case err := <-runErrors:
+			return err
+		}
+	}
+}
+
 func loadRomFile(filename string) ([]byte, error) {
 	romFile, err := os.Open(filename)
 	if err != nil {
@@ -49,3 +239,223 @@ func loadRomFile(filename string) ([]byte, error) {
 
 	return program, nil
 }
+
+//Unknown roms get an empty entry which keeps the default settings
+func lookupRom(program []byte) (romdb.Entry, error) {
+	db, err := romdb.Open()
+	if err != nil {
+		return romdb.Entry{}, err
+	}
+	if *romdbFile != "" {
+		if err := db.LoadOverrides(*romdbFile); err != nil {
+			return romdb.Entry{}, err
+		}
+	}
+
+	entry, _ := db.Lookup(program)
+	return entry, nil
+}
+
+//Rom overrides in the keymap file win over the keys from the rom database
+func loadKeymap(entry romdb.Entry) (*keymap.Keymap, error) {
+	file := keymap.Default()
+	if *keymapFile != "" {
+		var err error
+		file, err = keymap.Load(*keymapFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := file.Roms[entry.Hash]; !ok && len(entry.Keys) > 0 {
+		if file.Roms == nil {
+			file.Roms = map[string]keymap.Bindings{}
+		}
+		file.Roms[entry.Hash] = entry.Bindings(file.Bindings)
+	}
+	return file.Keymap(entry.Hash)
+}
+
+//A theme chosen on the command line wins over the rom's colours
+func applyColors(window *gui.GChipGUI, entry romdb.Entry) error {
+	if *themeName != "" {
+		index, err := gui.FindTheme(*themeName)
+		if err != nil {
+			return err
+		}
+		window.SetTheme(index)
+		return nil
+	}
+
+	colors, err := entry.Colors.PixelColors()
+	if err != nil {
+		return err
+	}
+	if palette := gui.NewPalette(colors); palette != nil {
+		window.SetPalette(palette)
+	}
+	return nil
+}
+
+//nil when the CRT filters are off
+func loadCRT() (*effects.CRTConfig, error) {
+	if *crtConfig != "" {
+		config, err := effects.LoadCRTConfig(*crtConfig)
+		return &config, err
+	}
+	if *crt {
+		config := effects.DefaultCRT()
+		return &config, nil
+	}
+	return nil, nil
+}
+
+//nil when there isn't a symbol file
+func loadSymbols(romFilename string) (chip8.Symbols, error) {
+	var table *symbols.Table
+	var err error
+	if *symbolsFile != "" {
+		table, err = symbols.Load(*symbolsFile)
+	} else {
+		table, err = symbols.LoadFor(romFilename)
+	}
+	if table == nil || err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+//The file is returned to be closed with closeTrace, both are nil without a trace file
+func createTracer() (*chip8.Tracer, *os.File, error) {
+	if *traceFile == "" {
+		return nil, nil, nil
+	}
+	file, err := os.Create(*traceFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format := chip8.TraceText
+	if *traceBinary {
+		format = chip8.TraceBinary
+	}
+	tracer := chip8.NewTracer(file, format)
+
+	if *traceRange != "" {
+		start, end, err := parseRange(*traceRange)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		tracer.AddRange(start, end)
+	}
+	return tracer, file, nil
+}
+
+//Writes out the rest of the trace, a failed write would otherwise lose it without a word
+func closeTrace(tracer *chip8.Tracer, file *os.File) {
+	if err := tracer.Flush(); err != nil {
+		log.Print(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Print(err)
+	}
+}
+
+func configureFont(config chip8.Config) (chip8.Config, error) {
+	var err error
+	if *fontFile != "" {
+		config.Font, err = chip8.LoadFontFile(*fontFile)
+	} else {
+		config.Font, err = chip8.FindFont(*fontName)
+	}
+	if err != nil {
+		return config, err
+	}
+
+	location, err := strconv.ParseUint(*fontLocation, 16, 16)
+	if err != nil {
+		return config, err
+	}
+	config.FontLocation = chip8.Address(location)
+	return config, config.Validate()
+}
+
+//Parses a hex address range in the form start-end
+func parseRange(text string) (chip8.Address, chip8.Address, error) {
+	parts := strings.SplitN(text, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("range error: %q is not in the form start-end", text)
+	}
+	start, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return chip8.Address(start), chip8.Address(end), nil
+}
+
+func writeProfiles(profiler *chip8.Profiler) {
+	if *profileFile != "" {
+		if err := writeFile(*profileFile, func(file *os.File) error { return profiler.WriteReport(file, 20) }); err != nil {
+			log.Print(err)
+		}
+	}
+	if *pprofFile != "" {
+		if err := writeFile(*pprofFile, func(file *os.File) error { return profiler.WriteProfile(file) }); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+func writeCoverage(coverage *chip8.Coverage, title string, program []byte) {
+	if *coverageFile != "" {
+		if err := writeFile(*coverageFile, func(file *os.File) error { return coverage.WriteListing(file, program) }); err != nil {
+			log.Print(err)
+		}
+	}
+	if *coverageHTML != "" {
+		if err := writeFile(*coverageHTML, func(file *os.File) error { return coverage.WriteHTML(file, title, program) }); err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+func writeFile(filename string, write func(file *os.File) error) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+//nil without a cheat file
+func loadCheats() (*cheats.File, error) {
+	if *cheatFile == "" {
+		return nil, nil
+	}
+	return cheats.Load(*cheatFile)
+}
+
+//nil without a definition file
+func createAchievements(system *chip8.Chip8, program []byte) (*achievements.Engine, error) {
+	if *achievementFile == "" {
+		return nil, nil
+	}
+	file, err := achievements.Load(*achievementFile)
+	if err != nil {
+		return nil, err
+	}
+	unlocks, err := achievements.OpenLog(*unlockLog)
+	if err != nil {
+		return nil, err
+	}
+	return achievements.NewEngine(system, romdb.Hash(program), file.Achievements(romdb.Hash(program)), unlocks)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gongaware.org/gChip8/pkg/cheats"
+	"gongaware.org/gChip8/pkg/romdb"
+)
+
+var cheatFile = flag.String("file", "cheats.json", "cheat `file` to edit")
+
+const usage = `usage: cheats [-file cheats.json] <command> <rom> [arguments]
+  list <rom>
+  add <rom> <name> <hex address> <hex value> [freeze|poke]
+  on|off|remove <rom> <name>`
+
+//Edits the cheats saved for a rom, the GUI's cheat window finds the addresses
+func main() {
+	flag.Parse()
+	if flag.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), flag.Arg(1), flag.Args()[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(command, romFilename string, args []string) error {
+	program, err := ioutil.ReadFile(romFilename)
+	if err != nil {
+		return err
+	}
+	hash := romdb.Hash(program)
+	file, err := cheats.Load(*cheatFile)
+	if err != nil {
+		return err
+	}
+	list := file.Cheats(hash)
+
+	switch command {
+	case "list":
+		for _, cheat := range list {
+			fmt.Println(describe(cheat))
+		}
+		return nil
+	case "add":
+		cheat, err := parseCheat(args)
+		if err != nil {
+			return err
+		}
+		if _, ok := cheats.Find(list, cheat.Name); ok {
+			return fmt.Errorf("cheat error: %s already has a cheat called %q", romFilename, cheat.Name)
+		}
+		list = append(list, cheat)
+	case "on", "off", "remove":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+		i, ok := cheats.Find(list, args[0])
+		if !ok {
+			return fmt.Errorf("cheat error: %s has no cheat called %q", romFilename, args[0])
+		}
+		if command == "remove" {
+			list = append(list[:i], list[i+1:]...)
+		} else {
+			list[i].Enabled = command == "on"
+		}
+	default:
+		return errors.New(usage)
+	}
+
+	file.SetCheats(hash, list)
+	return file.Save(*cheatFile)
+}
+
+//New cheats start enabled and frozen
+func parseCheat(args []string) (cheats.Cheat, error) {
+	if len(args) != 3 && len(args) != 4 {
+		return cheats.Cheat{}, errors.New(usage)
+	}
+	cheat, err := cheats.Parse(args[0], args[1], args[2])
+	if err != nil {
+		return cheats.Cheat{}, err
+	}
+	cheat.Freeze, cheat.Enabled = true, true
+	if len(args) == 4 {
+		switch args[3] {
+		case "freeze":
+		case "poke":
+			cheat.Freeze = false
+		default:
+			return cheats.Cheat{}, fmt.Errorf("cheat error: %q is not freeze or poke", args[3])
+		}
+	}
+	return cheat, nil
+}
+
+func describe(cheat cheats.Cheat) string {
+	state, kind := "off", "poke"
+	if cheat.Enabled {
+		state = "on"
+	}
+	if cheat.Freeze {
+		kind = "freeze"
+	}
+	return fmt.Sprintf("%-3s %.3X=%.2X %-6s %s", state, uint16(cheat.Address), cheat.Value, kind, cheat.Name)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/dap"
+	"gongaware.org/gChip8/pkg/romdb"
+)
+
+var (
+	listenAddress = flag.String("listen", "", "accept editor connections on `address` instead of using stdin and stdout")
+	romdbFile     = flag.String("romdb", "", "load rom settings that override the built in database from `file`")
+)
+
+//Usage: dap [-listen address]
+//Editors start it as a debug adapter, the rom comes from the launch request
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	db, err := romdb.Open()
+	if err != nil {
+		return err
+	}
+	if *romdbFile != "" {
+		if err := db.LoadOverrides(*romdbFile); err != nil {
+			return err
+		}
+	}
+
+	server := dap.NewServer(func(program []byte) (*chip8.Chip8, error) {
+		entry, _ := db.Lookup(program)
+		system, _, _, _, err := chip8.NewWithConfig(entry.Config())
+		if err != nil {
+			return nil, err
+		}
+		return system, system.LoadProgram(program)
+	})
+
+	if *listenAddress == "" {
+		return server.Serve(os.Stdin, os.Stdout)
+	}
+	listener, err := net.Listen("tcp", *listenAddress)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	fmt.Fprintf(os.Stderr, "waiting for an editor on %v\n", listener.Addr())
+	return server.ServeListener(listener)
+}
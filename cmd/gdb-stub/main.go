@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/gdbstub"
+	"gongaware.org/gChip8/pkg/romdb"
+)
+
+var (
+	listenAddress = flag.String("listen", "localhost:2159", "`address` to accept gdb connections on")
+	romdbFile     = flag.String("romdb", "", "load rom settings that override the built in database from `file`")
+)
+
+//Usage: gdb-stub [-listen address] rom.ch8
+//then in gdb: target remote localhost:2159
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdb-stub [-listen address] <rom>")
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(filename string) error {
+	program, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	db, err := romdb.Open()
+	if err != nil {
+		return err
+	}
+	if *romdbFile != "" {
+		if err := db.LoadOverrides(*romdbFile); err != nil {
+			return err
+		}
+	}
+	entry, _ := db.Lookup(program)
+
+	system, _, _, _, err := chip8.NewWithConfig(entry.Config())
+	if err != nil {
+		return err
+	}
+	if err := system.LoadProgram(program); err != nil {
+		return err
+	}
+
+	fmt.Printf("waiting for gdb on %v\n", *listenAddress)
+	return gdbstub.NewServer(system).ListenAndServe(*listenAddress)
+}
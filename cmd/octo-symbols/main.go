@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/symbols"
+)
+
+var (
+	romFile = flag.String("rom", "", "rom `file` Octo wrote from the source, the source's .ch8 by default")
+	outFile = flag.String("out", "", "symbol `file` to write, the rom's .sym by default so the GUI, dap and trace-diff find it")
+)
+
+//Usage: octo-symbols [-rom game.ch8] [-out game.sym] game.8o
+//Writes the labels and source lines of a rom made with Octo, the source is assembled again and has to match the rom
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: octo-symbols [-rom file] [-out file] <source.8o>")
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(sourceFilename string) error {
+	romFilename := *romFile
+	if romFilename == "" {
+		romFilename = withExtension(sourceFilename, ".ch8")
+	}
+	filename := *outFile
+	if filename == "" {
+		filename = withExtension(romFilename, ".sym")
+	}
+
+	program, table, err := symbols.AssembleOcto(sourceFilename)
+	if err != nil {
+		return err
+	}
+	rom, err := ioutil.ReadFile(romFilename)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(program, rom) {
+		return fmt.Errorf("octo-symbols error: %s wasn't assembled from %s, assemble it again with Octo", romFilename, sourceFilename)
+	}
+
+	directory, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := table.Write(file, directory); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func withExtension(filename, extension string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + extension
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/symbols"
+)
+
+var symbolsFile = flag.String("symbols", "", "name addresses with the labels in symbol `file`")
+
+//Usage: trace-diff [-symbols rom.sym] a.trace b.trace
+//Exits with 1 when the traces diverge and 2 on error
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: trace-diff [-symbols file] <trace a> <trace b>")
+		os.Exit(2)
+	}
+
+	var labels chip8.Symbols
+	if *symbolsFile != "" {
+		table, err := symbols.Load(*symbolsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		labels = table
+	}
+
+	divergence, err := diffFiles(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if divergence == nil {
+		fmt.Println("traces are identical")
+		return
+	}
+
+	fmt.Printf("traces diverge at instruction %v\n", divergence.Index)
+	printRecord("a", divergence.A, labels)
+	printRecord("b", divergence.B, labels)
+	if len(divergence.Fields) > 0 {
+		fmt.Printf("fields: %v\n", divergence.Fields)
+	}
+	os.Exit(1)
+}
+
+func diffFiles(filenameA, filenameB string) (*chip8.TraceDivergence, error) {
+	fileA, err := os.Open(filenameA)
+	if err != nil {
+		return nil, err
+	}
+	defer fileA.Close()
+	fileB, err := os.Open(filenameB)
+	if err != nil {
+		return nil, err
+	}
+	defer fileB.Close()
+
+	readerA, err := chip8.NewTraceReader(fileA)
+	if err != nil {
+		return nil, err
+	}
+	readerB, err := chip8.NewTraceReader(fileB)
+	if err != nil {
+		return nil, err
+	}
+
+	return chip8.DiffTraces(readerA, readerB)
+}
+
+func printRecord(name string, record *chip8.TraceRecord, labels chip8.Symbols) {
+	if record == nil {
+		fmt.Printf("%s: <end of trace>\n", name)
+	} else {
+		fmt.Printf("%s: %v\n", name, record.Format(labels))
+	}
+}
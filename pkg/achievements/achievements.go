@@ -0,0 +1,181 @@
+//Challenges for roms, conditions over memory and registers checked at the end of every frame
+package achievements
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//An achievement as written in a definition file
+//It unlocks once Trigger has held on Hits frames, 1 by default, and Reset holding sets the count back to 0
+type Achievement struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Trigger     string `json:"trigger"`
+	Reset       string `json:"reset,omitempty"`
+	Hits        int    `json:"hits,omitempty"`
+}
+
+//Layout of a definition file, Roms holds the achievements keyed by the rom's sha1
+type File struct {
+	Roms map[string][]Achievement `json:"roms"`
+}
+
+func Load(filename string) (*File, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &File{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("achievement error: %s: %v", filename, err)
+	}
+	file := &File{Roms: map[string][]Achievement{}}
+	for hash, achievements := range loaded.Roms {
+		file.Roms[strings.ToLower(hash)] = achievements
+	}
+	return file, nil
+}
+
+func (file *File) Achievements(romHash string) []Achievement {
+	return file.Roms[strings.ToLower(romHash)]
+}
+
+//How far an achievement has got
+type Progress struct {
+	Achievement
+	Count    int //Frames Trigger has held since the last reset
+	Unlocked bool
+}
+
+type tracked struct {
+	Progress
+	trigger, reset Condition
+}
+
+/*
+ENGINE
+A frame observer, add it to the system with AddFrameObserver
+Achievements in the unlock log for the rom are already unlocked and aren't checked again
+delta compares with the frame before, so after a reset, a loaded state or a gap in the frames it compares a frame with itself
+*/
+
+type Engine struct {
+	system  *chip8.Chip8
+	romHash string
+	log     *Log
+	unlocks chan Unlock
+	now     func() time.Time
+
+	mutex        sync.Mutex //Progress is read by frontends while Run updates it
+	achievements []*tracked
+	current      Frame
+	previous     Frame
+	hasPrevious  bool
+	lastFrame    uint64
+	err          error
+}
+
+func NewEngine(system *chip8.Chip8, romHash string, achievements []Achievement, log *Log) (*Engine, error) {
+	engine := &Engine{system: system, romHash: strings.ToLower(romHash), log: log, unlocks: make(chan Unlock, 8), now: time.Now}
+	for _, achievement := range achievements {
+		trigger, err := ParseCondition(achievement.Trigger)
+		if err != nil {
+			return nil, fmt.Errorf("achievement error: %s: %w", achievement.ID, err)
+		}
+		var reset Condition
+		if achievement.Reset != "" {
+			if reset, err = ParseCondition(achievement.Reset); err != nil {
+				return nil, fmt.Errorf("achievement error: %s: %w", achievement.ID, err)
+			}
+		}
+		if achievement.Hits < 1 {
+			achievement.Hits = 1
+		}
+
+		_, unlocked := log.Unlocked(engine.romHash, achievement.ID)
+		engine.achievements = append(engine.achievements, &tracked{
+			Progress: Progress{Achievement: achievement, Unlocked: unlocked},
+			trigger:  trigger,
+			reset:    reset,
+		})
+	}
+	return engine, nil
+}
+
+func (engine *Engine) ObserveFrame(frame uint64) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	engine.current = Frame{State: engine.system.State(), Memory: engine.system.Memory()}
+	if !engine.hasPrevious || frame != engine.lastFrame+1 {
+		engine.previous, engine.hasPrevious = engine.current, true
+	}
+	engine.lastFrame = frame
+	for _, achievement := range engine.achievements {
+		if achievement.Unlocked {
+			continue
+		}
+		if achievement.reset.Holds(&engine.current, &engine.previous) {
+			achievement.Count = 0
+			continue
+		}
+		if !achievement.trigger.Holds(&engine.current, &engine.previous) {
+			continue
+		}
+		achievement.Count++
+		if achievement.Count >= achievement.Hits {
+			engine.unlock(achievement, frame)
+		}
+	}
+	engine.previous = engine.current
+}
+
+func (engine *Engine) ObserveReset() {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	engine.hasPrevious = false
+}
+
+func (engine *Engine) unlock(achievement *tracked, frame uint64) {
+	achievement.Unlocked = true
+	unlock := Unlock{Time: engine.now().UTC(), Rom: engine.romHash, ID: achievement.ID, Title: achievement.Title, Frame: frame}
+	if err := engine.log.Append(unlock); err != nil && engine.err == nil {
+		engine.err = err
+	}
+	select {
+	case engine.unlocks <- unlock:
+	default: //Nobody is listening, the log still has it
+	}
+}
+
+//Unlocks as they happen, for frontends to show
+func (engine *Engine) Unlocks() <-chan Unlock {
+	return engine.unlocks
+}
+
+func (engine *Engine) Progress() []Progress {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+
+	progress := make([]Progress, len(engine.achievements))
+	for i, achievement := range engine.achievements {
+		progress[i] = achievement.Progress
+	}
+	return progress
+}
+
+//The first error writing the unlock log
+func (engine *Engine) Err() error {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	return engine.err
+}
@@ -0,0 +1,156 @@
+package achievements
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+const romHash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestCondition(t *testing.T) {
+	var current, previous Frame
+	current.Memory[0x3F0], current.Memory[0x3F1], previous.Memory[0x3F1] = 10, 4, 4
+	current.State.Registers[0xA], current.State.I, previous.State.Delay = 0x20, 0x300, 7
+
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"[0x3F0] >= 10 && [0x3F1] == delta [0x3F1]", true},
+		{"[0x3f0]==10&&[1009]==4", true},
+		{"va > 0x1F && I == 0x300", true},
+		{"delta dt == 7 && dt < delta dt", true},
+		{"[0x3F0] > 10", false},
+		{"[0x3F0] >= 10 && [0x3F1] != delta [0x3F1]", false},
+	}
+	for _, test := range tests {
+		condition, err := ParseCondition(test.text)
+		if err != nil {
+			t.Errorf("FAIL ParseCondition(%q): %v", test.text, err)
+			continue
+		}
+		if holds := condition.Holds(&current, &previous); holds != test.expected {
+			t.Errorf("FAIL %q=%v (expected %v)", test.text, holds, test.expected)
+		}
+	}
+
+	for _, text := range []string{"[0x3F0]", "[0x1000] == 1", "vg == 1", "delta 5 == 5", "score >= 10", "[0x3F0] == 1 &&"} {
+		if _, err := ParseCondition(text); err == nil {
+			t.Errorf("FAIL ParseCondition(%q) (expected an error)", text)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	system, _, _, _ := chip8.New()
+	if err := system.LoadProgram([]byte{0x12, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	logFile := filepath.Join(t.TempDir(), "unlocks.log")
+	log, err := OpenLog(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	achievements := []Achievement{
+		{ID: "ten", Title: "Ten", Trigger: "[0x3F0] >= 10 && [0x3F1] == delta [0x3F1]"},
+		{ID: "steady", Title: "Steady", Trigger: "[0x3F2] == 1", Reset: "[0x3F2] == 0", Hits: 3},
+	}
+	engine, err := NewEngine(system, romHash, achievements, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	system.AddFrameObserver(engine)
+
+	//Each frame sets [0x3F0], [0x3F1] and [0x3F2] first
+	frames := [][3]byte{{9, 0, 1}, {10, 1, 1}, {10, 1, 0}, {11, 1, 1}, {11, 1, 1}, {11, 1, 1}}
+	for _, values := range frames {
+		system.WriteMemory(0x3F0, values[:])
+		for frame := system.Frame(); system.Frame() == frame; {
+			if err := system.Step(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	progress := engine.Progress()
+	if !progress[0].Unlocked || !progress[1].Unlocked || progress[1].Count != 3 {
+		t.Errorf("FAIL progress=%+v (expected both unlocked and steady at 3 hits)", progress)
+	}
+	if unlock := <-engine.Unlocks(); unlock.ID != "ten" || unlock.Frame != 2 {
+		t.Errorf("FAIL first unlock=%+v (expected ten on frame 2)", unlock)
+	}
+	if unlock := <-engine.Unlocks(); unlock.ID != "steady" || unlock.Frame != 5 {
+		t.Errorf("FAIL second unlock=%+v (expected steady on frame 5 after the reset)", unlock)
+	}
+	if engine.Err() != nil {
+		t.Fatal(engine.Err())
+	}
+
+	//Unlocked achievements stay unlocked in the next session
+	log, err = OpenLog(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlock, ok := log.Unlocked(romHash, "steady"); !ok || unlock.Title != "Steady" || !unlock.Time.Equal(engine.now()) {
+		t.Errorf("FAIL logged unlock=%+v,%v", unlock, ok)
+	}
+	engine, err = NewEngine(system, romHash, achievements, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress := engine.Progress(); !progress[0].Unlocked || progress[0].Count != 0 {
+		t.Errorf("FAIL progress=%+v after reopening (expected ten unlocked from the log)", progress[0])
+	}
+}
+
+func TestEngineReset(t *testing.T) {
+	system, _, _, _ := chip8.New()
+	engine, err := NewEngine(system, romHash, []Achievement{{ID: "up", Title: "Up", Trigger: "[0x3F0] > delta [0x3F0]"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//A reset and a gap in the frames each start the deltas again, so neither change counts as going up
+	system.WriteMemory(0x3F0, []byte{1})
+	engine.ObserveFrame(0)
+	engine.ObserveReset()
+	system.WriteMemory(0x3F0, []byte{5})
+	engine.ObserveFrame(1)
+	system.WriteMemory(0x3F0, []byte{9})
+	engine.ObserveFrame(3)
+	if progress := engine.Progress(); progress[0].Unlocked {
+		t.Errorf("FAIL up unlocked across a reset or a gap in the frames")
+	}
+
+	system.WriteMemory(0x3F0, []byte{10})
+	engine.ObserveFrame(4)
+	if progress := engine.Progress(); !progress[0].Unlocked {
+		t.Errorf("FAIL up stayed locked when the next frame went up")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "achievements.json")
+	json := `{"roms": {"0123456789ABCDEF0123456789ABCDEF01234567": [{"id": "ten", "title": "Ten", "trigger": "[0x3F0] >= 10"}]}}`
+	if err := ioutil.WriteFile(filename, []byte(json), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if achievements := file.Achievements(romHash); len(achievements) != 1 || achievements[0].Trigger != "[0x3F0] >= 10" {
+		t.Errorf("FAIL Achievements=%+v", achievements)
+	}
+
+	system, _, _, _ := chip8.New()
+	if _, err := NewEngine(system, romHash, []Achievement{{ID: "bad", Trigger: "[0x3F0] is 10"}}, nil); err == nil {
+		t.Errorf("FAIL NewEngine with a bad trigger (expected an error)")
+	}
+}
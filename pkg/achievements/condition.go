@@ -0,0 +1,170 @@
+package achievements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+CONDITIONS
+Comparisons joined with && that all have to hold at the end of a frame, like [0x3F0] >= 10 && [0x3F1] == delta [0x3F1]
+	v0-vf, i, pc, sp, dt, st	a register
+	[address]					a byte of memory
+	42, 0x2A					a number
+	delta value					the register or byte at the end of the frame before
+Comparisons are ==, !=, <, <=, > and >=
+*/
+
+//Machine state at the end of a frame
+type Frame struct {
+	State  chip8.State
+	Memory [chip8.RamSize]byte
+}
+
+type operandType int
+
+const (
+	operandNumber operandType = iota
+	operandMemory
+	operandRegister //V0-VF
+	operandI
+	operandPC
+	operandSP
+	operandDelay
+	operandSound
+)
+
+var namedOperands = map[string]operandType{"i": operandI, "pc": operandPC, "sp": operandSP, "dt": operandDelay, "st": operandSound}
+
+type operand struct {
+	operandType operandType
+	value       int //The number, address or register
+	delta       bool
+}
+
+//Operators longest first so <= isn't read as <
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+type comparison struct {
+	left, right operand
+	operator    string
+}
+
+type Condition []comparison
+
+func ParseCondition(text string) (Condition, error) {
+	var condition Condition
+	for _, clause := range strings.Split(text, "&&") {
+		comparison, err := parseComparison(strings.TrimSpace(clause))
+		if err != nil {
+			return nil, err
+		}
+		condition = append(condition, comparison)
+	}
+	return condition, nil
+}
+
+func parseComparison(clause string) (comparison, error) {
+	for _, operator := range operators {
+		index := strings.Index(clause, operator)
+		if index < 0 {
+			continue
+		}
+		left, err := parseOperand(clause[:index])
+		if err != nil {
+			return comparison{}, err
+		}
+		right, err := parseOperand(clause[index+len(operator):])
+		if err != nil {
+			return comparison{}, err
+		}
+		return comparison{left: left, right: right, operator: operator}, nil
+	}
+	return comparison{}, fmt.Errorf("condition error: %q has no comparison", clause)
+}
+
+func parseOperand(text string) (operand, error) {
+	term := strings.ToLower(strings.TrimSpace(text))
+	result := operand{}
+	if strings.HasPrefix(term, "delta ") {
+		result.delta = true
+		term = strings.TrimSpace(strings.TrimPrefix(term, "delta "))
+	}
+
+	if operandType, ok := namedOperands[term]; ok {
+		result.operandType = operandType
+		return result, nil
+	}
+	if len(term) == 2 && term[0] == 'v' {
+		if register, err := strconv.ParseUint(term[1:], 16, 8); err == nil {
+			result.operandType, result.value = operandRegister, int(register)
+			return result, nil
+		}
+	}
+	if strings.HasPrefix(term, "[") && strings.HasSuffix(term, "]") {
+		address, err := strconv.ParseUint(strings.TrimSpace(term[1:len(term)-1]), 0, 16)
+		if err != nil || address >= chip8.RamSize {
+			return operand{}, fmt.Errorf("condition error: %q is not an address", term)
+		}
+		result.operandType, result.value = operandMemory, int(address)
+		return result, nil
+	}
+	if value, err := strconv.ParseUint(term, 0, 16); err == nil && !result.delta {
+		result.operandType, result.value = operandNumber, int(value)
+		return result, nil
+	}
+	return operand{}, fmt.Errorf("condition error: unknown value %q", strings.TrimSpace(text))
+}
+
+func (operand operand) evaluate(current, previous *Frame) int {
+	frame := current
+	if operand.delta {
+		frame = previous
+	}
+	switch operand.operandType {
+	case operandMemory:
+		return int(frame.Memory[operand.value])
+	case operandRegister:
+		return int(frame.State.Registers[operand.value])
+	case operandI:
+		return int(frame.State.I)
+	case operandPC:
+		return int(frame.State.PC)
+	case operandSP:
+		return int(frame.State.SP)
+	case operandDelay:
+		return int(frame.State.Delay)
+	case operandSound:
+		return int(frame.State.Sound)
+	}
+	return operand.value
+}
+
+//Whether every comparison holds, an empty condition never does
+func (condition Condition) Holds(current, previous *Frame) bool {
+	for _, comparison := range condition {
+		left, right := comparison.left.evaluate(current, previous), comparison.right.evaluate(current, previous)
+		var holds bool
+		switch comparison.operator {
+		case "==":
+			holds = left == right
+		case "!=":
+			holds = left != right
+		case "<":
+			holds = left < right
+		case "<=":
+			holds = left <= right
+		case ">":
+			holds = left > right
+		case ">=":
+			holds = left >= right
+		}
+		if !holds {
+			return false
+		}
+	}
+	return len(condition) > 0
+}
@@ -0,0 +1,99 @@
+package achievements
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+/*
+UNLOCK LOG
+A local record of every achievement unlocked, one json object a line so it is only ever appended to
+*/
+
+type Unlock struct {
+	Time  time.Time `json:"time"`
+	Rom   string    `json:"rom"`
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	Frame uint64    `json:"frame"` //Frames since the system started
+}
+
+type Log struct {
+	filename string //Empty keeps the log in memory
+	unlocks  []Unlock
+}
+
+//Reads the unlocks already in a log file, a missing file is empty
+func OpenLog(filename string) (*Log, error) {
+	log := &Log{filename: filename}
+	if filename == "" {
+		return log, nil
+	}
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return log, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var unlock Unlock
+		if err := json.Unmarshal(scanner.Bytes(), &unlock); err != nil {
+			return nil, fmt.Errorf("achievement error: %s line %v: %v", filename, line, err)
+		}
+		log.unlocks = append(log.unlocks, unlock)
+	}
+	return log, scanner.Err()
+}
+
+func (log *Log) Unlocked(romHash, id string) (Unlock, bool) {
+	if log == nil {
+		return Unlock{}, false
+	}
+	for _, unlock := range log.unlocks {
+		if unlock.Rom == romHash && unlock.ID == id {
+			return unlock, true
+		}
+	}
+	return Unlock{}, false
+}
+
+func (log *Log) Unlocks() []Unlock {
+	if log == nil {
+		return nil
+	}
+	return log.unlocks
+}
+
+func (log *Log) Append(unlock Unlock) error {
+	if log == nil {
+		return nil
+	}
+	log.unlocks = append(log.unlocks, unlock)
+	if log.filename == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(unlock)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(log.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("achievement error: %v", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("achievement error: %v", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,151 @@
+//Cheats for roms: a memory search to find the bytes worth changing and a file of cheats per rom
+package cheats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//A named cheat for one rom
+//Freeze writes the value every frame, otherwise it is poked once when the cheat is turned on
+type Cheat struct {
+	Name    string
+	Address chip8.Address
+	Value   byte
+	Freeze  bool
+	Enabled bool
+}
+
+//How a cheat is written in a cheat file, the address and value are hex
+type entry struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Value   string `json:"value"`
+	Freeze  bool   `json:"freeze,omitempty"`
+	Enabled bool   `json:"enabled,omitempty"`
+}
+
+func (cheat Cheat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entry{
+		Name:    cheat.Name,
+		Address: fmt.Sprintf("%.3X", uint16(cheat.Address)),
+		Value:   fmt.Sprintf("%.2X", cheat.Value),
+		Freeze:  cheat.Freeze,
+		Enabled: cheat.Enabled,
+	})
+}
+
+func (cheat *Cheat) UnmarshalJSON(data []byte) error {
+	var entry entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	parsed, err := Parse(entry.Name, entry.Address, entry.Value)
+	if err != nil {
+		return err
+	}
+	*cheat = parsed
+	cheat.Freeze, cheat.Enabled = entry.Freeze, entry.Enabled
+	return nil
+}
+
+//Reads a cheat's hex address and value as written in a cheat file or on the command line
+func Parse(name, address, value string) (Cheat, error) {
+	parsedAddress, err := strconv.ParseUint(strings.TrimPrefix(address, "0x"), 16, 16)
+	if err != nil || parsedAddress >= chip8.RamSize {
+		return Cheat{}, fmt.Errorf("cheat error: %q: %q is not an address", name, address)
+	}
+	parsedValue, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 8)
+	if err != nil {
+		return Cheat{}, fmt.Errorf("cheat error: %q: %q is not a byte", name, value)
+	}
+	return Cheat{Name: name, Address: chip8.Address(parsedAddress), Value: byte(parsedValue)}, nil
+}
+
+//The cheats turned on for the system
+func (cheat Cheat) Core() chip8.Cheat {
+	return chip8.Cheat{Address: cheat.Address, Value: cheat.Value, Freeze: cheat.Freeze}
+}
+
+//Layout of a cheat file, Roms holds the cheats keyed by the rom's sha1
+type File struct {
+	Roms map[string][]Cheat `json:"roms"`
+}
+
+//Loads a cheat file, a missing file is empty so the first cheat can create it
+func Load(filename string) (*File, error) {
+	file := &File{Roms: map[string][]Cheat{}}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &File{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		return nil, fmt.Errorf("cheat error: %s: %v", filename, err)
+	}
+	for hash, cheats := range loaded.Roms {
+		file.Roms[strings.ToLower(hash)] = cheats
+	}
+	return file, nil
+}
+
+func (file *File) Save(filename string) error {
+	data, err := json.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cheat error: %v", err)
+	}
+	return nil
+}
+
+func (file *File) Cheats(romHash string) []Cheat {
+	return file.Roms[strings.ToLower(romHash)]
+}
+
+//Replaces a rom's cheats, an empty list removes the rom from the file
+func (file *File) SetCheats(romHash string, cheats []Cheat) {
+	romHash = strings.ToLower(romHash)
+	if len(cheats) == 0 {
+		delete(file.Roms, romHash)
+		return
+	}
+	if file.Roms == nil {
+		file.Roms = map[string][]Cheat{}
+	}
+	file.Roms[romHash] = cheats
+}
+
+//Finds a cheat by name ignoring case
+func Find(cheats []Cheat, name string) (int, bool) {
+	for i, cheat := range cheats {
+		if strings.EqualFold(cheat.Name, name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+//The enabled cheats for the system, sorted by address so the same cheats always apply in the same order
+func Enabled(cheats []Cheat) []chip8.Cheat {
+	var enabled []chip8.Cheat
+	for _, cheat := range cheats {
+		if cheat.Enabled {
+			enabled = append(enabled, cheat.Core())
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool { return enabled[i].Address < enabled[j].Address })
+	return enabled
+}
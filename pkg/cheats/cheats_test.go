@@ -0,0 +1,87 @@
+package cheats
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+const romHash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestSearch(t *testing.T) {
+	var memory [chip8.RamSize]byte
+	memory[0x300], memory[0x301], memory[0x302] = 3, 3, 7
+	search := NewSearch(memory)
+
+	//Lose a life
+	memory[0x300], memory[0x301] = 2, 4
+	search.Filter(memory, Decreased, 0)
+	if candidates := search.Candidates(); len(candidates) != 1 || candidates[0] != 0x300 {
+		t.Errorf("FAIL decreased=%v (expected [0x300])", candidates)
+	}
+
+	search.Reset(memory)
+	search.Filter(memory, Equal, 4)
+	if candidates := search.Candidates(); len(candidates) != 1 || candidates[0] != 0x301 {
+		t.Errorf("FAIL equal to 4=%v (expected [0x301])", candidates)
+	}
+	memory[0x301] = 5
+	search.Filter(memory, Unchanged, 0)
+	if candidates := search.Candidates(); len(candidates) != 0 || search.Previous(0x301) != 5 {
+		t.Errorf("FAIL unchanged=%v previous=%v (expected none and 5)", candidates, search.Previous(0x301))
+	}
+
+	if comparison, err := ParseComparison("increased"); err != nil || comparison != Increased {
+		t.Errorf("FAIL ParseComparison(increased)=%v,%v", comparison, err)
+	}
+	if _, err := ParseComparison("more"); err == nil {
+		t.Errorf("FAIL ParseComparison(more) (expected an error)")
+	}
+}
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cheats.json")
+	file, err := Load(filename)
+	if err != nil || len(file.Roms) != 0 {
+		t.Fatalf("FAIL Load of a missing file=%v,%v (expected an empty file)", file, err)
+	}
+
+	cheats := []Cheat{
+		{Name: "Lives", Address: 0x3F0, Value: 0x09, Freeze: true, Enabled: true},
+		{Name: "Level", Address: 0x3E0, Value: 0x05, Enabled: true},
+		{Name: "Score", Address: 0x3F2, Value: 0xFF},
+	}
+	file.SetCheats(strings.ToUpper(romHash), cheats)
+	if err := file.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(filename)
+	if !strings.Contains(string(data), `"address": "3F0"`) {
+		t.Errorf("FAIL saved file doesn't use hex addresses:\n%s", data)
+	}
+
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := loaded.Cheats(romHash); len(result) != 3 || result[0] != cheats[0] || result[2] != cheats[2] {
+		t.Errorf("FAIL loaded cheats=%+v (expected %+v)", result, cheats)
+	}
+	if i, ok := Find(loaded.Cheats(romHash), "level"); !ok || i != 1 {
+		t.Errorf("FAIL Find(level)=%v,%v (expected 1)", i, ok)
+	}
+	enabled := Enabled(loaded.Cheats(romHash))
+	if len(enabled) != 2 || enabled[0] != (chip8.Cheat{Address: 0x3E0, Value: 0x05}) || !enabled[1].Freeze {
+		t.Errorf("FAIL Enabled=%+v (expected the level poke then the lives freeze)", enabled)
+	}
+
+	for _, text := range []string{`{"roms": {"a": [{"name": "x", "address": "1000", "value": "1"}]}}`, `{"roms": {"a": [{"name": "x", "address": "300", "value": "100"}]}}`} {
+		ioutil.WriteFile(filename, []byte(text), 0644)
+		if _, err := Load(filename); err == nil {
+			t.Errorf("FAIL Load(%v) (expected an error)", text)
+		}
+	}
+}
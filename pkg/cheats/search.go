@@ -0,0 +1,96 @@
+package cheats
+
+import (
+	"fmt"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+SEARCH
+Finds the bytes holding things like lives or score by narrowing down every address over several snapshots
+Each filter compares the new memory with the memory from the filter before it
+*/
+
+type Comparison int
+
+const (
+	Equal Comparison = iota //To the value given
+	Changed
+	Unchanged
+	Increased
+	Decreased
+)
+
+var comparisonNames = []string{"equal", "changed", "unchanged", "increased", "decreased"}
+
+func (comparison Comparison) String() string {
+	if int(comparison) < len(comparisonNames) {
+		return comparisonNames[comparison]
+	}
+	return fmt.Sprintf("Comparison(%d)", int(comparison))
+}
+
+func ParseComparison(name string) (Comparison, error) {
+	for i, comparisonName := range comparisonNames {
+		if name == comparisonName {
+			return Comparison(i), nil
+		}
+	}
+	return 0, fmt.Errorf("cheat error: %q is not a comparison (expected one of %v)", name, comparisonNames)
+}
+
+type Search struct {
+	candidates []chip8.Address
+	previous   [chip8.RamSize]byte
+}
+
+//Starts a search with every address as a candidate
+func NewSearch(memory [chip8.RamSize]byte) *Search {
+	search := &Search{}
+	search.Reset(memory)
+	return search
+}
+
+func (search *Search) Reset(memory [chip8.RamSize]byte) {
+	search.candidates = make([]chip8.Address, chip8.RamSize)
+	for i := range search.candidates {
+		search.candidates[i] = chip8.Address(i)
+	}
+	search.previous = memory
+}
+
+//Keeps the candidates that pass the comparison, value is only used by Equal
+func (search *Search) Filter(memory [chip8.RamSize]byte, comparison Comparison, value byte) {
+	kept := search.candidates[:0]
+	for _, address := range search.candidates {
+		now, before := memory[address], search.previous[address]
+		var keep bool
+		switch comparison {
+		case Equal:
+			keep = now == value
+		case Changed:
+			keep = now != before
+		case Unchanged:
+			keep = now == before
+		case Increased:
+			keep = now > before
+		case Decreased:
+			keep = now < before
+		}
+		if keep {
+			kept = append(kept, address)
+		}
+	}
+	search.candidates = kept
+	search.previous = memory
+}
+
+func (search *Search) Candidates() []chip8.Address {
+	return search.candidates
+}
+
+//The value an address had when the search last looked at memory
+func (search *Search) Previous(address chip8.Address) byte {
+	return search.previous[address]
+}
@@ -23,7 +23,7 @@ func subroutineReturn(cpu *cpu) (Operation, error) {
 			cpu.programCounter = cpu.stack[cpu.stackPointer]
 		}, nil
 	} else {
-		return nil, fmt.Errorf("ret error: stack empty, nothing to return too")
+		return nil, fmt.Errorf("ret error: %w", ErrStackEmpty)
 	}
 }
 
@@ -45,7 +45,7 @@ func subroutineCall(cpu *cpu, subroutine Address) (Operation, error) {
 			cpu.stackPointer++
 		}, nil
 	} else {
-		return nil, fmt.Errorf("call error: stack overflow")
+		return nil, fmt.Errorf("call error: %w", ErrStackOverflow)
 	}
 }
 
@@ -82,54 +82,68 @@ func xor(vX *byte, vY byte) Operation {
 	}
 }
 
+/*
+8XYN FLAGS
+VX gets the result first and VF gets the flag last, so with X as F the flag is what is left in VF
+Both operands are read before either is written, so with Y as F the old VF is used as the operand
+8XY4 VF=1 when VX+VY carries past 0xFF
+8XY5 VF=1 when VX>=VY, there is no borrow so equal operands set it
+8XY7 VF=1 when VY>=VX
+8XY6 VF=the bit shifted out of the right
+8XYE VF=the bit shifted out of the left
+*/
+
 func add(status *byte, vX *byte, vY byte) Operation {
 	return func() {
-		temp := *vX
-		*vX += vY
-		if temp > *vX { //overflow
-			*status = 1
-		} else {
-			*status = 0
-		}
-
+		sum := uint16(*vX) + uint16(vY)
+		*vX = byte(sum)
+		*status = byte(sum >> 8) //Carry
 	}
 }
 
 func subtract(status *byte, vX *byte, vY byte) Operation {
 	return func() {
-		temp := *vX
+		notBorrow := statusFlag(*vX >= vY)
 		*vX -= vY
-		if temp > vY { //NOT borrow
-			*status = 1
-		} else {
-			*status = 0
-		}
+		*status = notBorrow
 	}
 }
 
-func shiftRight(status *byte, vX *byte) Operation {
+//source is vX or vY depending on the shift quirk
+func shiftRight(status *byte, vX *byte, source byte) Operation {
 	return func() {
-		*status = *vX & 1 //Shift right bit unto status
-		*vX >>= 1
+		*vX = source >> 1
+		*status = source & 1 //Shift right bit unto status
 	}
 }
 
 func subtractN(status *byte, vX *byte, vY byte) Operation {
 	return func() {
-		temp := *vX
+		notBorrow := statusFlag(vY >= *vX)
 		*vX = vY - *vX
-		if vY > temp { //NOT borrow
-			*status = 1
-		} else {
-			*status = 0
-		}
+		*status = notBorrow
 	}
 }
 
-func shiftLeft(status *byte, vX *byte) Operation {
+func shiftLeft(status *byte, vX *byte, source byte) Operation {
 	return func() {
-		*status = *vX >> 7 //Shift Left bit unto status
-		*vX <<= 1
+		*vX = source << 1
+		*status = source >> 7 //Shift Left bit unto status
+	}
+}
+
+func statusFlag(condition bool) byte {
+	if condition {
+		return 1
+	}
+	return 0
+}
+
+//VIP logic instructions clobber VF as a side effect
+func resetStatus(status *byte, operation Operation) Operation {
+	return func() {
+		operation()
+		*status = 0
 	}
 }
 
@@ -147,9 +161,9 @@ func randByteMasked(rand *rand.Rand, vX *byte, mask byte) Operation {
 	}
 }
 
-func draw(display *Display, sprite []byte, vX byte, vY byte, status *byte) Operation {
+func draw(display *Display, sprite []byte, vX byte, vY byte, wrap bool, status *byte) Operation {
 	return func() {
-		if display.drawSprite(sprite, vX, vY) {
+		if display.drawSprite(sprite, vX, vY, wrap) {
 			*status = 1
 		} else {
 			*status = 0
@@ -157,25 +171,15 @@ func draw(display *Display, sprite []byte, vX byte, vY byte, status *byte) Opera
 	}
 }
 
-func loadKeyPress(cpu *cpu, vX *byte, keys *Input) Operation {
-	cpu.isWaitingForInput = true
-	initialKeys := *keys
+//FX0A, the first execution starts the wait and the cpu keeps executing it until a key is resolved
+func loadKeyPress(cpu *cpu, vX *byte) Operation {
 	return func() {
-		if *cpu.keys >= initialKeys { //We are waiting for a release which would be when a bit is unset
-			initialKeys = *cpu.keys //Reset keys to check against as additional keys could be pressed
-		} else {
-			keysReleased := ^(^initialKeys | *cpu.keys) //Bitmagic or NOTImplication
-			for i := byte(0); i < numKeys; i++ {
-				if keysReleased.checkKey(0) { //Check first key
-					*vX = i
-
-					fmt.Printf("input:%v\n", i)
-					cpu.isWaitingForInput = false
-					break
-				} else { //If not shift and then check again
-					keysReleased >>= 1
-				}
-			}
+		if !cpu.isWaitingForInput {
+			cpu.isWaitingForInput = true
+			cpu.keyWait.start()
+		} else if cpu.keyWait.resolved {
+			*vX = cpu.keyWait.key
+			cpu.isWaitingForInput = false
 		}
 	}
 }
@@ -186,9 +190,15 @@ func addI(registerI *Address, vX byte) Operation {
 	}
 }
 
-func loadDigit(registerI *Address, vX byte) Operation {
+func loadDigit(registerI *Address, vX byte, fontLocation Address) Operation {
+	return func() {
+		*registerI = (Address(vX) * smallGlyphSize) + fontLocation
+	}
+}
+
+func loadLargeDigit(registerI *Address, vX byte, fontLocation Address) Operation {
 	return func() {
-		*registerI = (Address(vX) * 5) + digitSpriteLocation
+		*registerI = (Address(vX) * largeGlyphSize) + fontLocation
 	}
 }
 
@@ -201,18 +211,21 @@ func storeBCD(registerI Address, vX byte, memory *memory) Operation {
 	}
 }
 
-func storeRegisters(registers *[registerCount]byte, registerI Address, vX byte, memory *memory) Operation {
+//increment is added to I afterwards and depends on the memory quirks
+func storeRegisters(registers *[registerCount]byte, registerI *Address, vX byte, increment Address, memory *memory) Operation {
 	return func() {
 		for offset := Address(0); offset <= Address(vX); offset++ {
-			memory[registerI+offset] = registers[offset]
+			memory[*registerI+offset] = registers[offset]
 		}
+		*registerI += increment
 	}
 }
 
-func loadRegisters(registers *[registerCount]byte, registerI Address, vX byte, memory *memory) Operation {
+func loadRegisters(registers *[registerCount]byte, registerI *Address, vX byte, increment Address, memory *memory) Operation {
 	return func() {
 		for offset := Address(0); offset <= Address(vX); offset++ {
-			registers[offset] = memory[registerI+offset]
+			registers[offset] = memory[*registerI+offset]
 		}
+		*registerI += increment
 	}
 }
@@ -0,0 +1,107 @@
+package chip8
+
+import (
+	"testing"
+)
+
+//Result and flag of 8XYN from the specification in alu.go, hasFlag is false when VF is left alone
+func aluSpec(operation byte, vX byte, vY byte, quirks Quirks) (result byte, flag byte, hasFlag bool) {
+	shiftSource := vX
+	if quirks.ShiftVY {
+		shiftSource = vY
+	}
+
+	switch operation {
+	case 0x0:
+		return vY, 0, false
+	case 0x1:
+		return vX | vY, 0, quirks.ResetVF
+	case 0x2:
+		return vX & vY, 0, quirks.ResetVF
+	case 0x3:
+		return vX ^ vY, 0, quirks.ResetVF
+	case 0x4:
+		sum := int(vX) + int(vY)
+		return byte(sum), byte(sum >> 8), true
+	case 0x5:
+		if vX >= vY {
+			return vX - vY, 1, true
+		}
+		return vX - vY, 0, true
+	case 0x6:
+		return shiftSource >> 1, shiftSource & 1, true
+	case 0x7:
+		if vY >= vX {
+			return vY - vX, 1, true
+		}
+		return vY - vX, 0, true
+	case 0xE:
+		return shiftSource << 1, shiftSource >> 7, true
+	}
+	panic("no 8XYN operation")
+}
+
+func TestALUExhaustive(t *testing.T) {
+	const oldFlag = 0xAA //VF before each instruction when it isn't an operand
+	operations := []byte{0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0xE}
+	registers := []struct{ x, y byte }{{1, 2}, {0xF, 2}, {1, 0xF}, {0xF, 0xF}}
+
+	system := createNewSystem(nil)
+	cpu := &system.cpu
+	for _, quirks := range []Quirks{{}, {ShiftVY: true, ResetVF: true}} {
+		cpu.quirks = quirks
+		for _, operation := range operations {
+			for _, register := range registers {
+				opcode := Instruction(0x8000) | Instruction(register.x)<<8 | Instruction(register.y)<<4 | Instruction(operation)
+				failures := 0
+				for a := 0; a <= 0xFF; a++ {
+					for b := 0; b <= 0xFF; b++ {
+						vX, vY, before := byte(a), byte(b), byte(oldFlag)
+						//An operand in VF is also the flag before the instruction, both operands are VF for X=Y=F
+						switch {
+						case register.x == 0xF && register.y == 0xF:
+							if a != b {
+								continue
+							}
+							before = vX
+						case register.x == 0xF:
+							before = vX
+						case register.y == 0xF:
+							before = vY
+						}
+
+						cpu.Registers[statusRegister] = before
+						cpu.Registers[register.x] = vX
+						cpu.Registers[register.y] = vY
+						execute, err := cpu.decode(opcode)
+						if err != nil {
+							t.Fatal(err)
+						}
+						execute()
+
+						result, flag, hasFlag := aluSpec(operation, vX, vY, quirks)
+						expectedX, expectedF := result, flag
+						if !hasFlag {
+							expectedF = before
+						}
+						if register.x == 0xF {
+							//The flag is written last
+							if hasFlag {
+								expectedX = flag
+							} else {
+								expectedF = result
+							}
+						}
+						if cpu.Registers[register.x] != expectedX || cpu.Registers[statusRegister] != expectedF {
+							if failures < 5 {
+								t.Errorf("FAIL %.4X quirks=%+v VX=%v VY=%v: V%X=%v VF=%v (expected %v and %v)",
+									uint16(opcode), quirks, vX, vY, register.x, cpu.Registers[register.x], cpu.Registers[statusRegister], expectedX, expectedF)
+							}
+							failures++
+						}
+					}
+				}
+			}
+		}
+	}
+}
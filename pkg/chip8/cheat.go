@@ -0,0 +1,65 @@
+package chip8
+
+/*
+CHEATS
+A frozen address is written at the end of every frame so the rom can't change it for long
+A poke is written once when it is first set and then left to the rom, setting it again with the same value leaves memory alone
+Resetting the system writes every poke again as the rom starts over with fresh memory
+*/
+
+type Cheat struct {
+	Address Address
+	Value   byte
+	Freeze  bool
+}
+
+//Replaces the cheats, freezes and pokes that weren't in the last set are written straight away
+func (system *Chip8) SetCheats(cheats []Cheat) error {
+	for _, cheat := range cheats {
+		if err := system.ram.checkRange("cheat", cheat.Address, 1); err != nil {
+			return err
+		}
+	}
+
+	previous := system.cheats
+	system.cheats = append([]Cheat{}, cheats...)
+	for _, cheat := range cheats {
+		if cheat.Freeze || !containsCheat(previous, cheat) {
+			system.ram[cheat.Address] = cheat.Value
+		}
+	}
+	return nil
+}
+
+func containsCheat(cheats []Cheat, cheat Cheat) bool {
+	for _, other := range cheats {
+		if other == cheat {
+			return true
+		}
+	}
+	return false
+}
+
+//Channel for cheats changed while the system runs, must be called before Run
+//Cheats it rejects are reported in Snapshot.CheatError and the last ones it took stay in place
+func (system *Chip8) CheatChannel() chan<- []Cheat {
+	if system.cheatChannel == nil {
+		system.cheatChannel = make(chan []Cheat, channelBuffer)
+	}
+	return system.cheatChannel
+}
+
+func (system *Chip8) applyFrozen() {
+	for _, cheat := range system.cheats {
+		if cheat.Freeze {
+			system.ram[cheat.Address] = cheat.Value
+		}
+	}
+}
+
+//Writes every cheat, pokes included, after a reset has cleared memory
+func (system *Chip8) applyCheats() {
+	for _, cheat := range system.cheats {
+		system.ram[cheat.Address] = cheat.Value
+	}
+}
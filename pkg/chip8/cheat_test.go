@@ -0,0 +1,70 @@
+package chip8
+
+import "testing"
+
+func TestCheats(t *testing.T) {
+	//0x200 LD I, 0x300; 0x202 LD V0, 0x01; 0x204 LD [I], V0; 0x206 JP 0x202
+	system := createNewSystem([]byte{0xA3, 0x00, 0x60, 0x01, 0xF0, 0x55, 0x12, 0x02})
+	if err := system.SetCheats([]Cheat{{Address: 0x300, Value: 0x09, Freeze: true}, {Address: 0x301, Value: 0x05}}); err != nil {
+		t.Fatal(err)
+	}
+	if system.ram[0x300] != 0x09 || system.ram[0x301] != 0x05 {
+		t.Errorf("FAIL memory=%X (expected 09 05 as soon as the cheats are set)", system.ram[0x300:0x302])
+	}
+
+	system.ram[0x301] = 0x04
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.ram[0x300] != 0x09 || system.ram[0x301] != 0x04 {
+		t.Errorf("FAIL memory=%X after a frame (expected the freeze to hold and the poke to be left alone)", system.ram[0x300:0x302])
+	}
+
+	//Setting the same poke again leaves the rom's value, a changed one is written
+	system.SetCheats([]Cheat{{Address: 0x300, Value: 0x09, Freeze: true}, {Address: 0x301, Value: 0x05}})
+	if system.ram[0x301] != 0x04 {
+		t.Errorf("FAIL [0x301]=0x%.2X after setting the poke again (expected the rom's 0x04)", system.ram[0x301])
+	}
+	system.SetCheats([]Cheat{{Address: 0x300, Value: 0x09, Freeze: true}, {Address: 0x301, Value: 0x06}})
+	if system.ram[0x301] != 0x06 {
+		t.Errorf("FAIL [0x301]=0x%.2X after changing the poke (expected 0x06)", system.ram[0x301])
+	}
+
+	//Reset starts the rom again with the pokes written into fresh memory
+	system.ram[0x301] = 0x04
+	system.reset()
+	if system.ram[0x300] != 0x09 || system.ram[0x301] != 0x06 {
+		t.Errorf("FAIL memory=%X after a reset (expected 09 06)", system.ram[0x300:0x302])
+	}
+
+	system.SetCheats(nil)
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.ram[0x300] != 0x01 {
+		t.Errorf("FAIL [0x300]=0x%.2X with no cheats (expected the rom's 0x01)", system.ram[0x300])
+	}
+
+	if err := system.SetCheats([]Cheat{{Address: 0x300, Value: 1}, {Address: RamSize, Value: 1}}); err == nil || system.ram[0x300] != 0x01 {
+		t.Errorf("FAIL cheat past the end of memory (expected an error and nothing written)")
+	}
+}
+
+//Cheats outside memory are reported in the snapshots, the last good ones stay and the system keeps running
+func TestRunKeepsGoingAfterRejectedCheats(t *testing.T) {
+	system, displays, _, commands := New()
+	system.LoadProgram([]byte{0x12, 0x00})
+	snapshots, _ := system.DebugChannels()
+	cheats := system.CheatChannel()
+	startRun(t, system, displays)
+
+	cheats <- []Cheat{{Address: 0x300, Value: 0xAB, Freeze: true}}
+	cheats <- []Cheat{{Address: 0x301, Value: 0xCD}, {Address: RamSize, Value: 1}}
+	waitForSnapshot(t, snapshots, func(snapshot Snapshot) bool {
+		return snapshot.CheatError != nil && snapshot.Memory[0x300] == 0xAB && snapshot.Memory[0x301] == 0
+	})
+
+	cheats <- []Cheat{{Address: 0x301, Value: 0xCD}}
+	waitForSnapshot(t, snapshots, func(snapshot Snapshot) bool { return snapshot.CheatError == nil && snapshot.Memory[0x301] == 0xCD })
+	commands <- CommandPowerOff
+}
@@ -137,13 +137,14 @@ func TestAdd(t *testing.T) {
 	program := []byte{0x72, 0x1E, 0x73, 0xFF}
 	system := createNewSystem(program)
 
+	//7XNN never touches VF, it starts as 0xF
 	t.Run("No Overflow", func(t *testing.T) {
 		err := system.cpu.cycle()
 		if err != nil {
 			t.Error(err)
 		} else {
-			if system.cpu.Registers[2] != 0x20 || system.cpu.Registers[statusRegister] != 0 {
-				t.Errorf("FAIL v2=0x%.3X (expected 0x20) status=%v (expected 0)", system.cpu.Registers[2], system.cpu.Registers[statusRegister])
+			if system.cpu.Registers[2] != 0x20 || system.cpu.Registers[statusRegister] != 0xF {
+				t.Errorf("FAIL v2=0x%.3X (expected 0x20) status=%v (expected 15)", system.cpu.Registers[2], system.cpu.Registers[statusRegister])
 			}
 		}
 	})
@@ -153,8 +154,8 @@ func TestAdd(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		} else {
-			if system.cpu.Registers[3] != 0x02 || system.cpu.Registers[statusRegister] != 1 {
-				t.Errorf("FAIL v2=0x%.3X (expected 0x20) status=%v (expected 1)", system.cpu.Registers[2], system.cpu.Registers[statusRegister])
+			if system.cpu.Registers[3] != 0x02 || system.cpu.Registers[statusRegister] != 0xF {
+				t.Errorf("FAIL v3=0x%.3X (expected 0x02) status=%v (expected 15)", system.cpu.Registers[3], system.cpu.Registers[statusRegister])
 			}
 		}
 	})
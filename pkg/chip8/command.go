@@ -0,0 +1,88 @@
+package chip8
+
+//Commands control the running system from a frontend
+type Command int
+
+const (
+	CommandPause     Command = iota //Toggles pause
+	CommandReset                    //Restarts the loaded program
+	CommandSaveState                //Saves the system into the state slot
+	CommandLoadState                //Restores the state slot if a state was saved
+	CommandSpeedUp                  //Toggles running several frames per tick
+	CommandPowerOff                 //Stops Run
+	CommandStep                     //Runs one instruction while paused
+)
+
+type savedState struct {
+	cpu     cpu
+	ram     memory
+	display Display
+}
+
+func (system *Chip8) handleCommand(command Command) error {
+	switch command {
+	case CommandPause:
+		system.isPaused = !system.isPaused
+		system.isResuming = !system.isPaused
+	case CommandReset:
+		system.reset()
+	case CommandSaveState:
+		system.saveState()
+	case CommandLoadState:
+		system.loadState()
+	case CommandSpeedUp:
+		if system.speed == 1 {
+			system.speed = speedUpFactor
+		} else {
+			system.speed = 1
+		}
+	case CommandPowerOff:
+		system.IsRunning = false
+	case CommandStep:
+		if system.isPaused {
+			return system.Step()
+		}
+	}
+	return nil
+}
+
+//Clears the machine and reloads the program, observers, quirks and cheats are kept
+func (system *Chip8) reset() {
+	observers, quirks := system.cpu.observers, system.cpu.quirks
+
+	system.cpu = cpu{observers: observers, quirks: quirks}
+	system.ram = memory{}
+	system.ram.loadProgam(system.program) //Already fit when it was first loaded
+	system.applyCheats()
+	system.display.clearScreen()
+	system.cpu.initialize(&system.ram, &system.input, &system.display)
+	system.loadFont()
+	system.cycleDebt, system.isFrameOpen = 0, false
+	if system.isSeeded {
+		system.Seed(system.seed)
+	}
+	system.notifyReset()
+}
+
+func (system *Chip8) saveState() {
+	system.savedState = &savedState{
+		cpu:     system.cpu,
+		ram:     system.ram,
+		display: system.display,
+	}
+}
+
+func (system *Chip8) loadState() {
+	if system.savedState == nil {
+		return
+	}
+	observers, quirks := system.cpu.observers, system.cpu.quirks
+
+	system.cpu = system.savedState.cpu
+	system.cpu.observers, system.cpu.quirks = observers, quirks
+	system.ram = system.savedState.ram
+	system.display = system.savedState.display
+	system.display.hasChanged = true
+	system.display.hasDrawn = true
+	system.notifyReset()
+}
@@ -0,0 +1,55 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	system := createNewSystem([]byte{0x60, 0x05, 0x70, 0x01, 0x12, 0x02})
+	cycle(t, system)
+	system.handleCommand(CommandSaveState)
+
+	cycle(t, system)
+	cycle(t, system)
+	system.ram[0x300] = 0xAA
+	if system.cpu.Registers[0] != 0x06 {
+		t.Fatalf("FAIL v0=0x%X (expected 0x06)", system.cpu.Registers[0])
+	}
+
+	system.handleCommand(CommandLoadState)
+	if system.cpu.Registers[0] != 0x05 || system.cpu.programCounter != 0x202 || system.ram[0x300] != 0 {
+		t.Errorf("FAIL v0=0x%X (expected 0x05) pc=0x%.3X (expected 0x202) [0x300]=0x%X (expected 0)",
+			system.cpu.Registers[0], system.cpu.programCounter, system.ram[0x300])
+	}
+}
+
+type resetCounter struct {
+	resets int
+}
+
+func (counter *resetCounter) ObserveFrame(frame uint64) {}
+func (counter *resetCounter) ObserveReset()             { counter.resets++ }
+
+func TestReset(t *testing.T) {
+	system := createNewSystem([]byte{0x60, 0x05, 0x12, 0x02})
+	system.SetQuirks(Quirks{KeyWaitOnPress: true})
+	counter := &resetCounter{}
+	system.AddFrameObserver(counter)
+	cycle(t, system)
+	cycle(t, system)
+
+	system.handleCommand(CommandReset)
+	if system.cpu.Registers[0] != 0 || system.cpu.programCounter != initialPC || system.ram[initialPC] != 0x60 {
+		t.Errorf("FAIL v0=0x%X (expected 0) pc=0x%.3X (expected 0x200) [0x200]=0x%X (expected 0x60)",
+			system.cpu.Registers[0], system.cpu.programCounter, system.ram[initialPC])
+	}
+	if !system.cpu.quirks.KeyWaitOnPress {
+		t.Errorf("FAIL quirks were not kept across reset")
+	}
+
+	system.handleCommand(CommandSaveState)
+	system.handleCommand(CommandLoadState)
+	if counter.resets != 2 {
+		t.Errorf("FAIL %v resets observed (expected the reset and the loaded state)", counter.resets)
+	}
+}
@@ -0,0 +1,219 @@
+package chip8
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+//Coverage records which instructions were executed and which way the skip instructions went
+//Add it to a system with AddObserver
+type Coverage struct {
+	counts   [RamSize]uint64
+	branches map[Address]*BranchCoverage
+
+	pendingSkip *BranchCoverage
+	skipAddress Address
+}
+
+//Outcomes of a skip instruction
+type BranchCoverage struct {
+	Taken    uint64
+	NotTaken uint64
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{branches: map[Address]*BranchCoverage{}}
+}
+
+func (coverage *Coverage) ObserveInstruction(state *State, opcode Instruction) {
+	if state.WaitingForKey {
+		return
+	}
+
+	//The address after a skip tells which way it went
+	if coverage.pendingSkip != nil {
+		if state.PC == coverage.skipAddress+2*instructionSize {
+			coverage.pendingSkip.Taken++
+		} else {
+			coverage.pendingSkip.NotTaken++
+		}
+		coverage.pendingSkip = nil
+	}
+
+	coverage.counts[state.PC]++
+	if isSkip(opcode) {
+		branch, ok := coverage.branches[state.PC]
+		if !ok {
+			branch = &BranchCoverage{}
+			coverage.branches[state.PC] = branch
+		}
+		coverage.pendingSkip = branch
+		coverage.skipAddress = state.PC
+	}
+}
+
+func (coverage *Coverage) Count(address Address) uint64 {
+	return coverage.counts[address]
+}
+
+//Returns nil if the address was never executed as a skip
+func (coverage *Coverage) Branch(address Address) *BranchCoverage {
+	return coverage.branches[address]
+}
+
+func isSkip(opcode Instruction) bool {
+	switch OpcodeClass(opcode) {
+	case "3XNN", "4XNN", "5XY0", "9XY0", "EX9E", "EXA1":
+		return true
+	}
+	return false
+}
+
+//One line of the annotated listing
+type CoverageLine struct {
+	Address  Address
+	Opcode   Instruction
+	Mnemonic string
+	Count    uint64
+	Branch   *BranchCoverage
+}
+
+func (line CoverageLine) Executed() bool {
+	return line.Count > 0
+}
+
+//A skip is partial when only one of its outcomes was seen
+func (line CoverageLine) Partial() bool {
+	return line.Branch != nil && (line.Branch.Taken == 0 || line.Branch.NotTaken == 0)
+}
+
+//Totals over a listing
+type CoverageSummary struct {
+	Instructions        int
+	CoveredInstructions int
+	Branches            int //Each skip has two outcomes
+	CoveredBranches     int
+}
+
+//Walks the program two bytes at a time, realigning when code was executed at an odd address
+func (coverage *Coverage) Lines(program []byte) []CoverageLine {
+	lines := []CoverageLine{}
+	for offset := 0; offset+1 < len(program); {
+		address := Address(programStart + offset)
+		if coverage.counts[address] == 0 && coverage.counts[address+1] > 0 {
+			offset++
+			continue
+		}
+
+		opcode := Instruction(program[offset])<<8 | Instruction(program[offset+1])
+		lines = append(lines, CoverageLine{
+			Address:  address,
+			Opcode:   opcode,
+			Mnemonic: Disassemble(opcode),
+			Count:    coverage.counts[address],
+			Branch:   coverage.branches[address],
+		})
+		offset += instructionSize
+	}
+	return lines
+}
+
+func summarize(lines []CoverageLine) CoverageSummary {
+	summary := CoverageSummary{Instructions: len(lines)}
+	for _, line := range lines {
+		if line.Executed() {
+			summary.CoveredInstructions++
+		}
+		if isSkip(line.Opcode) {
+			summary.Branches += 2
+			if line.Branch != nil {
+				if line.Branch.Taken > 0 {
+					summary.CoveredBranches++
+				}
+				if line.Branch.NotTaken > 0 {
+					summary.CoveredBranches++
+				}
+			}
+		}
+	}
+	return summary
+}
+
+func (summary CoverageSummary) String() string {
+	return fmt.Sprintf("instructions: %v/%v (%.1f%%) branches: %v/%v (%.1f%%)",
+		summary.CoveredInstructions, summary.Instructions, percentOf(summary.CoveredInstructions, summary.Instructions),
+		summary.CoveredBranches, summary.Branches, percentOf(summary.CoveredBranches, summary.Branches))
+}
+
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+//Writes a disassembly of program with execution counts in the gcov style
+//Never executed lines are marked with ##### and partially covered skips with a !
+func (coverage *Coverage) WriteListing(writer io.Writer, program []byte) error {
+	report := &reportWriter{writer: writer}
+	lines := coverage.Lines(program)
+
+	for _, line := range lines {
+		count := "#####"
+		if line.Executed() {
+			count = fmt.Sprint(line.Count)
+		}
+		marker := " "
+		if line.Partial() {
+			marker = "!"
+		}
+
+		report.printf("%10s %s 0x%.3X  %.4X  %-16s", count, marker, line.Address, uint16(line.Opcode), line.Mnemonic)
+		if line.Branch != nil {
+			report.printf(" taken %v, not taken %v", line.Branch.Taken, line.Branch.NotTaken)
+		}
+		report.printf("\n")
+	}
+	report.printf("\n%v\n", summarize(lines))
+	return report.err
+}
+
+//Writes the listing as a standalone html page
+func (coverage *Coverage) WriteHTML(writer io.Writer, title string, program []byte) error {
+	lines := coverage.Lines(program)
+	return coverageTemplate.Execute(writer, struct {
+		Title   string
+		Summary CoverageSummary
+		Lines   []CoverageLine
+	}{title, summarize(lines), lines})
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} coverage</title>
+<style>
+body { font-family: monospace; background: #fff; }
+table { border-collapse: collapse; }
+td { padding: 0 0.75em; }
+.covered { background: #c8f0c8; }
+.uncovered { background: #f0c8c8; }
+.partial { background: #f0e6b4; }
+.count { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Summary}}</p>
+<table>
+<tr><th>count</th><th>address</th><th>opcode</th><th>instruction</th><th>branches</th></tr>
+{{range .Lines}}<tr class="{{if .Partial}}partial{{else if .Executed}}covered{{else}}uncovered{{end}}">
+<td class="count">{{.Count}}</td><td>{{printf "0x%.3X" .Address}}</td><td>{{printf "%.4X" .Opcode}}</td><td>{{.Mnemonic}}</td>
+<td>{{with .Branch}}taken {{.Taken}}, not taken {{.NotTaken}}{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
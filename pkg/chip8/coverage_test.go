@@ -0,0 +1,47 @@
+package chip8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCoverageBranches(t *testing.T) {
+	//0x200 SE V2, 0x02 (taken), 0x204 SE V2, 0x03 (not taken), 0x206 JP 0x206
+	program := []byte{0x32, 0x02, 0x00, 0xE0, 0x32, 0x03, 0x12, 0x06, 0x00, 0xE0}
+	system := createNewSystem(program)
+	coverage := NewCoverage()
+	system.AddObserver(coverage)
+
+	for i := 0; i < 4; i++ {
+		if err := system.cpu.cycle(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if branch := coverage.Branch(0x200); branch == nil || branch.Taken != 1 || branch.NotTaken != 0 {
+		t.Errorf("FAIL branch[0x200]=%+v (expected taken 1)", branch)
+	}
+	if branch := coverage.Branch(0x204); branch == nil || branch.Taken != 0 || branch.NotTaken != 1 {
+		t.Errorf("FAIL branch[0x204]=%+v (expected not taken 1)", branch)
+	}
+	if coverage.Count(0x202) != 0 || coverage.Count(0x206) != 2 {
+		t.Errorf("FAIL count[0x202]=%v (expected 0) count[0x206]=%v (expected 2)", coverage.Count(0x202), coverage.Count(0x206))
+	}
+
+	var listing bytes.Buffer
+	if err := coverage.WriteListing(&listing, program); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(listing.String(), "##### ") || !strings.Contains(listing.String(), "instructions: 3/5 (60.0%) branches: 2/4 (50.0%)") {
+		t.Errorf("FAIL listing:\n%v", listing.String())
+	}
+
+	var html bytes.Buffer
+	if err := coverage.WriteHTML(&html, "test", program); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(html.String(), `class="partial"`) != 2 {
+		t.Errorf("FAIL html does not mark both skips as partial:\n%v", html.String())
+	}
+}
@@ -29,15 +29,23 @@ type cpu struct {
 	RegisterI     Address //Register used for addresses
 
 	//Internal data
-	programCounter    Address
-	stackPointer      byte
-	stack             [maxSubroutineLevel]Address
-	ram               *memory
-	keys              *Input
-	display           *Display
-	isWaitingForInput bool
+	programCounter     Address
+	stackPointer       byte
+	stack              [maxSubroutineLevel]Address
+	ram                *memory
+	keys               *Input
+	display            *Display
+	isWaitingForInput  bool
+	keyWait            keyWait
+	isWaitingForVBlank bool //Set by DXYN with the display wait quirk, the rest of the frame is skipped
+	quirks             Quirks
+	fontLocation       Address //Small hex digits for FX29
+	largeFontLocation  Address //Large decimal digits for FX30
 
-	execute Operation
+	instructionAddress Address //Address of the instruction being executed
+	opcode             Instruction
+	execute            Operation
+	observers          []InstructionObserver
 
 	random *rand.Rand
 }
@@ -54,16 +62,21 @@ func (cpu *cpu) initialize(ram *memory, keys *Input, display *Display) {
 func (cpu *cpu) cycle() error {
 	if !cpu.isWaitingForInput {
 		//fetch
+		cpu.instructionAddress = cpu.programCounter
 		opcode, err := cpu.fetch()
 		if err != nil {
 			return err
 		}
+		cpu.opcode = opcode
+		cpu.notifyObservers()
 
 		//decode
 		cpu.execute, err = cpu.decode(opcode)
 		if err != nil {
 			return err
 		}
+	} else {
+		cpu.notifyObservers()
 	}
 	//execute
 	cpu.execute()
@@ -72,6 +85,9 @@ func (cpu *cpu) cycle() error {
 
 func (cpu *cpu) fetch() (Instruction, error) {
 	address := cpu.programCounter
+	if err := cpu.ram.checkRange("fetch", address, instructionSize); err != nil {
+		return 0, err
+	}
 	cpu.programCounter += 2
 	result := Instruction(cpu.ram[address+1])
 	result |= Instruction(cpu.ram[address]) << 8
@@ -83,8 +99,10 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 
 	switch startingNibble {
 	case 0x0000:
-		lastNibble := byte(opcode & 0x00FF) //Mask to solo the last nibble
-		return decode0(cpu, lastNibble)
+		if opcode&0x0F00 == 0 { //0NNN machine code routines aren't supported
+			lastNibble := byte(opcode & 0x00FF) //Mask to solo the last nibble
+			return decode0(cpu, lastNibble)
+		}
 	case 0x1000: //JP instruction
 		return jump(&cpu.programCounter, maskAddress(opcode)), nil
 	case 0x2000: //CALL instruction
@@ -96,8 +114,10 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 		return skipInstructionIfTrue(&cpu.programCounter,
 			cpu.Registers[maskXRegister(opcode)] != maskEndingByte(opcode)), nil
 	case 0x5000: //SE Skip if register equals register
-		return skipInstructionIfTrue(&cpu.programCounter,
-			cpu.Registers[maskXRegister(opcode)] == cpu.Registers[maskYRegister(opcode)]), nil
+		if opcode&0x000F == 0 {
+			return skipInstructionIfTrue(&cpu.programCounter,
+				cpu.Registers[maskXRegister(opcode)] == cpu.Registers[maskYRegister(opcode)]), nil
+		}
 	case 0x6000: //LD Load byte into register
 		return loadRegister(&cpu.Registers[maskXRegister(opcode)], maskEndingByte(opcode)), nil
 	case 0x7000: //ADD Adds byte into register
@@ -108,7 +128,7 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 		xRegister := &cpu.Registers[maskXRegister(opcode)]
 		yRegister := cpu.Registers[maskYRegister(opcode)]
 		lastNibble := opcode & 0x000F //Mask to solo the last nibble
-		op := decode8(&cpu.Registers[statusRegister], xRegister, yRegister, byte(lastNibble))
+		op := decode8(&cpu.Registers[statusRegister], xRegister, yRegister, byte(lastNibble), cpu.quirks)
 
 		//This is needed as not all 0x8xxx opcodes are valid
 		if op != nil {
@@ -122,15 +142,29 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 	case 0xA000: //LD Load address into I
 		return loadAddress(&cpu.RegisterI, maskAddress(opcode)), nil
 	case 0xB000: //JP Offset opcode address with register 0 and jump there
-		return jumpOffset(&cpu.programCounter, cpu.Registers[0], maskAddress(opcode)), nil
+		offsetRegister := byte(0)
+		if cpu.quirks.JumpVX {
+			offsetRegister = maskXRegister(opcode)
+		}
+		return jumpOffset(&cpu.programCounter, cpu.Registers[offsetRegister], maskAddress(opcode)), nil
 	case 0xC000: //RND load a register x with a random byte AND a byte mask
 		return randByteMasked(cpu.random, &cpu.Registers[maskXRegister(opcode)], maskEndingByte(opcode)), nil
 	case 0xD000: //DRW
 		xRegister := cpu.Registers[maskXRegister(opcode)]
 		yRegister := cpu.Registers[maskYRegister(opcode)]
 		lastNibble := byte(opcode & 0x000F) //Mask to solo the last nibble
+		if err := cpu.ram.checkRange("draw", cpu.RegisterI, int(lastNibble)); err != nil {
+			return nil, err
+		}
 
-		return draw(cpu.display, cpu.ram.getSprite(cpu.RegisterI, lastNibble), xRegister, yRegister, &cpu.Registers[statusRegister]), nil
+		op := draw(cpu.display, cpu.ram.getSprite(cpu.RegisterI, lastNibble), xRegister, yRegister, cpu.quirks.WrapSprites, &cpu.Registers[statusRegister])
+		if cpu.quirks.DisplayWait {
+			return func() {
+				op()
+				cpu.isWaitingForVBlank = true
+			}, nil
+		}
+		return op, nil
 	case 0xE000: //Keyboard functions
 		xRegister := cpu.Registers[maskXRegister(opcode)]
 		lastByte := byte(opcode & 0x00FF) //Mask to solo the last byte
@@ -143,6 +177,9 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 		}
 	case 0xF000:
 		lastByte := byte(opcode & 0x00FF) //Mask to solo the last byte
+		if err := checkMemoryAccess(cpu, maskXRegister(opcode), lastByte); err != nil {
+			return nil, err
+		}
 		op := decodeF(cpu, maskXRegister(opcode), lastByte)
 
 		//This is needed as not all 0xFxxx opcodes are valid
@@ -150,7 +187,7 @@ func (cpu *cpu) decode(opcode Instruction) (Operation, error) {
 			return op, nil
 		}
 	}
-	return nil, fmt.Errorf("decode error: 0x%X not implemented/supported", opcode)
+	return nil, fmt.Errorf("decode error: 0x%X %w", opcode, ErrUnsupportedOpcode)
 }
 
 func decode0(cpu *cpu, lastByte byte) (Operation, error) {
@@ -160,30 +197,44 @@ func decode0(cpu *cpu, lastByte byte) (Operation, error) {
 	case 0xEE: //RET Return from subroutine
 		return subroutineReturn(cpu)
 	}
-	return nil, fmt.Errorf("decode error: 0x00%X not implemented/supported", lastByte)
+	return nil, fmt.Errorf("decode error: 0x00%X %w", lastByte, ErrUnsupportedOpcode)
 }
 
 //Function to make decode 0x8xxx not cloud up the decode function
-func decode8(statusRegister *byte, xRegister *byte, yValue byte, lastByte byte) Operation {
+func decode8(statusRegister *byte, xRegister *byte, yValue byte, lastByte byte, quirks Quirks) Operation {
+	shiftSource := *xRegister
+	if quirks.ShiftVY {
+		shiftSource = yValue
+	}
+
 	switch lastByte {
 	case 0x0000: //LD Load register Y into register X
 		return loadRegister(xRegister, yValue)
 	case 0x0001: //OR Store registerX OR registerY into register X
+		if quirks.ResetVF {
+			return resetStatus(statusRegister, or(xRegister, yValue))
+		}
 		return or(xRegister, yValue)
 	case 0x0002: //AND Store registerX AND registerY into register X
+		if quirks.ResetVF {
+			return resetStatus(statusRegister, and(xRegister, yValue))
+		}
 		return and(xRegister, yValue)
 	case 0x0003: //XOR Store registerX XOR registerY into register X
+		if quirks.ResetVF {
+			return resetStatus(statusRegister, xor(xRegister, yValue))
+		}
 		return xor(xRegister, yValue)
 	case 0x0004: //ADD Store registerX + registerY into register X
 		return add(statusRegister, xRegister, yValue)
 	case 0x0005: //SUB Store registerX - registerY into register X
 		return subtract(statusRegister, xRegister, yValue)
 	case 0x0006: //SHR Store registerX >> 1 into register X
-		return shiftRight(statusRegister, xRegister)
+		return shiftRight(statusRegister, xRegister, shiftSource)
 	case 0x0007: //SUBN Store registerY - registerX into register X
 		return subtractN(statusRegister, xRegister, yValue)
 	case 0x000E: //SHL Store registerX << 1 into register X
-		return shiftLeft(statusRegister, xRegister)
+		return shiftLeft(statusRegister, xRegister, shiftSource)
 	}
 	return nil
 }
@@ -193,7 +244,7 @@ func decodeF(cpu *cpu, xIndex byte, lastByte byte) Operation {
 	case 0x07: //LD Load delay into register X
 		return loadRegister(&cpu.Registers[xIndex], cpu.DelayRegister)
 	case 0x0A: //LD Load Keypress
-		return loadKeyPress(cpu, &cpu.Registers[xIndex], cpu.keys)
+		return loadKeyPress(cpu, &cpu.Registers[xIndex])
 	case 0x15: //LD Load register X into delay
 		return loadRegister(&cpu.DelayRegister, cpu.Registers[xIndex])
 	case 0x18: //LD Load register X into sound
@@ -201,13 +252,28 @@ func decodeF(cpu *cpu, xIndex byte, lastByte byte) Operation {
 	case 0x1E: //ADD Add register X into I
 		return addI(&cpu.RegisterI, cpu.Registers[xIndex])
 	case 0x29: //LD Load location of digit sprite into I
-		return loadDigit(&cpu.RegisterI, cpu.Registers[xIndex])
+		return loadDigit(&cpu.RegisterI, cpu.Registers[xIndex], cpu.fontLocation)
+	case 0x30: //LD Load location of SUPER-CHIP large digit sprite into I
+		return loadLargeDigit(&cpu.RegisterI, cpu.Registers[xIndex], cpu.largeFontLocation)
 	case 0x33: //LD Store BCD representations of register x into I, I+1, I+2
 		return storeBCD(cpu.RegisterI, cpu.Registers[xIndex], cpu.ram)
 	case 0x55: //LD Store registers starting at memory location I
-		return storeRegisters(&cpu.Registers, cpu.RegisterI, xIndex, cpu.ram)
+		return storeRegisters(&cpu.Registers, &cpu.RegisterI, xIndex, cpu.quirks.memoryIncrement(xIndex), cpu.ram)
 	case 0x65: //LD Load registers from memory locations starting at location I
-		return loadRegisters(&cpu.Registers, cpu.RegisterI, xIndex, cpu.ram)
+		return loadRegisters(&cpu.Registers, &cpu.RegisterI, xIndex, cpu.quirks.memoryIncrement(xIndex), cpu.ram)
+	}
+	return nil
+}
+
+//FX33, FX55 and FX65 use memory from I onwards, which has to fit in memory
+func checkMemoryAccess(cpu *cpu, xIndex byte, lastByte byte) error {
+	switch lastByte {
+	case 0x33:
+		return cpu.ram.checkRange("bcd", cpu.RegisterI, 3)
+	case 0x55:
+		return cpu.ram.checkRange("store", cpu.RegisterI, int(xIndex)+1)
+	case 0x65:
+		return cpu.ram.checkRange("load", cpu.RegisterI, int(xIndex)+1)
 	}
 	return nil
 }
@@ -0,0 +1,166 @@
+package chip8
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+STEPPING
+Debuggers drive the system one instruction at a time instead of calling Run
+Steps are counted against frames the same way Run counts them, with TickRate instructions or the VIP's machine cycles,
+and the timers count down when the frame is used up
+*/
+
+func (system *Chip8) Step() error {
+	system.startFrame()
+	for system.isFrameDone { //The last frame overran by the whole of this one
+		system.finishFrame()
+		system.startFrame()
+	}
+	if err := system.runInstruction(); err != nil {
+		return err
+	}
+	if system.isFrameDone {
+		system.finishFrame()
+	}
+	return nil
+}
+
+//Frames finished since the system started
+func (system *Chip8) Frame() uint64 {
+	return system.frame
+}
+
+func (system *Chip8) State() State {
+	return system.cpu.state()
+}
+
+//Replaces the cpu state, an FX0A wait is left as it is
+func (system *Chip8) SetState(state State) error {
+	if state.SP > maxSubroutineLevel {
+		return fmt.Errorf("state error: stack pointer %v is past the %v levels of the stack", state.SP, maxSubroutineLevel)
+	}
+
+	cpu := &system.cpu
+	cpu.programCounter = state.PC
+	cpu.Registers = state.Registers
+	cpu.RegisterI = state.I
+	cpu.stackPointer = state.SP
+	cpu.stack = state.Stack
+	cpu.DelayRegister = state.Delay
+	cpu.SoundRegister = state.Sound
+	return nil
+}
+
+func (system *Chip8) ReadMemory(address Address, length int) ([]byte, error) {
+	if err := system.ram.checkRange("read", address, length); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, system.ram[address:int(address)+length]...), nil
+}
+
+//Copy of all of memory, cheaper than ReadMemory for observers that look at many bytes every frame
+func (system *Chip8) Memory() [RamSize]byte {
+	return system.ram
+}
+
+func (system *Chip8) WriteMemory(address Address, data []byte) error {
+	if err := system.ram.checkRange("write", address, len(data)); err != nil {
+		return err
+	}
+	copy(system.ram[address:], data)
+	return nil
+}
+
+/*
+DEBUGGER WINDOWS
+Frontends debug a running system over channels like the rest of its controls
+Run pauses before an instruction with a breakpoint in the middle of its frame, which carries on when it resumes or steps
+The instruction it resumes or steps from doesn't stop it again
+*/
+
+type DebugEventType int
+
+const (
+	DebugSetBreakpoint DebugEventType = iota
+	DebugClearBreakpoint
+	DebugWriteMemory
+)
+
+type DebugEvent struct {
+	Type    DebugEventType
+	Address Address
+	Data    []byte //Bytes written from Address by DebugWriteMemory
+}
+
+//Everything a debugger window shows
+type Snapshot struct {
+	Status
+	Memory      [RamSize]byte
+	Breakpoints []Address //Sorted
+	DebugError  error     //Why the last debug event was rejected, nil once one is applied
+	CheatError  error     //Why the last cheats sent to CheatChannel were rejected, nil once some are applied
+}
+
+//Channels for a debugger window, must be called before Run
+//Snapshots are sent like statuses and also straight after anything a debugger changes
+func (system *Chip8) DebugChannels() (<-chan Snapshot, chan<- DebugEvent) {
+	if system.snapshotChannel == nil {
+		system.snapshotChannel = make(chan Snapshot, 1)
+		system.debugChannel = make(chan DebugEvent, channelBuffer)
+	}
+	return system.snapshotChannel, system.debugChannel
+}
+
+func (system *Chip8) applyDebugEvent(event DebugEvent) error {
+	switch event.Type {
+	case DebugSetBreakpoint:
+		if system.breakpoints == nil {
+			system.breakpoints = map[Address]bool{}
+		}
+		system.breakpoints[event.Address] = true
+	case DebugClearBreakpoint:
+		delete(system.breakpoints, event.Address)
+	case DebugWriteMemory:
+		return system.WriteMemory(event.Address, event.Data)
+	}
+	return nil
+}
+
+//Checked before each instruction Run executes, pauses the system when it is at a breakpoint
+func (system *Chip8) hitBreakpoint() bool {
+	if system.isResuming || system.cpu.isWaitingForInput {
+		system.isResuming = false
+		return false
+	}
+	if system.breakpoints[system.cpu.programCounter] {
+		system.isPaused = true
+		return true
+	}
+	return false
+}
+
+func (system *Chip8) snapshot() Snapshot {
+	breakpoints := make([]Address, 0, len(system.breakpoints))
+	for address := range system.breakpoints {
+		breakpoints = append(breakpoints, address)
+	}
+	sort.Slice(breakpoints, func(i, j int) bool { return breakpoints[i] < breakpoints[j] })
+	return Snapshot{Status: system.status(), Memory: system.ram, Breakpoints: breakpoints, DebugError: system.debugError, CheatError: system.cheatError}
+}
+
+//Replaces a snapshot the debugger hasn't taken yet so it always gets the latest
+func (system *Chip8) sendSnapshot() {
+	if system.snapshotChannel == nil {
+		return
+	}
+	select {
+	case <-system.snapshotChannel:
+	default:
+	}
+	select {
+	case system.snapshotChannel <- system.snapshot():
+	default:
+	}
+}
@@ -0,0 +1,133 @@
+package chip8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakpoints(t *testing.T) {
+	//0x200 ADD V0, 1; 0x202 ADD V1, 1; 0x204 JP 0x200
+	system := createNewSystem([]byte{0x70, 0x01, 0x71, 0x01, 0x12, 0x00})
+	system.applyDebugEvent(DebugEvent{Type: DebugSetBreakpoint, Address: 0x202})
+
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if !system.isPaused || system.cpu.programCounter != 0x202 || system.cpu.Registers[0] != 0x01 {
+		t.Fatalf("FAIL paused=%v pc=0x%.3X v0=0x%X (expected paused at 0x202 after one instruction)", system.isPaused, system.cpu.programCounter, system.cpu.Registers[0])
+	}
+
+	system.handleCommand(CommandStep)
+	if !system.isPaused || system.cpu.programCounter != 0x204 || system.cpu.Registers[1] != 0x02 {
+		t.Errorf("FAIL paused=%v pc=0x%.3X v1=0x%X (expected one step to 0x204)", system.isPaused, system.cpu.programCounter, system.cpu.Registers[1])
+	}
+
+	//Resuming runs the loop once more before stopping again
+	system.handleCommand(CommandStep)
+	system.handleCommand(CommandStep)
+	system.handleCommand(CommandPause)
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if !system.isPaused || system.cpu.programCounter != 0x202 || system.cpu.Registers[0] != 0x03 {
+		t.Errorf("FAIL paused=%v pc=0x%.3X v0=0x%X (expected paused at 0x202 with v0=3)", system.isPaused, system.cpu.programCounter, system.cpu.Registers[0])
+	}
+
+	system.applyDebugEvent(DebugEvent{Type: DebugSetBreakpoint, Address: 0x204})
+	system.applyDebugEvent(DebugEvent{Type: DebugWriteMemory, Address: 0x300, Data: []byte{0xAB, 0xCD}})
+	snapshot := system.snapshot()
+	if len(snapshot.Breakpoints) != 2 || snapshot.Breakpoints[0] != 0x202 || snapshot.Breakpoints[1] != 0x204 {
+		t.Errorf("FAIL breakpoints=%v (expected [0x202 0x204])", snapshot.Breakpoints)
+	}
+	if snapshot.Memory[0x300] != 0xAB || snapshot.Memory[0x301] != 0xCD || !snapshot.IsPaused || snapshot.PC != 0x202 {
+		t.Errorf("FAIL snapshot memory=%X paused=%v pc=0x%.3X", snapshot.Memory[0x300:0x302], snapshot.IsPaused, snapshot.PC)
+	}
+
+	system.applyDebugEvent(DebugEvent{Type: DebugClearBreakpoint, Address: 0x202})
+	if err := system.applyDebugEvent(DebugEvent{Type: DebugWriteMemory, Address: 0xFFF, Data: []byte{1, 2}}); err == nil {
+		t.Errorf("FAIL writing past the end of memory (expected an error)")
+	}
+	if snapshot := system.snapshot(); len(snapshot.Breakpoints) != 1 {
+		t.Errorf("FAIL breakpoints=%v (expected [0x204])", snapshot.Breakpoints)
+	}
+}
+
+func TestBreakpointKeepsFrameOpen(t *testing.T) {
+	//0x200 ADD V0, 1; 0x202 ADD V1, 1; 0x204 JP 0x200
+	system := createNewSystem([]byte{0x70, 0x01, 0x71, 0x01, 0x12, 0x00})
+	system.Configure(Config{TickRate: 10})
+	system.cpu.DelayRegister = 5
+	system.applyDebugEvent(DebugEvent{Type: DebugSetBreakpoint, Address: 0x202})
+
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if !system.isPaused || system.frame != 0 || system.cpu.DelayRegister != 5 {
+		t.Fatalf("FAIL paused=%v frame=%v delay=%v (expected paused in frame 0 before the timers count down)", system.isPaused, system.frame, system.cpu.DelayRegister)
+	}
+
+	//Steps count towards the paused frame and the rest of it runs after continuing
+	system.handleCommand(CommandStep)
+	system.handleCommand(CommandStep)
+	system.applyDebugEvent(DebugEvent{Type: DebugClearBreakpoint, Address: 0x202})
+	system.handleCommand(CommandPause)
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.frame != 1 || system.cycles != 10 || system.cpu.DelayRegister != 4 {
+		t.Errorf("FAIL frame=%v cycles=%v delay=%v (expected one frame of 10 instructions and one count down)", system.frame, system.cycles, system.cpu.DelayRegister)
+	}
+}
+
+//A debugger edit outside memory is reported in the snapshots and the system keeps running
+func TestRunKeepsGoingAfterRejectedWrite(t *testing.T) {
+	system, displays, _, commands := New()
+	system.LoadProgram([]byte{0x12, 0x00})
+	snapshots, events := system.DebugChannels()
+	startRun(t, system, displays)
+
+	events <- DebugEvent{Type: DebugWriteMemory, Address: RamSize - 1, Data: []byte{1, 2}}
+	waitForSnapshot(t, snapshots, func(snapshot Snapshot) bool { return snapshot.DebugError != nil })
+
+	events <- DebugEvent{Type: DebugWriteMemory, Address: 0x300, Data: []byte{0xAB}}
+	waitForSnapshot(t, snapshots, func(snapshot Snapshot) bool { return snapshot.DebugError == nil && snapshot.Memory[0x300] == 0xAB })
+	commands <- CommandPowerOff
+}
+
+//Runs the system in the background, the test has to power it off and fails if Run returned an error
+func startRun(t *testing.T, system *Chip8, displays <-chan Display) {
+	done := make(chan error, 1)
+	go func() {
+		done <- system.Run()
+	}()
+	go func() {
+		for range displays {
+		}
+	}()
+
+	t.Cleanup(func() {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("FAIL Run err=%v (expected nil)", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("FAIL Run didn't stop after power off")
+		}
+	})
+}
+
+func waitForSnapshot(t *testing.T, snapshots <-chan Snapshot, isWanted func(Snapshot) bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case snapshot := <-snapshots:
+			if isWanted(snapshot) {
+				return
+			}
+		case <-timeout:
+			t.Fatal("FAIL timed out (expected a matching snapshot)")
+		}
+	}
+}
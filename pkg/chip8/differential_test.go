@@ -0,0 +1,221 @@
+package chip8
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/chip8/internal/reference"
+)
+
+const differentialFrames = 120
+
+//Instruction templates for random programs, the operand bits are filled in at random
+var randomInstructions = []struct {
+	base, operands Instruction
+}{
+	{0x00E0, 0}, {0x00EE, 0}, {0x1000, 0}, {0x2000, 0}, {0x3000, 0x0FFF}, {0x4000, 0x0FFF},
+	{0x5000, 0x0FF0}, {0x6000, 0x0FFF}, {0x7000, 0x0FFF}, {0x8000, 0x0FF0}, {0x8001, 0x0FF0},
+	{0x8002, 0x0FF0}, {0x8003, 0x0FF0}, {0x8004, 0x0FF0}, {0x8005, 0x0FF0}, {0x8006, 0x0FF0},
+	{0x8007, 0x0FF0}, {0x800E, 0x0FF0}, {0x9000, 0x0FF0}, {0xA000, 0x0FFF}, {0xB000, 0}, {0xC000, 0x0FFF},
+	{0xD000, 0x0FFF}, {0xE09E, 0x0F00}, {0xE0A1, 0x0F00}, {0xF007, 0x0F00}, {0xF00A, 0x0F00},
+	{0xF015, 0x0F00}, {0xF018, 0x0F00}, {0xF01E, 0x0F00}, {0xF029, 0x0F00}, {0xF030, 0x0F00},
+	{0xF033, 0x0F00}, {0xF055, 0x0F00}, {0xF065, 0x0F00},
+}
+
+//Jumps and calls land on an instruction in the program so random programs run for a while
+func randomProgram(random *rand.Rand, instructions int) []byte {
+	program := make([]byte, 0, instructions*instructionSize)
+	for i := 0; i < instructions; i++ {
+		template := randomInstructions[random.Intn(len(randomInstructions))]
+		opcode := template.base | Instruction(random.Intn(0x10000))&template.operands
+		switch template.base {
+		case 0x1000, 0x2000, 0xB000:
+			opcode |= Instruction(programStart + instructionSize*random.Intn(instructions))
+		}
+		program = append(program, byte(opcode>>8), byte(opcode))
+	}
+	return program
+}
+
+func newReference(system *Chip8) *reference.Machine {
+	machine, _ := reference.New(nil)
+	machine.Memory = system.ram
+	machine.FontLocation = uint16(system.cpu.fontLocation)
+	machine.LargeFontLocation = uint16(system.cpu.largeFontLocation)
+	quirks := system.cpu.quirks
+	machine.Options = reference.Options{
+		KeyWaitOnPress: quirks.KeyWaitOnPress,
+		ShiftVY:        quirks.ShiftVY,
+		IncrementI:     quirks.IncrementI,
+		IncrementIByX:  quirks.IncrementIByX,
+		ResetVF:        quirks.ResetVF,
+		JumpVX:         quirks.JumpVX,
+		DisplayWait:    quirks.DisplayWait,
+		WrapSprites:    quirks.WrapSprites,
+	}
+	return machine
+}
+
+//Whether an instruction can change memory or the display, only then are they compared to keep the test quick
+func writesMemory(opcode Instruction) bool {
+	switch opcode & 0xF000 {
+	case 0x0000, 0xD000:
+		return true
+	case 0xF000:
+		lastByte := opcode & 0x00FF
+		return lastByte == 0x33 || lastByte == 0x55
+	}
+	return false
+}
+
+//First difference between the core and the reference, empty when they match
+func divergence(system *Chip8, machine *reference.Machine) string {
+	cpu := &system.cpu
+	for i, value := range cpu.Registers {
+		if value != machine.V[i] {
+			return fmt.Sprintf("V%X=0x%.2X (reference 0x%.2X)", i, value, machine.V[i])
+		}
+	}
+	switch {
+	case uint16(cpu.RegisterI) != machine.I:
+		return fmt.Sprintf("I=0x%.4X (reference 0x%.4X)", uint16(cpu.RegisterI), machine.I)
+	case uint16(cpu.programCounter) != machine.PC:
+		return fmt.Sprintf("PC=0x%.4X (reference 0x%.4X)", uint16(cpu.programCounter), machine.PC)
+	case int(cpu.stackPointer) != machine.SP:
+		return fmt.Sprintf("SP=%v (reference %v)", cpu.stackPointer, machine.SP)
+	case cpu.DelayRegister != machine.DT:
+		return fmt.Sprintf("DT=%v (reference %v)", cpu.DelayRegister, machine.DT)
+	case cpu.SoundRegister != machine.ST:
+		return fmt.Sprintf("ST=%v (reference %v)", cpu.SoundRegister, machine.ST)
+	case cpu.isWaitingForInput != machine.WaitingForKey:
+		return fmt.Sprintf("waiting for key=%v (reference %v)", cpu.isWaitingForInput, machine.WaitingForKey)
+	}
+	for i := 0; i < machine.SP; i++ {
+		if uint16(cpu.stack[i]) != machine.Stack[i] {
+			return fmt.Sprintf("stack[%v]=0x%.3X (reference 0x%.3X)", i, uint16(cpu.stack[i]), machine.Stack[i])
+		}
+	}
+	if !writesMemory(cpu.opcode) {
+		return ""
+	}
+	for address, value := range system.ram {
+		if value != machine.Memory[address] {
+			return fmt.Sprintf("[0x%.3X]=0x%.2X (reference 0x%.2X)", address, value, machine.Memory[address])
+		}
+	}
+	grid := system.display.ToBoolArray()
+	for y := range grid {
+		for x, isOn := range grid[y] {
+			if isOn != machine.Display[y][x] {
+				return fmt.Sprintf("dot (%v,%v)=%v (reference %v)", x, y, isOn, machine.Display[y][x])
+			}
+		}
+	}
+	return ""
+}
+
+//Runs a program through the core and the reference in lockstep with random key events
+//Returns a description of the first instruction where they disagree, or an empty string
+func runDifferential(program []byte, config Config, seed int64) (string, error) {
+	system, _, _, _, err := NewWithConfig(config)
+	if err != nil {
+		return "", err
+	}
+	if err := system.LoadProgram(program); err != nil {
+		return "", err
+	}
+	machine := newReference(system)
+	system.Seed(seed)
+	random := rand.New(rand.NewSource(seed))
+	machine.Random = func() byte { return byte(random.Uint64() >> 56) }
+
+	keys := rand.New(rand.NewSource(seed))
+	step := 0
+	for frame := 0; frame < differentialFrames; frame++ {
+		if keys.Intn(4) == 0 {
+			key, pressed := byte(keys.Intn(numKeys)), keys.Intn(2) == 0
+			system.applyKeyEvent(KeyEvent{Key: key, Pressed: pressed})
+			machine.KeyEvent(key, pressed)
+		}
+
+		system.cpu.isWaitingForVBlank = false //As stepFrame does
+		for i := 0; i < system.cyclesPerFrame && !system.cpu.isWaitingForVBlank; i++ {
+			address := system.cpu.programCounter
+			err, referenceErr := system.cpu.cycle(), machine.Step()
+			if (err != nil) != (referenceErr != nil) {
+				return fmt.Sprintf("step %v at 0x%.3X: error %v (reference %v)", step, uint16(address), err, referenceErr), nil
+			}
+			if err != nil {
+				return "", nil //Both stopped
+			}
+			if difference := divergence(system, machine); difference != "" {
+				return fmt.Sprintf("step %v at 0x%.3X opcode %.4X: %v", step, uint16(address), uint16(system.cpu.opcode), difference), nil
+			}
+			step++
+		}
+		if system.cpu.isWaitingForVBlank != machine.WaitingForVBlank {
+			return fmt.Sprintf("frame %v: display wait=%v (reference %v)", frame, system.cpu.isWaitingForVBlank, machine.WaitingForVBlank), nil
+		}
+		system.countDownTimers()
+		machine.TickTimers()
+	}
+	return "", nil
+}
+
+var differentialConfigs = []struct {
+	name   string
+	config Config
+}{
+	{"default", DefaultConfig()},
+	{"vip", Config{Quirks: VIPQuirks()}},
+	{"schip", Config{Quirks: Quirks{KeyWaitOnPress: true, IncrementIByX: true, JumpVX: true, WrapSprites: true}}},
+}
+
+func TestDifferentialRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		program := randomProgram(random, 64)
+		for _, test := range differentialConfigs {
+			difference, err := runDifferential(program, test.config, int64(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if difference != "" {
+				t.Fatalf("FAIL program %v %v: %v\n%X", i, test.name, difference, program)
+			}
+		}
+	}
+}
+
+//The vendored test suite roms are included as they cover the most opcodes
+func TestDifferentialROMs(t *testing.T) {
+	roms := []string{}
+	for _, pattern := range []string{"*.ch8", filepath.Join("suite", "*.ch8")} {
+		matches, err := filepath.Glob(filepath.Join("testdata", "roms", pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		roms = append(roms, matches...)
+	}
+	if len(roms) == 0 {
+		t.Fatal("FAIL no roms found in testdata/roms")
+	}
+	for _, rom := range roms {
+		program, err := ioutil.ReadFile(rom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, test := range differentialConfigs {
+			difference, err := runDifferential(program, test.config, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if difference != "" {
+				t.Errorf("FAIL %v %v: %v", filepath.Base(rom), test.name, difference)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package chip8
+
+import "fmt"
+
+//Disassemble returns the mnemonic for an opcode using the same names as the decode comments
+//Opcodes that do not decode are returned as a raw data word
+func Disassemble(opcode Instruction) string {
+	return DisassembleSymbols(opcode, nil)
+}
+
+//Like Disassemble with address operands named by symbols, e.g. CALL draw_player
+func DisassembleSymbols(opcode Instruction, symbols Symbols) string {
+	x, y := maskXRegister(opcode), maskYRegister(opcode)
+	address, value := maskAddress(opcode), maskEndingByte(opcode)
+	lastNibble := byte(opcode & 0x000F)
+
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			return "CLS"
+		case 0x00EE:
+			return "RET"
+		}
+	case 0x1000:
+		return fmt.Sprintf("JP %s", Symbolize(symbols, address))
+	case 0x2000:
+		return fmt.Sprintf("CALL %s", Symbolize(symbols, address))
+	case 0x3000:
+		return fmt.Sprintf("SE V%X, 0x%.2X", x, value)
+	case 0x4000:
+		return fmt.Sprintf("SNE V%X, 0x%.2X", x, value)
+	case 0x5000:
+		if lastNibble == 0 {
+			return fmt.Sprintf("SE V%X, V%X", x, y)
+		}
+	case 0x6000:
+		return fmt.Sprintf("LD V%X, 0x%.2X", x, value)
+	case 0x7000:
+		return fmt.Sprintf("ADD V%X, 0x%.2X", x, value)
+	case 0x8000:
+		if name, ok := mnemonics8[lastNibble]; ok {
+			return fmt.Sprintf("%s V%X, V%X", name, x, y)
+		}
+	case 0x9000:
+		if lastNibble == 0 {
+			return fmt.Sprintf("SNE V%X, V%X", x, y)
+		}
+	case 0xA000:
+		return fmt.Sprintf("LD I, %s", Symbolize(symbols, address))
+	case 0xB000:
+		return fmt.Sprintf("JP V0, %s", Symbolize(symbols, address))
+	case 0xC000:
+		return fmt.Sprintf("RND V%X, 0x%.2X", x, value)
+	case 0xD000:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, lastNibble)
+	case 0xE000:
+		switch value {
+		case 0x9E:
+			return fmt.Sprintf("SKP V%X", x)
+		case 0xA1:
+			return fmt.Sprintf("SKNP V%X", x)
+		}
+	case 0xF000:
+		if format, ok := mnemonicsF[value]; ok {
+			return fmt.Sprintf(format, x)
+		}
+	}
+	return fmt.Sprintf("DW 0x%.4X", uint16(opcode))
+}
+
+var mnemonics8 = map[byte]string{
+	0x0: "LD",
+	0x1: "OR",
+	0x2: "AND",
+	0x3: "XOR",
+	0x4: "ADD",
+	0x5: "SUB",
+	0x6: "SHR",
+	0x7: "SUBN",
+	0xE: "SHL",
+}
+
+var mnemonicsF = map[byte]string{
+	0x07: "LD V%X, DT",
+	0x0A: "LD V%X, K",
+	0x15: "LD DT, V%X",
+	0x18: "LD ST, V%X",
+	0x1E: "ADD I, V%X",
+	0x29: "LD F, V%X",
+	0x30: "LD HF, V%X",
+	0x33: "LD B, V%X",
+	0x55: "LD [I], V%X",
+	0x65: "LD V%X, [I]",
+}
+
+//OpcodeClass returns the conventional pattern name of an opcode e.g. 8XY4 or FX33
+func OpcodeClass(opcode Instruction) string {
+	first := opcode >> 12
+	switch first {
+	case 0x0:
+		if opcode == 0x00E0 || opcode == 0x00EE {
+			return fmt.Sprintf("%.4X", uint16(opcode))
+		}
+		return "0NNN"
+	case 0x1, 0x2, 0xA, 0xB:
+		return fmt.Sprintf("%XNNN", first)
+	case 0x3, 0x4, 0x6, 0x7, 0xC:
+		return fmt.Sprintf("%XXNN", first)
+	case 0x5, 0x8, 0x9:
+		return fmt.Sprintf("%XXY%X", first, opcode&0x000F)
+	case 0xD:
+		return "DXYN"
+	}
+	return fmt.Sprintf("%XX%.2X", first, maskEndingByte(opcode)) //EX and FX families
+}
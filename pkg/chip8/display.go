@@ -14,26 +14,54 @@ type Display struct {
 	pixels pixelsArray
 
 	hasChanged bool
+	hasDrawn   bool //A sprite was drawn since the display was last sent, CLS alone doesn't count
 }
 
-//Returns collison
-func (display *Display) drawSprite(sprite []byte, x byte, y byte) bool {
-	hasCollided := false
-	bitOffset := x % 8            //This is the offset the the first byte needs to be shifts right
-	startingXByte := (x % 64) / 8 //First byte that needs to be XORed
+//Returns collision, whether any dot that was on got turned off
+//The starting position always wraps onto the screen, the rest of the sprite is clipped at the edges unless wrap is set
+func (display *Display) drawSprite(sprite []byte, x byte, y byte, wrap bool) bool {
+	width, height := display.GetSize()
+	byteWidth := width / 8
+	startX, startY := int(x)%width, int(y)%height
+	bitOffset := startX % 8     //This is the offset the the first byte needs to be shifts right
+	startingXByte := startX / 8 //First byte that needs to be XORed
 
-	// fmt.Printf("draw(%v,%v)*%v\n", x, y, len(sprite))
+	hasCollided := false
 	for i, spriteLine := range sprite {
-		display.pixels[y+byte(i)][startingXByte] ^= (spriteLine >> bitOffset)
-		if bitOffset > 0 && startingXByte+1 < byte(len(display.pixels[0])) {
-			display.pixels[y+byte(i)][startingXByte+1] ^= (spriteLine << (8 - bitOffset)) //Shift right for the second byte
+		row := startY + i
+		if row >= height {
+			if !wrap {
+				break
+			}
+			row %= height
+		}
+
+		pixels := &display.pixels[row]
+		hasCollided = xorDots(&pixels[startingXByte], spriteLine>>bitOffset) || hasCollided
+		if bitOffset > 0 {
+			nextXByte := startingXByte + 1
+			if nextXByte >= byteWidth {
+				if !wrap {
+					continue
+				}
+				nextXByte = 0
+			}
+			hasCollided = xorDots(&pixels[nextXByte], spriteLine<<(8-bitOffset)) || hasCollided //Shift left for the second byte
 		}
 	}
 	display.hasChanged = true
+	display.hasDrawn = true
 
 	return hasCollided
 }
 
+//XORs sprite dots into a byte of the display, returns whether a dot was turned off
+func xorDots(dots *byte, sprite byte) bool {
+	hasCollided := *dots&sprite != 0
+	*dots ^= sprite
+	return hasCollided
+}
+
 func (display *Display) clearScreen() {
 	display.pixels = pixelsArray{}
 	display.hasChanged = true
@@ -53,6 +81,12 @@ func (display Display) HasChanged() bool {
 	return display.hasChanged
 }
 
+//Whether a sprite was drawn in the frames this display covers
+//Frontends that only present drawn frames hide the blank screen between a CLS and the redraw
+func (display Display) HasDrawn() bool {
+	return display.hasDrawn
+}
+
 func (display Display) GetSize() (maxX, maxY int) {
 	return defaultWidth, defaultHeight
 }
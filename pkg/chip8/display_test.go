@@ -0,0 +1,116 @@
+package chip8
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDrawSpriteEdges(t *testing.T) {
+	block := []byte{0xC0, 0xC0} //2x2 dots
+	tests := []struct {
+		name     string
+		x, y     byte
+		wrap     bool
+		expected []image.Point
+	}{
+		{"top left", 0, 0, false, []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+		{"across bytes", 7, 4, false, []image.Point{{7, 4}, {8, 4}, {7, 5}, {8, 5}}},
+		{"right edge clipped", 63, 10, false, []image.Point{{63, 10}, {63, 11}}},
+		{"right edge wrapped", 63, 10, true, []image.Point{{63, 10}, {63, 11}, {0, 10}, {0, 11}}},
+		{"left of right edge", 62, 10, false, []image.Point{{62, 10}, {63, 10}, {62, 11}, {63, 11}}},
+		{"bottom edge clipped", 10, 31, false, []image.Point{{10, 31}, {11, 31}}},
+		{"bottom edge wrapped", 10, 31, true, []image.Point{{10, 31}, {11, 31}, {10, 0}, {11, 0}}},
+		{"top right", 62, 0, false, []image.Point{{62, 0}, {63, 0}, {62, 1}, {63, 1}}},
+		{"bottom left", 0, 30, false, []image.Point{{0, 30}, {1, 30}, {0, 31}, {1, 31}}},
+		{"bottom right clipped", 63, 31, false, []image.Point{{63, 31}}},
+		{"bottom right wrapped", 63, 31, true, []image.Point{{63, 31}, {0, 31}, {63, 0}, {0, 0}}},
+		{"start past the edges", 64 + 5, 32 + 3, false, []image.Point{{5, 3}, {6, 3}, {5, 4}, {6, 4}}},
+		{"start at 255 clipped", 255, 255, false, []image.Point{{63, 31}}},
+		{"start at 255 wrapped", 255, 255, true, []image.Point{{63, 31}, {0, 31}, {63, 0}, {0, 0}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			display := Display{}
+			if display.drawSprite(block, test.x, test.y, test.wrap) {
+				t.Errorf("FAIL collision on a blank display")
+			}
+
+			grid := display.ToBoolArray()
+			expected := map[image.Point]bool{}
+			for _, point := range test.expected {
+				expected[point] = true
+			}
+			for y := range grid {
+				for x, isOn := range grid[y] {
+					if isOn != expected[image.Pt(x, y)] {
+						t.Errorf("FAIL dot (%v,%v)=%v (expected %v)", x, y, isOn, !isOn)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDrawSpriteTall(t *testing.T) {
+	sprite := make([]byte, 15)
+	for i := range sprite {
+		sprite[i] = 0x80
+	}
+
+	for _, wrap := range []bool{false, true} {
+		display := Display{}
+		display.drawSprite(sprite, 0, 30, wrap)
+
+		lit := 0
+		for _, row := range display.ToBoolArray() {
+			if row[0] {
+				lit++
+			}
+		}
+		expected := 2
+		if wrap {
+			expected = 15
+		}
+		if lit != expected {
+			t.Errorf("FAIL wrap=%v lit=%v (expected %v)", wrap, lit, expected)
+		}
+	}
+}
+
+func TestDrawSpriteCollision(t *testing.T) {
+	dot := []byte{0x80}
+	block := []byte{0xC0, 0xC0}
+	tests := []struct {
+		name     string
+		x, y     byte
+		wrap     bool
+		expected bool
+	}{
+		//Against a dot in the top left corner
+		{"same place", 0, 0, false, true},
+		{"beside", 2, 0, false, false},
+		{"second byte", 8, 0, false, false},
+		{"clipped off the right edge", 63, 0, false, false},
+		{"wrapped from the right edge", 63, 0, true, true},
+		{"clipped off the bottom edge", 0, 31, false, false},
+		{"wrapped from the bottom edge", 0, 31, true, true},
+		{"clipped off the corner", 63, 31, false, false},
+		{"wrapped from the corner", 63, 31, true, true},
+	}
+
+	for _, test := range tests {
+		display := Display{}
+		display.drawSprite(dot, 0, 0, false)
+		if collided := display.drawSprite(block, test.x, test.y, test.wrap); collided != test.expected {
+			t.Errorf("FAIL %v collision=%v (expected %v)", test.name, collided, test.expected)
+		}
+	}
+
+	//Drawing the same sprite twice erases it
+	display := Display{}
+	display.drawSprite(block, 30, 15, false)
+	if !display.drawSprite(block, 30, 15, false) || display.ToBoolArray() != (DotGrid{}) {
+		t.Errorf("FAIL sprite drawn twice wasn't erased with a collision")
+	}
+}
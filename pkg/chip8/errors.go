@@ -0,0 +1,12 @@
+package chip8
+
+import "errors"
+
+//Errors the system stops with, returned wrapped with the details so they can be checked with errors.Is
+var (
+	ErrUnsupportedOpcode = errors.New("not implemented/supported")
+	ErrOutOfMemory       = errors.New("out of memory")
+	ErrStackOverflow     = errors.New("stack overflow")
+	ErrStackEmpty        = errors.New("stack empty, nothing to return too")
+	ErrProgramTooLarge   = errors.New("program is too large and cannot be loaded into memory")
+)
@@ -0,0 +1,45 @@
+package chip8
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  []byte
+		expected error
+	}{
+		{"unsupported opcode", []byte{0x80, 0x0F}, ErrUnsupportedOpcode},
+		{"fetch at the end of memory", []byte{0x1F, 0xFF}, ErrOutOfMemory},
+		{"jump offset past memory", []byte{0x60, 0xFF, 0xBF, 0xFF}, ErrOutOfMemory},
+		{"BCD at the end of memory", []byte{0xAF, 0xFF, 0xF0, 0x33}, ErrOutOfMemory},
+		{"store at the end of memory", []byte{0xAF, 0xFF, 0xFF, 0x55}, ErrOutOfMemory},
+		{"load past memory", []byte{0x60, 0xFF, 0xAF, 0xFF, 0xF0, 0x1E, 0xF0, 0x65}, ErrOutOfMemory},
+		{"sprite at the end of memory", []byte{0xAF, 0xFF, 0xD0, 0x0F}, ErrOutOfMemory},
+		{"stack overflow", []byte{0x22, 0x00}, ErrStackOverflow},
+		{"return with an empty stack", []byte{0x00, 0xEE}, ErrStackEmpty},
+	}
+
+	for _, test := range tests {
+		system, _, _, _ := New()
+		if err := system.LoadProgram(test.program); err != nil {
+			t.Fatal(err)
+		}
+		err := system.stepFrame()
+		if !errors.Is(err, test.expected) {
+			t.Errorf("FAIL %v err=%v (expected %v)", test.name, err, test.expected)
+		}
+	}
+}
+
+func TestLoadProgramSize(t *testing.T) {
+	system, _, _, _ := New()
+	if err := system.LoadProgram(make([]byte, RamSize-programStart)); err != nil {
+		t.Errorf("FAIL program filling memory err=%v (expected nil)", err)
+	}
+	if err := system.LoadProgram(make([]byte, RamSize-programStart+1)); !errors.Is(err, ErrProgramTooLarge) {
+		t.Errorf("FAIL program past memory err=%v (expected %v)", err, ErrProgramTooLarge)
+	}
+}
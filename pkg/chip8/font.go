@@ -1,20 +1,170 @@
 package chip8
 
-var font = [80]byte{
-	0xF0, 0x90, 0x90, 0x90, 0xF0,
-	0x20, 0x60, 0x20, 0x20, 0x70,
-	0xF0, 0x10, 0xF0, 0x80, 0xF0,
-	0xF0, 0x10, 0xF0, 0x10, 0xF0,
-	0x90, 0x90, 0xF0, 0x10, 0x10,
-	0xF0, 0x80, 0xF0, 0x10, 0xF0,
-	0xF0, 0x80, 0xF0, 0x90, 0xF0,
-	0xF0, 0x10, 0x20, 0x40, 0x40,
-	0xF0, 0x90, 0xF0, 0x90, 0xF0,
-	0xF0, 0x90, 0xF0, 0x10, 0xF0,
-	0xF0, 0x90, 0xF0, 0x90, 0x90,
-	0xE0, 0x90, 0xE0, 0x90, 0xE0,
-	0xF0, 0x80, 0x80, 0x80, 0xF0,
-	0xE0, 0x90, 0x90, 0x90, 0xE0,
-	0xF0, 0x80, 0xF0, 0x80, 0xF0,
-	0xF0, 0x80, 0xF0, 0x80, 0x80,
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	smallGlyphSize = 5  //Lines in a hex digit, FX29
+	largeGlyphSize = 10 //Lines in a SUPER-CHIP decimal digit, FX30
+
+	smallFontSize = 16 * smallGlyphSize
+	largeFontSize = 10 * largeGlyphSize
+)
+
+//Digit sprites built into an interpreter
+type Font struct {
+	Name  string
+	Small []byte //Hex digits 0-F of 5 lines for FX29
+	Large []byte //Decimal digits 0-9 of 10 lines for FX30, placed straight after the small digits
+}
+
+//SUPER-CHIP 1.1 large digits, every built in set uses them for FX30
+var schipLarge = []byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C,
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C,
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF,
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C,
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06,
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C,
+	0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C,
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60,
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C,
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C,
+}
+
+//Built in font sets, the first is the default
+var Fonts = []Font{
+	{"chip48", []byte{ //CHIP-48 and most modern interpreters
+		0xF0, 0x90, 0x90, 0x90, 0xF0,
+		0x20, 0x60, 0x20, 0x20, 0x70,
+		0xF0, 0x10, 0xF0, 0x80, 0xF0,
+		0xF0, 0x10, 0xF0, 0x10, 0xF0,
+		0x90, 0x90, 0xF0, 0x10, 0x10,
+		0xF0, 0x80, 0xF0, 0x10, 0xF0,
+		0xF0, 0x80, 0xF0, 0x90, 0xF0,
+		0xF0, 0x10, 0x20, 0x40, 0x40,
+		0xF0, 0x90, 0xF0, 0x90, 0xF0,
+		0xF0, 0x90, 0xF0, 0x10, 0xF0,
+		0xF0, 0x90, 0xF0, 0x90, 0x90,
+		0xE0, 0x90, 0xE0, 0x90, 0xE0,
+		0xF0, 0x80, 0x80, 0x80, 0xF0,
+		0xE0, 0x90, 0x90, 0x90, 0xE0,
+		0xF0, 0x80, 0xF0, 0x80, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0x80,
+	}, schipLarge},
+	{"vip", []byte{ //COSMAC VIP
+		0xF0, 0x90, 0x90, 0x90, 0xF0,
+		0x60, 0x20, 0x20, 0x20, 0x70,
+		0xF0, 0x10, 0xF0, 0x80, 0xF0,
+		0xF0, 0x10, 0xF0, 0x10, 0xF0,
+		0xA0, 0xA0, 0xF0, 0x20, 0x20,
+		0xF0, 0x80, 0xF0, 0x10, 0xF0,
+		0xF0, 0x80, 0xF0, 0x90, 0xF0,
+		0xF0, 0x10, 0x10, 0x10, 0x10,
+		0xF0, 0x90, 0xF0, 0x90, 0xF0,
+		0xF0, 0x90, 0xF0, 0x10, 0xF0,
+		0xF0, 0x90, 0xF0, 0x90, 0x90,
+		0xF0, 0x50, 0x70, 0x50, 0xF0,
+		0xF0, 0x80, 0x80, 0x80, 0xF0,
+		0xF0, 0x50, 0x50, 0x50, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0xF0,
+		0xF0, 0x80, 0xF0, 0x80, 0x80,
+	}, schipLarge},
+	{"dream6800", []byte{ //DREAM 6800, 3 dots wide
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0,
+		0x40, 0x40, 0x40, 0x40, 0x40,
+		0xE0, 0x20, 0xE0, 0x80, 0xE0,
+		0xE0, 0x20, 0xE0, 0x20, 0xE0,
+		0x80, 0xA0, 0xA0, 0xE0, 0x20,
+		0xE0, 0x80, 0xE0, 0x20, 0xE0,
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0,
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0,
+		0xC0, 0xA0, 0xE0, 0xA0, 0xC0,
+		0xE0, 0x80, 0x80, 0x80, 0xE0,
+		0xC0, 0xA0, 0xA0, 0xA0, 0xC0,
+		0xE0, 0x80, 0xE0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	}, schipLarge},
+	{"eti660", []byte{ //ETI-660, lower case b and d
+		0xE0, 0xA0, 0xA0, 0xA0, 0xE0,
+		0x20, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0x20, 0xE0, 0x80, 0xE0,
+		0xE0, 0x20, 0xE0, 0x20, 0xE0,
+		0xA0, 0xA0, 0xE0, 0x20, 0x20,
+		0xE0, 0x80, 0xE0, 0x20, 0xE0,
+		0xE0, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x20, 0x20, 0x20, 0x20,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xE0,
+		0xE0, 0xA0, 0xE0, 0x20, 0xE0,
+		0xE0, 0xA0, 0xE0, 0xA0, 0xA0,
+		0x80, 0x80, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x80, 0x80, 0x80, 0xE0,
+		0x20, 0x20, 0xE0, 0xA0, 0xE0,
+		0xE0, 0x80, 0xE0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	}, schipLarge},
+	{"fishnchips", []byte{ //FISH-N-CHIPS, rounded digits
+		0x60, 0xA0, 0xA0, 0xA0, 0xC0,
+		0x40, 0xC0, 0x40, 0x40, 0xE0,
+		0xC0, 0x20, 0x40, 0x80, 0xE0,
+		0xC0, 0x20, 0x40, 0x20, 0xC0,
+		0x20, 0xA0, 0xE0, 0x20, 0x20,
+		0xE0, 0x80, 0xC0, 0x20, 0xC0,
+		0x40, 0x80, 0xC0, 0xA0, 0x40,
+		0xE0, 0x20, 0x60, 0x40, 0x40,
+		0x40, 0xA0, 0x40, 0xA0, 0x40,
+		0x40, 0xA0, 0x60, 0x20, 0x40,
+		0x40, 0xA0, 0xE0, 0xA0, 0xA0,
+		0xC0, 0xA0, 0xC0, 0xA0, 0xC0,
+		0x60, 0x80, 0x80, 0x80, 0x60,
+		0xC0, 0xA0, 0xA0, 0xA0, 0xC0,
+		0xE0, 0x80, 0xC0, 0x80, 0xE0,
+		0xE0, 0x80, 0xC0, 0x80, 0x80,
+	}, schipLarge},
+}
+
+//Address interpreters that keep the font out of the way of their own code commonly load it at
+const CommonFontLocation = 0x50
+
+func FindFont(name string) (Font, error) {
+	for _, font := range Fonts {
+		if font.Name == name {
+			return font, nil
+		}
+	}
+	return Font{}, fmt.Errorf("font error: no font named %q", name)
+}
+
+//Loads a raw font file of 80 bytes of small digits, optionally followed by 100 bytes of large digits
+func LoadFontFile(filename string) (Font, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Font{}, err
+	}
+
+	font := Font{Name: filename}
+	switch len(data) {
+	case smallFontSize:
+		font.Small = data
+		font.Large = schipLarge
+	case smallFontSize + largeFontSize:
+		font.Small, font.Large = data[:smallFontSize], data[smallFontSize:]
+	default:
+		return Font{}, fmt.Errorf("font error: %s is %v bytes (expected %v or %v)", filename, len(data), smallFontSize, smallFontSize+largeFontSize)
+	}
+	return font, nil
+}
+
+//Bytes the font takes up in memory
+func (font Font) size() int {
+	return len(font.Small) + len(font.Large)
+}
+
+func (font Font) isZero() bool {
+	return font.Small == nil && font.Large == nil
 }
@@ -0,0 +1,85 @@
+package chip8
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFontLocation(t *testing.T) {
+	vip, err := FindFont("vip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//LD F, V2 then LD HF, V3
+	system := createNewSystem([]byte{0xF2, 0x29, 0xF3, 0x30})
+	system.Configure(Config{Font: vip, FontLocation: CommonFontLocation})
+	if !bytes.Equal(system.ram[0x50:0x50+smallFontSize], vip.Small) || !bytes.Equal(system.ram[0xA0:0xA0+largeFontSize], schipLarge) {
+		t.Fatalf("FAIL font was not loaded at 0x050 with the large digits at 0x0A0")
+	}
+
+	cycle(t, system)
+	if system.cpu.RegisterI != 0x50+2*smallGlyphSize {
+		t.Errorf("FAIL FX29 I=0x%.3X (expected 0x05A)", system.cpu.RegisterI)
+	}
+	cycle(t, system)
+	if system.cpu.RegisterI != 0xA0+3*largeGlyphSize {
+		t.Errorf("FAIL FX30 I=0x%.3X (expected 0x0BE)", system.cpu.RegisterI)
+	}
+
+	system.handleCommand(CommandReset)
+	if !bytes.Equal(system.ram[0x50:0x50+smallFontSize], vip.Small) || system.cpu.fontLocation != 0x50 {
+		t.Errorf("FAIL font was not kept across reset")
+	}
+}
+
+func TestLoadFontFile(t *testing.T) {
+	directory := t.TempDir()
+	tests := []struct {
+		size  int
+		valid bool
+	}{
+		{smallFontSize, true},
+		{smallFontSize + largeFontSize, true},
+		{smallFontSize - 1, false},
+	}
+
+	for _, test := range tests {
+		filename := filepath.Join(directory, "font.bin")
+		if err := ioutil.WriteFile(filename, make([]byte, test.size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		font, err := LoadFontFile(filename)
+		if (err == nil) != test.valid {
+			t.Errorf("FAIL %v bytes err=%v (expected valid=%v)", test.size, err, test.valid)
+		} else if err == nil && (len(font.Small) != smallFontSize || len(font.Large) != largeFontSize) {
+			t.Errorf("FAIL %v bytes loaded %v small and %v large", test.size, len(font.Small), len(font.Large))
+		}
+	}
+}
+
+func TestFontConfig(t *testing.T) {
+	for _, font := range Fonts {
+		if err := (Config{Font: font, FontLocation: CommonFontLocation}).Validate(); err != nil {
+			t.Errorf("FAIL %v: %v", font.Name, err)
+		}
+	}
+	if err := (Config{FontLocation: RamSize - smallFontSize}).Validate(); err == nil {
+		t.Errorf("FAIL font past the end of memory was accepted")
+	}
+	if err := (Config{Font: Font{Name: "short", Small: make([]byte, 10)}}).Validate(); err == nil {
+		t.Errorf("FAIL short font was accepted")
+	}
+
+	//Systems refuse an invalid config
+	bad := Config{FontLocation: RamSize - smallFontSize, TickRate: 3}
+	if _, _, _, _, err := NewWithConfig(bad); err == nil {
+		t.Errorf("FAIL NewWithConfig accepted a font past the end of memory")
+	}
+	system := createNewSystem(nil)
+	if err := system.Configure(bad); err == nil || system.cyclesPerFrame == 3 || system.ram[RamSize-1] != 0 {
+		t.Errorf("FAIL Configure err=%v tick rate=%v (expected an error and the system left as it was)", err, system.cyclesPerFrame)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build go1.18
+
+package chip8
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const fuzzFrames = 30
+
+//Errors the system is allowed to stop with, anything else or a panic is a bug
+var systemErrors = []error{ErrUnsupportedOpcode, ErrOutOfMemory, ErrStackOverflow, ErrStackEmpty, ErrProgramTooLarge}
+
+func isSystemError(err error) bool {
+	for _, target := range systemErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+//Quirks and timing from the bits of a fuzzed byte
+func fuzzConfig(settings byte) Config {
+	config := DefaultConfig()
+	config.Quirks = Quirks{
+		KeyWaitOnPress: settings&0x01 != 0,
+		ShiftVY:        settings&0x02 != 0,
+		IncrementI:     settings&0x04 != 0,
+		IncrementIByX:  settings&0x08 != 0,
+		ResetVF:        settings&0x10 != 0,
+		JumpVX:         settings&0x20 != 0,
+		DisplayWait:    settings&0x40 != 0,
+		WrapSprites:    settings&0x80 != 0,
+	}
+	if settings&0x0C == 0x0C {
+		config.Timing = TimingVIP
+	}
+	return config
+}
+
+//Runs programs with a key event from keys before each frame, the top bit of the event is pressed and the bottom nibble the key
+func FuzzRun(f *testing.F) {
+	roms, err := filepath.Glob(filepath.Join("testdata", "roms", "*.ch8"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, rom := range roms {
+		program, err := ioutil.ReadFile(rom)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(program, []byte{0x87, 0x07, 0x8B}, byte(0))
+		f.Add(program, []byte{}, byte(0x56))
+	}
+
+	f.Fuzz(func(t *testing.T, program []byte, keys []byte, settings byte) {
+		system, _, _, _, err := NewWithConfig(fuzzConfig(settings))
+		if err != nil {
+			t.Fatal(err)
+		}
+		system.Seed(1)
+		if err := system.LoadProgram(program); err != nil {
+			if !isSystemError(err) {
+				t.Fatalf("FAIL untyped error %v", err)
+			}
+			return
+		}
+
+		for frame := 0; frame < fuzzFrames; frame++ {
+			if frame < len(keys) {
+				system.applyKeyEvent(KeyEvent{Key: keys[frame] & 0xF, Pressed: keys[frame]&0x80 != 0})
+			}
+			if err := system.stepFrame(); err != nil {
+				if !isSystemError(err) {
+					t.Fatalf("FAIL untyped error %v", err)
+				}
+				return
+			}
+		}
+	})
+}
+
+//Decodes and executes one instruction with any registers and I
+func FuzzDecode(f *testing.F) {
+	f.Add(uint16(0xD01F), []byte{0, 30}, uint16(0xFFF))
+	f.Add(uint16(0xFF65), []byte{}, uint16(0xFF0))
+	f.Add(uint16(0x8F1E), []byte{0x80, 0xFF}, uint16(0))
+
+	f.Fuzz(func(t *testing.T, opcode uint16, registers []byte, registerI uint16) {
+		system := createNewSystem(nil)
+		copy(system.cpu.Registers[:], registers)
+		system.cpu.RegisterI = Address(registerI)
+
+		execute, err := system.cpu.decode(Instruction(opcode))
+		if err != nil {
+			if !isSystemError(err) {
+				t.Fatalf("FAIL untyped error %v", err)
+			}
+			return
+		}
+		execute()
+	})
+}
@@ -0,0 +1,154 @@
+package chip8
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden screenshots in testdata/golden")
+
+//Key event sent before the given frame runs
+type scriptedKey struct {
+	frame   uint64
+	key     byte
+	pressed bool
+}
+
+//Test ROMs in testdata/roms, the .asm next to each .ch8 says what the screen should show
+//The suite directory holds the unmodified roms of Timendus' chip8-test-suite, every goldened screen shows a pass
+var goldenTests = []struct {
+	name      string
+	rom       string
+	config    Config
+	frames    int
+	keys      []scriptedKey
+	selection byte //Written to 0x1FF, the suite roms read it to skip their menu
+}{
+	{"flags", "flags.ch8", DefaultConfig(), 10, nil, 0},
+	{"opcodes", "opcodes.ch8", DefaultConfig(), 10, nil, 0},
+	{"memory", "memory.ch8", DefaultConfig(), 10, nil, 0},
+	{"quirks", "quirks.ch8", DefaultConfig(), 10, nil, 0},
+	{"quirks-vip", "quirks.ch8", Config{Quirks: VIPQuirks()}, 60, nil, 0}, //Display wait draws once a frame
+	{"keypad", "keypad.ch8", DefaultConfig(), 20, []scriptedKey{{2, 0x7, true}, {4, 0x7, false}, {8, 0xB, true}}, 0},
+	{"random", "random.ch8", DefaultConfig(), 10, nil, 0},
+	{"suite-chip8-logo", "suite/1-chip8-logo.ch8", DefaultConfig(), 60, nil, 0},
+	{"suite-ibm-logo", "suite/2-ibm-logo.ch8", DefaultConfig(), 60, nil, 0},
+	{"suite-corax", "suite/3-corax+.ch8", DefaultConfig(), 60, nil, 0},
+	{"suite-flags", "suite/4-flags.ch8", DefaultConfig(), 60, nil, 0},
+	{"suite-quirks-vip", "suite/5-quirks.ch8", Config{Quirks: VIPQuirks()}, 600, nil, 1},                                  //1 tests the CHIP-8 quirks
+	{"suite-keypad-fx0a", "suite/6-keypad.ch8", DefaultConfig(), 60, []scriptedKey{{30, 0x5, true}, {33, 0x5, false}}, 3}, //3 tests FX0A waiting for a release
+}
+
+func TestGoldenROMs(t *testing.T) {
+	for _, test := range goldenTests {
+		t.Run(test.name, func(t *testing.T) {
+			program, err := ioutil.ReadFile(filepath.Join("testdata", "roms", test.rom))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			system, _, _, _, err := NewWithConfig(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := system.LoadProgram(program); err != nil {
+				t.Fatal(err)
+			}
+			system.Seed(1)
+			if test.selection != 0 {
+				system.ram[0x1FF] = test.selection
+			}
+			for frame := 0; frame < test.frames; frame++ {
+				for _, key := range test.keys {
+					if key.frame == uint64(frame) {
+						system.applyKeyEvent(KeyEvent{Key: key.key, Pressed: key.pressed})
+					}
+				}
+				if err := system.stepFrame(); err != nil {
+					t.Fatalf("FAIL frame %v: %v", frame, err)
+				}
+			}
+
+			compareScreenshot(t, filepath.Join("testdata", "golden", test.name+".png"), system.display.ToBoolArray())
+		})
+	}
+}
+
+//Compares the display with a golden 1:1 screenshot, a mismatch leaves the actual screen in a temporary directory
+func compareScreenshot(t *testing.T, filename string, grid DotGrid) {
+	screenshot := screenshotImage(grid)
+	if *update {
+		encoded := bytes.Buffer{}
+		if err := png.Encode(&encoded, screenshot); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, encoded.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("FAIL %v (run the tests with -update to create it)", err)
+	}
+	golden, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, actual := screenshotHash(screenshotGrid(golden)), screenshotHash(grid)
+	if expected != actual {
+		actualFile := filepath.Join(t.TempDir(), filepath.Base(filename))
+		encoded := bytes.Buffer{}
+		if err := png.Encode(&encoded, screenshot); err == nil {
+			ioutil.WriteFile(actualFile, encoded.Bytes(), 0644)
+		}
+		t.Errorf("FAIL %v screen %v written to %v (expected %v)", filename, actual, actualFile, expected)
+	}
+}
+
+func screenshotImage(grid DotGrid) *image.Gray {
+	result := image.NewGray(image.Rect(0, 0, defaultWidth, defaultHeight))
+	for y := range grid {
+		for x, isOn := range grid[y] {
+			if isOn {
+				result.SetGray(x, y, color.Gray{0xFF})
+			}
+		}
+	}
+	return result
+}
+
+func screenshotGrid(screenshot image.Image) DotGrid {
+	grid := DotGrid{}
+	for y := range grid {
+		for x := range grid[y] {
+			gray := color.GrayModel.Convert(screenshot.At(x, y)).(color.Gray)
+			grid[y][x] = gray.Y >= 0x80
+		}
+	}
+	return grid
+}
+
+func screenshotHash(grid DotGrid) string {
+	hash := sha1.New()
+	for y := range grid {
+		for _, isOn := range grid[y] {
+			if isOn {
+				hash.Write([]byte{1})
+			} else {
+				hash.Write([]byte{0})
+			}
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
@@ -6,10 +6,22 @@ const (
 
 type Input uint16
 
+//A key press or release sent to the system
+type KeyEvent struct {
+	Key     byte
+	Pressed bool
+
+	Frame uint64 //Frame the event was applied on, set by the system and reported as Status.LastKey
+}
+
 func (input Input) checkKey(key byte) bool {
 	return input&(0x1<<key) > 0 //Check key by moving digit to the correct bit and then masking
 }
 
+func (input Input) IsPressed(key byte) bool {
+	return input.checkKey(key)
+}
+
 func (input *Input) PressKey(key byte) {
 	*input |= (0x1 << key)
 }
@@ -17,3 +29,46 @@ func (input *Input) PressKey(key byte) {
 func (input *Input) ReleaseKey(key byte) {
 	*input &= ^(0x1 << key) //NOTAND always turns off
 }
+
+func (input *Input) Apply(event KeyEvent) {
+	if event.Pressed {
+		input.PressKey(event.Key)
+	} else {
+		input.ReleaseKey(event.Key)
+	}
+}
+
+//State of an FX0A wait
+//On the VIP a key has to be pressed and then released, keys already held when the wait starts are ignored
+type keyWait struct {
+	key      byte
+	pressed  bool //key has been pressed and the wait is now for its release
+	resolved bool
+}
+
+func (wait *keyWait) start() {
+	*wait = keyWait{}
+}
+
+func (wait *keyWait) handle(event KeyEvent, onPress bool) {
+	if wait.resolved || event.Key >= numKeys {
+		return
+	}
+
+	if event.Pressed && !wait.pressed {
+		wait.key = event.Key
+		wait.pressed = true
+		wait.resolved = onPress
+	} else if !event.Pressed && wait.pressed && event.Key == wait.key {
+		wait.resolved = true
+	}
+}
+
+func (system *Chip8) applyKeyEvent(event KeyEvent) {
+	event.Frame = system.frame
+	system.lastKey = event
+	system.input.Apply(event)
+	if system.cpu.isWaitingForInput {
+		system.cpu.keyWait.handle(event, system.cpu.quirks.KeyWaitOnPress)
+	}
+}
@@ -0,0 +1,97 @@
+package chip8
+
+import (
+	"testing"
+)
+
+type keyStep struct {
+	key     byte
+	pressed bool
+}
+
+func TestLoadKeyPress(t *testing.T) {
+	tests := []struct {
+		name     string
+		held     []byte //Keys held before FX0A starts
+		events   []keyStep
+		onPress  bool
+		resolved bool
+		expected byte
+	}{
+		{"press and release", nil, []keyStep{{0x5, true}, {0x5, false}}, false, true, 0x5},
+		{"press only", nil, []keyStep{{0x5, true}}, false, false, 0},
+		{"press only quirk", nil, []keyStep{{0x5, true}}, true, true, 0x5},
+		{"second key released first", nil, []keyStep{{0x5, true}, {0xA, true}, {0xA, false}}, false, false, 0},
+		{"first key released last", nil, []keyStep{{0x5, true}, {0xA, true}, {0xA, false}, {0x5, false}}, false, true, 0x5},
+		{"first key released while second held", nil, []keyStep{{0x5, true}, {0xA, true}, {0x5, false}}, false, true, 0x5},
+		{"held key released", []byte{0x3}, []keyStep{{0x3, false}}, false, false, 0},
+		{"held key ignored", []byte{0x3}, []keyStep{{0xC, true}, {0x3, false}, {0xC, false}}, false, true, 0xC},
+		{"held key pressed again", []byte{0x3}, []keyStep{{0x3, false}, {0x3, true}, {0x3, false}}, false, true, 0x3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			system := createNewSystem([]byte{0xF3, 0x0A, 0x12, 0x02})
+			system.SetQuirks(Quirks{KeyWaitOnPress: test.onPress})
+			for _, key := range test.held {
+				system.applyKeyEvent(KeyEvent{Key: key, Pressed: true})
+			}
+
+			cycle(t, system) //Start the wait
+			for _, step := range test.events {
+				system.applyKeyEvent(KeyEvent{Key: step.key, Pressed: step.pressed})
+				cycle(t, system)
+			}
+
+			if system.cpu.isWaitingForInput == test.resolved {
+				t.Fatalf("FAIL waiting=%v (expected %v)", system.cpu.isWaitingForInput, !test.resolved)
+			}
+			if test.resolved && system.cpu.Registers[3] != test.expected {
+				t.Errorf("FAIL v3=0x%X (expected 0x%X)", system.cpu.Registers[3], test.expected)
+			}
+			if !test.resolved && system.cpu.programCounter != 0x202 {
+				t.Errorf("FAIL pc=0x%.3X (expected 0x202 while waiting)", system.cpu.programCounter)
+			}
+		})
+	}
+}
+
+func TestKeyEventsUpdateInput(t *testing.T) {
+	system := createNewSystem(nil)
+
+	system.applyKeyEvent(KeyEvent{Key: 0x1, Pressed: true})
+	system.applyKeyEvent(KeyEvent{Key: 0xF, Pressed: true})
+	system.applyKeyEvent(KeyEvent{Key: 0x1, Pressed: false})
+
+	if system.input != 0x8000 {
+		t.Errorf("FAIL input=%.16b (expected only key F)", system.input)
+	}
+}
+
+func TestKeyEventFrames(t *testing.T) {
+	system := createNewSystem([]byte{0x12, 0x00})
+	for i := 0; i < 3; i++ {
+		if err := system.stepFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	system.applyKeyEvent(KeyEvent{Key: 0x7, Pressed: true})
+	if status := system.status(); status.LastKey != (KeyEvent{Key: 0x7, Pressed: true, Frame: 3}) {
+		t.Errorf("FAIL LastKey=%+v (expected key 7 pressed on frame 3)", status.LastKey)
+	}
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	system.applyKeyEvent(KeyEvent{Key: 0x7, Pressed: false, Frame: 99})
+	if status := system.status(); status.LastKey != (KeyEvent{Key: 0x7, Pressed: false, Frame: 4}) {
+		t.Errorf("FAIL LastKey=%+v (expected key 7 released on frame 4 whatever the sender set)", status.LastKey)
+	}
+}
+
+func cycle(t *testing.T, system *Chip8) {
+	t.Helper()
+	if err := system.cpu.cycle(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,317 @@
+//A deliberately simple CHIP-8 interpreter to check the chip8 core against
+//Each instruction is a row of a table that works straight on the machine, nothing is decoded ahead of time
+package reference
+
+import "fmt"
+
+const (
+	MemorySize   = 0x1000
+	ProgramStart = 0x200
+	Width        = 64
+	Height       = 32
+	StackSize    = 16
+)
+
+//Same meanings as chip8.Quirks
+type Options struct {
+	KeyWaitOnPress bool
+	ShiftVY        bool
+	IncrementI     bool
+	IncrementIByX  bool
+	ResetVF        bool
+	JumpVX         bool
+	DisplayWait    bool
+	WrapSprites    bool
+}
+
+type Machine struct {
+	V      [16]byte
+	I      uint16
+	PC     uint16
+	Stack  [StackSize]uint16
+	SP     int
+	DT, ST byte
+
+	Memory  [MemorySize]byte
+	Display [Height][Width]bool
+	Keys    [16]bool
+
+	Options           Options
+	Random            func() byte //CXNN, before the mask is applied
+	FontLocation      uint16      //FX29
+	LargeFontLocation uint16      //FX30
+
+	WaitingForKey    bool //FX0A is waiting, the machine does nothing until a key resolves it
+	WaitingForVBlank bool //DXYN with the display wait option ends the frame
+
+	waitRegister int
+	waitKey      byte
+	keyPressed   bool
+	keyResolved  bool
+}
+
+//Opcode with helpers for its fields
+type opcode uint16
+
+func (op opcode) x() int      { return int(op>>8) & 0xF }
+func (op opcode) y() int      { return int(op>>4) & 0xF }
+func (op opcode) n() int      { return int(op) & 0xF }
+func (op opcode) nn() byte    { return byte(op) }
+func (op opcode) nnn() uint16 { return uint16(op) & 0xFFF }
+
+type instruction struct {
+	mask, pattern uint16
+	execute       func(m *Machine, op opcode) error
+}
+
+//An opcode runs the first row where opcode&mask == pattern
+var instructions = []instruction{
+	{0xFFFF, 0x00E0, func(m *Machine, op opcode) error { m.Display = [Height][Width]bool{}; return nil }},
+	{0xFFFF, 0x00EE, func(m *Machine, op opcode) error {
+		if m.SP == 0 {
+			return fmt.Errorf("reference: return with an empty stack")
+		}
+		m.SP--
+		m.PC = m.Stack[m.SP]
+		return nil
+	}},
+	{0xF000, 0x1000, func(m *Machine, op opcode) error { m.PC = op.nnn(); return nil }},
+	{0xF000, 0x2000, func(m *Machine, op opcode) error {
+		if m.SP == StackSize {
+			return fmt.Errorf("reference: stack overflow")
+		}
+		m.Stack[m.SP] = m.PC
+		m.SP++
+		m.PC = op.nnn()
+		return nil
+	}},
+	{0xF000, 0x3000, func(m *Machine, op opcode) error { m.skipIf(m.V[op.x()] == op.nn()); return nil }},
+	{0xF000, 0x4000, func(m *Machine, op opcode) error { m.skipIf(m.V[op.x()] != op.nn()); return nil }},
+	{0xF00F, 0x5000, func(m *Machine, op opcode) error { m.skipIf(m.V[op.x()] == m.V[op.y()]); return nil }},
+	{0xF000, 0x6000, func(m *Machine, op opcode) error { m.V[op.x()] = op.nn(); return nil }},
+	{0xF000, 0x7000, func(m *Machine, op opcode) error { m.V[op.x()] += op.nn(); return nil }},
+	{0xF00F, 0x8000, func(m *Machine, op opcode) error { m.V[op.x()] = m.V[op.y()]; return nil }},
+	{0xF00F, 0x8001, func(m *Machine, op opcode) error { m.logic(op, m.V[op.x()]|m.V[op.y()]); return nil }},
+	{0xF00F, 0x8002, func(m *Machine, op opcode) error { m.logic(op, m.V[op.x()]&m.V[op.y()]); return nil }},
+	{0xF00F, 0x8003, func(m *Machine, op opcode) error { m.logic(op, m.V[op.x()]^m.V[op.y()]); return nil }},
+	{0xF00F, 0x8004, func(m *Machine, op opcode) error {
+		sum := int(m.V[op.x()]) + int(m.V[op.y()])
+		m.setWithFlag(op, byte(sum), sum > 0xFF)
+		return nil
+	}},
+	{0xF00F, 0x8005, func(m *Machine, op opcode) error {
+		vX, vY := m.V[op.x()], m.V[op.y()]
+		m.setWithFlag(op, vX-vY, vX >= vY)
+		return nil
+	}},
+	{0xF00F, 0x8006, func(m *Machine, op opcode) error {
+		source := m.shiftSource(op)
+		m.setWithFlag(op, source>>1, source&1 == 1)
+		return nil
+	}},
+	{0xF00F, 0x8007, func(m *Machine, op opcode) error {
+		vX, vY := m.V[op.x()], m.V[op.y()]
+		m.setWithFlag(op, vY-vX, vY >= vX)
+		return nil
+	}},
+	{0xF00F, 0x800E, func(m *Machine, op opcode) error {
+		source := m.shiftSource(op)
+		m.setWithFlag(op, source<<1, source&0x80 != 0)
+		return nil
+	}},
+	{0xF00F, 0x9000, func(m *Machine, op opcode) error { m.skipIf(m.V[op.x()] != m.V[op.y()]); return nil }},
+	{0xF000, 0xA000, func(m *Machine, op opcode) error { m.I = op.nnn(); return nil }},
+	{0xF000, 0xB000, func(m *Machine, op opcode) error {
+		offset := m.V[0]
+		if m.Options.JumpVX {
+			offset = m.V[op.x()]
+		}
+		m.PC = op.nnn() + uint16(offset)
+		return nil
+	}},
+	{0xF000, 0xC000, func(m *Machine, op opcode) error { m.V[op.x()] = m.Random() & op.nn(); return nil }},
+	{0xF000, 0xD000, func(m *Machine, op opcode) error { return m.draw(op) }},
+	{0xF0FF, 0xE09E, func(m *Machine, op opcode) error { m.skipIf(m.isPressed(m.V[op.x()])); return nil }},
+	{0xF0FF, 0xE0A1, func(m *Machine, op opcode) error { m.skipIf(!m.isPressed(m.V[op.x()])); return nil }},
+	{0xF0FF, 0xF007, func(m *Machine, op opcode) error { m.V[op.x()] = m.DT; return nil }},
+	{0xF0FF, 0xF00A, func(m *Machine, op opcode) error {
+		m.WaitingForKey, m.waitRegister = true, op.x()
+		m.keyPressed, m.keyResolved = false, false
+		return nil
+	}},
+	{0xF0FF, 0xF015, func(m *Machine, op opcode) error { m.DT = m.V[op.x()]; return nil }},
+	{0xF0FF, 0xF018, func(m *Machine, op opcode) error { m.ST = m.V[op.x()]; return nil }},
+	{0xF0FF, 0xF01E, func(m *Machine, op opcode) error { m.I += uint16(m.V[op.x()]); return nil }},
+	{0xF0FF, 0xF029, func(m *Machine, op opcode) error { m.I = m.FontLocation + 5*uint16(m.V[op.x()]); return nil }},
+	{0xF0FF, 0xF030, func(m *Machine, op opcode) error { m.I = m.LargeFontLocation + 10*uint16(m.V[op.x()]); return nil }},
+	{0xF0FF, 0xF033, func(m *Machine, op opcode) error {
+		if err := m.checkMemory(m.I, 3); err != nil {
+			return err
+		}
+		vX := m.V[op.x()]
+		m.Memory[m.I], m.Memory[m.I+1], m.Memory[m.I+2] = vX/100, vX/10%10, vX%10
+		return nil
+	}},
+	{0xF0FF, 0xF055, func(m *Machine, op opcode) error {
+		if err := m.checkMemory(m.I, op.x()+1); err != nil {
+			return err
+		}
+		for i := 0; i <= op.x(); i++ {
+			m.Memory[int(m.I)+i] = m.V[i]
+		}
+		m.incrementI(op)
+		return nil
+	}},
+	{0xF0FF, 0xF065, func(m *Machine, op opcode) error {
+		if err := m.checkMemory(m.I, op.x()+1); err != nil {
+			return err
+		}
+		for i := 0; i <= op.x(); i++ {
+			m.V[i] = m.Memory[int(m.I)+i]
+		}
+		m.incrementI(op)
+		return nil
+	}},
+}
+
+func New(program []byte) (*Machine, error) {
+	if len(program) > MemorySize-ProgramStart {
+		return nil, fmt.Errorf("reference: program too large")
+	}
+	m := &Machine{PC: ProgramStart}
+	copy(m.Memory[ProgramStart:], program)
+	return m, nil
+}
+
+//Runs one instruction, or checks whether a key has finished an FX0A wait
+func (m *Machine) Step() error {
+	if m.WaitingForKey {
+		if m.keyResolved {
+			m.V[m.waitRegister] = m.waitKey
+			m.WaitingForKey = false
+		}
+		return nil
+	}
+
+	if err := m.checkMemory(m.PC, 2); err != nil {
+		return err
+	}
+	op := opcode(m.Memory[m.PC])<<8 | opcode(m.Memory[m.PC+1])
+	m.PC += 2
+	for _, instruction := range instructions {
+		if uint16(op)&instruction.mask == instruction.pattern {
+			return instruction.execute(m, op)
+		}
+	}
+	return fmt.Errorf("reference: unknown opcode %.4X", uint16(op))
+}
+
+//Counts the timers down at the end of a 60hz frame
+func (m *Machine) TickTimers() {
+	if m.DT > 0 {
+		m.DT--
+	}
+	if m.ST > 0 {
+		m.ST--
+	}
+	m.WaitingForVBlank = false
+}
+
+//Holds or lets go of a key, an FX0A wait takes the first key pressed after it started
+func (m *Machine) KeyEvent(key byte, pressed bool) {
+	if key >= 16 {
+		return
+	}
+	m.Keys[key] = pressed
+	if !m.WaitingForKey || m.keyResolved {
+		return
+	}
+	if pressed && !m.keyPressed {
+		m.waitKey, m.keyPressed = key, true
+		m.keyResolved = m.Options.KeyWaitOnPress
+	} else if !pressed && m.keyPressed && key == m.waitKey {
+		m.keyResolved = true
+	}
+}
+
+func (m *Machine) skipIf(condition bool) {
+	if condition {
+		m.PC += 2
+	}
+}
+
+func (m *Machine) isPressed(key byte) bool {
+	return key < 16 && m.Keys[key]
+}
+
+//VX gets the result and then VF the flag
+func (m *Machine) setWithFlag(op opcode, result byte, flag bool) {
+	m.V[op.x()] = result
+	if flag {
+		m.V[0xF] = 1
+	} else {
+		m.V[0xF] = 0
+	}
+}
+
+func (m *Machine) logic(op opcode, result byte) {
+	m.V[op.x()] = result
+	if m.Options.ResetVF {
+		m.V[0xF] = 0
+	}
+}
+
+func (m *Machine) shiftSource(op opcode) byte {
+	if m.Options.ShiftVY {
+		return m.V[op.y()]
+	}
+	return m.V[op.x()]
+}
+
+func (m *Machine) incrementI(op opcode) {
+	switch {
+	case m.Options.IncrementI:
+		m.I += uint16(op.x()) + 1
+	case m.Options.IncrementIByX:
+		m.I += uint16(op.x())
+	}
+}
+
+func (m *Machine) checkMemory(address uint16, size int) error {
+	if int(address)+size > MemorySize {
+		return fmt.Errorf("reference: %v bytes at %.4X are past the end of memory", size, address)
+	}
+	return nil
+}
+
+//Draws one dot at a time, the start wraps and the rest clips unless WrapSprites is set
+func (m *Machine) draw(op opcode) error {
+	rows := op.n()
+	if err := m.checkMemory(m.I, rows); err != nil {
+		return err
+	}
+
+	startX, startY := int(m.V[op.x()])%Width, int(m.V[op.y()])%Height
+	m.V[0xF] = 0
+	for row := 0; row < rows; row++ {
+		line := m.Memory[int(m.I)+row]
+		for column := 0; column < 8; column++ {
+			if line&(0x80>>column) == 0 {
+				continue
+			}
+			x, y := startX+column, startY+row
+			if x >= Width || y >= Height {
+				if !m.Options.WrapSprites {
+					continue
+				}
+				x, y = x%Width, y%Height
+			}
+			if m.Display[y][x] {
+				m.V[0xF] = 1
+			}
+			m.Display[y][x] = !m.Display[y][x]
+		}
+	}
+	m.WaitingForVBlank = m.Options.DisplayWait
+	return nil
+}
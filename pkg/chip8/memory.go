@@ -3,16 +3,15 @@ package chip8
 import "fmt"
 
 const (
-	RamSize             = 0x1000
-	programStart        = 0x200
-	digitSpriteLocation = 0x0 //Address where the digit sprites start
+	RamSize      = 0x1000
+	programStart = 0x200
 )
 
 type memory [RamSize]byte
 
 func (ram *memory) loadProgam(program []byte) error {
-	if len(program)-200 > RamSize {
-		return fmt.Errorf("ram error: program is too large and cannot be loaded into memory")
+	if len(program) > RamSize-programStart {
+		return fmt.Errorf("ram error: %v bytes %w", len(program), ErrProgramTooLarge)
 	}
 	for i, programByte := range program {
 		ram[i+programStart] = programByte
@@ -20,6 +19,14 @@ func (ram *memory) loadProgam(program []byte) error {
 	return nil
 }
 
+//Errors when size bytes from address don't fit in memory, name is the instruction for the message
+func (ram *memory) checkRange(name string, address Address, size int) error {
+	if int(address)+size > RamSize {
+		return fmt.Errorf("%s error: %v bytes at 0x%.4X %w", name, size, uint16(address), ErrOutOfMemory)
+	}
+	return nil
+}
+
 func (memory *memory) getSprite(address Address, size byte) []byte {
 	sprite := make([]byte, size)
 
@@ -30,8 +37,8 @@ func (memory *memory) getSprite(address Address, size byte) []byte {
 	return sprite
 }
 
-func (ram *memory) loadFont() {
-	for i, fontByte := range font {
-		ram[i+digitSpriteLocation] = fontByte
-	}
+//Small digits at location followed by the large digits
+func (ram *memory) loadFont(font Font, location Address) {
+	copy(ram[location:], font.Small)
+	copy(ram[int(location)+len(font.Small):], font.Large)
 }
@@ -0,0 +1,190 @@
+package chip8
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+//Minimal encoder for the pprof profile.proto format
+//Only the messages needed for the rom profiler are implemented
+
+//Field numbers from github.com/google/pprof/proto/profile.proto
+const (
+	profileSampleType  = 1
+	profileSample      = 2
+	profileLocation    = 4
+	profileFunction    = 5
+	profileStringTable = 6
+	profilePeriodType  = 11
+	profilePeriod      = 12
+	profileComment     = 13
+	profileDefaultType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID      = 1
+	locationAddress = 3
+	locationLine    = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID   = 1
+	functionName = 2
+)
+
+type protoBuffer struct {
+	data []byte
+}
+
+func (buffer *protoBuffer) varint(value uint64) {
+	for value >= 0x80 {
+		buffer.data = append(buffer.data, byte(value)|0x80)
+		value >>= 7
+	}
+	buffer.data = append(buffer.data, byte(value))
+}
+
+func (buffer *protoBuffer) tag(field int, wireType int) {
+	buffer.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (buffer *protoBuffer) uint64(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	buffer.tag(field, 0)
+	buffer.varint(value)
+}
+
+func (buffer *protoBuffer) int64(field int, value int64) {
+	buffer.uint64(field, uint64(value))
+}
+
+func (buffer *protoBuffer) packed(field int, values []uint64) {
+	if len(values) == 0 {
+		return
+	}
+	packed := protoBuffer{}
+	for _, value := range values {
+		packed.varint(value)
+	}
+	buffer.bytes(field, packed.data)
+}
+
+func (buffer *protoBuffer) bytes(field int, value []byte) {
+	buffer.tag(field, 2)
+	buffer.varint(uint64(len(value)))
+	buffer.data = append(buffer.data, value...)
+}
+
+func (buffer *protoBuffer) message(field int, message *protoBuffer) {
+	buffer.bytes(field, message.data)
+}
+
+//Builds a profile with a string table, locations are rom addresses and functions are subroutines
+type pprofBuilder struct {
+	profile   protoBuffer
+	strings   map[string]int64
+	locations map[Address]uint64
+	functions map[Address]uint64
+	stringTab []string
+}
+
+func newPprofBuilder(sampleTypes [][2]string, comment string) *pprofBuilder {
+	builder := &pprofBuilder{
+		strings:   map[string]int64{},
+		locations: map[Address]uint64{},
+		functions: map[Address]uint64{},
+	}
+	builder.str("") //String 0 must always be empty
+
+	for _, sampleType := range sampleTypes {
+		builder.profile.message(profileSampleType, builder.valueType(sampleType[0], sampleType[1]))
+	}
+	builder.profile.message(profilePeriodType, builder.valueType(sampleTypes[0][0], sampleTypes[0][1]))
+	builder.profile.int64(profilePeriod, 1)
+	builder.profile.int64(profileComment, builder.str(comment))
+	builder.profile.int64(profileDefaultType, builder.str(sampleTypes[0][0]))
+	return builder
+}
+
+func (builder *pprofBuilder) str(value string) int64 {
+	index, ok := builder.strings[value]
+	if !ok {
+		index = int64(len(builder.stringTab))
+		builder.strings[value] = index
+		builder.stringTab = append(builder.stringTab, value)
+	}
+	return index
+}
+
+func (builder *pprofBuilder) valueType(typeName, unit string) *protoBuffer {
+	message := &protoBuffer{}
+	message.int64(valueTypeType, builder.str(typeName))
+	message.int64(valueTypeUnit, builder.str(unit))
+	return message
+}
+
+func (builder *pprofBuilder) function(entry Address, name string) uint64 {
+	id, ok := builder.functions[entry]
+	if !ok {
+		id = uint64(len(builder.functions) + 1)
+		builder.functions[entry] = id
+
+		message := &protoBuffer{}
+		message.uint64(functionID, id)
+		message.int64(functionName, builder.str(name))
+		builder.profile.message(profileFunction, message)
+	}
+	return id
+}
+
+//The line number of a location is its address so pprof can list hot addresses within a subroutine
+func (builder *pprofBuilder) location(address Address, function uint64) uint64 {
+	id, ok := builder.locations[address]
+	if !ok {
+		id = uint64(len(builder.locations) + 1)
+		builder.locations[address] = id
+
+		line := &protoBuffer{}
+		line.uint64(lineFunctionID, function)
+		line.int64(lineLine, int64(address))
+
+		message := &protoBuffer{}
+		message.uint64(locationID, id)
+		message.uint64(locationAddress, uint64(address))
+		message.message(locationLine, line)
+		builder.profile.message(profileLocation, message)
+	}
+	return id
+}
+
+//Locations are ordered leaf first
+func (builder *pprofBuilder) sample(locations []uint64, values []int64) {
+	converted := make([]uint64, len(values))
+	for i, value := range values {
+		converted[i] = uint64(value)
+	}
+
+	message := &protoBuffer{}
+	message.packed(sampleLocationID, locations)
+	message.packed(sampleValue, converted)
+	builder.profile.message(profileSample, message)
+}
+
+func (builder *pprofBuilder) write(writer io.Writer) error {
+	for _, value := range builder.stringTab {
+		builder.profile.bytes(profileStringTable, []byte(value))
+	}
+
+	compressed := gzip.NewWriter(writer)
+	if _, err := compressed.Write(builder.profile.data); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
@@ -0,0 +1,304 @@
+package chip8
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+//Profiler counts where a rom spends its cycles
+//Add it to a system with both AddObserver and AddFrameObserver
+type Profiler struct {
+	instructions uint64
+	waitCycles   uint64
+
+	pcCounts   [RamSize]uint64
+	opcodes    [RamSize]Instruction
+	waits      map[Address]uint64 //FX0A address to cycles spent waiting
+	classes    map[string]uint64
+	routines   map[Address]*RoutineProfile
+	stacks     map[stackKey]*stackSample
+	frameCount uint64
+	frameMin   uint64
+	frameMax   uint64
+	frameStart uint64 //Instruction count at the start of the current frame
+
+	frames []Address //Entry address of each active subroutine, the first is the program entry
+
+	symbols Symbols
+}
+
+//Instruction counts for a subroutine identified by its entry address
+type RoutineProfile struct {
+	Entry     Address
+	Calls     uint64
+	Inclusive uint64 //Instructions executed in the subroutine and everything it calls
+	Exclusive uint64 //Instructions executed in the subroutine itself
+}
+
+type stackKey struct {
+	pc      Address
+	depth   byte
+	returns [maxSubroutineLevel]Address
+	frames  [maxSubroutineLevel + 1]Address
+}
+
+type stackSample struct {
+	pc           Address
+	returns      []Address
+	frames       []Address
+	instructions uint64
+	waitCycles   uint64
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		waits:    map[Address]uint64{},
+		classes:  map[string]uint64{},
+		routines: map[Address]*RoutineProfile{},
+		stacks:   map[stackKey]*stackSample{},
+	}
+}
+
+//Reports and profiles name addresses and subroutines with symbols
+func (profiler *Profiler) SetSymbols(symbols Symbols) {
+	profiler.symbols = symbols
+}
+
+func (profiler *Profiler) ObserveInstruction(state *State, opcode Instruction) {
+	profiler.trackFrames(state)
+	sample := profiler.stackSample(state)
+
+	if state.WaitingForKey {
+		profiler.waitCycles++
+		profiler.waits[state.PC]++
+		sample.waitCycles++
+		return
+	}
+
+	profiler.instructions++
+	profiler.pcCounts[state.PC]++
+	profiler.opcodes[state.PC] = opcode
+	profiler.classes[OpcodeClass(opcode)]++
+	sample.instructions++
+
+	for i, entry := range profiler.frames {
+		if !containsAddress(profiler.frames[:i], entry) { //Recursive subroutines are only counted once
+			profiler.routines[entry].Inclusive++
+		}
+	}
+	profiler.routines[profiler.frames[len(profiler.frames)-1]].Exclusive++
+}
+
+func (profiler *Profiler) ObserveFrame(frame uint64) {
+	executed := profiler.instructions - profiler.frameStart
+	if profiler.frameCount == 0 || executed < profiler.frameMin {
+		profiler.frameMin = executed
+	}
+	if executed > profiler.frameMax {
+		profiler.frameMax = executed
+	}
+	profiler.frameCount++
+	profiler.frameStart = profiler.instructions
+}
+
+//Keeps the active subroutines in step with the stack pointer
+//A subroutine is entered at the first instruction seen after the stack grows
+func (profiler *Profiler) trackFrames(state *State) {
+	depth := int(state.SP) + 1
+	for len(profiler.frames) > depth {
+		profiler.frames = profiler.frames[:len(profiler.frames)-1]
+	}
+	for len(profiler.frames) < depth {
+		profiler.frames = append(profiler.frames, state.PC)
+
+		routine, ok := profiler.routines[state.PC]
+		if !ok {
+			routine = &RoutineProfile{Entry: state.PC}
+			profiler.routines[state.PC] = routine
+		}
+		routine.Calls++
+	}
+}
+
+func (profiler *Profiler) stackSample(state *State) *stackSample {
+	key := stackKey{pc: state.PC, depth: state.SP}
+	copy(key.returns[:], state.Stack[:state.SP])
+	copy(key.frames[:], profiler.frames)
+
+	sample, ok := profiler.stacks[key]
+	if !ok {
+		sample = &stackSample{
+			pc:      state.PC,
+			returns: append([]Address(nil), key.returns[:state.SP]...),
+			frames:  append([]Address(nil), profiler.frames...),
+		}
+		profiler.stacks[key] = sample
+	}
+	return sample
+}
+
+//Subroutines sorted by inclusive count
+func (profiler *Profiler) Routines() []RoutineProfile {
+	result := make([]RoutineProfile, 0, len(profiler.routines))
+	for _, routine := range profiler.routines {
+		result = append(result, *routine)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Inclusive != result[j].Inclusive {
+			return result[i].Inclusive > result[j].Inclusive
+		}
+		return result[i].Entry < result[j].Entry
+	})
+	return result
+}
+
+func (profiler *Profiler) Count(address Address) uint64 {
+	return profiler.pcCounts[address]
+}
+
+func (profiler *Profiler) ClassCount(class string) uint64 {
+	return profiler.classes[class]
+}
+
+func (profiler *Profiler) WaitCycles() uint64 {
+	return profiler.waitCycles
+}
+
+//Writes a plain text report, top limits the number of hot addresses listed
+func (profiler *Profiler) WriteReport(writer io.Writer, top int) error {
+	report := &reportWriter{writer: writer}
+
+	report.printf("instructions: %v\n", profiler.instructions)
+	report.printf("FX0A wait cycles: %v\n", profiler.waitCycles)
+	if profiler.frameCount > 0 {
+		report.printf("instructions per frame: min %v avg %.1f max %v over %v frames\n",
+			profiler.frameMin, float64(profiler.instructions)/float64(profiler.frameCount), profiler.frameMax, profiler.frameCount)
+	}
+
+	report.printf("\nhot addresses:\n")
+	addresses := []Address{}
+	for address, count := range profiler.pcCounts {
+		if count > 0 {
+			addresses = append(addresses, Address(address))
+		}
+	}
+	sort.SliceStable(addresses, func(i, j int) bool {
+		return profiler.pcCounts[addresses[i]] > profiler.pcCounts[addresses[j]]
+	})
+	if top > 0 && len(addresses) > top {
+		addresses = addresses[:top]
+	}
+	for _, address := range addresses {
+		report.printf("  0x%.3X %10v %6.2f%%  %s%s\n", address, profiler.pcCounts[address],
+			profiler.percent(profiler.pcCounts[address]), DisassembleSymbols(profiler.opcodes[address], profiler.symbols), profiler.location(address))
+	}
+
+	report.printf("\ninstruction mix:\n")
+	classes := make([]string, 0, len(profiler.classes))
+	for class := range profiler.classes {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if profiler.classes[classes[i]] != profiler.classes[classes[j]] {
+			return profiler.classes[classes[i]] > profiler.classes[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+	for _, class := range classes {
+		report.printf("  %s %10v %6.2f%%\n", class, profiler.classes[class], profiler.percent(profiler.classes[class]))
+	}
+
+	report.printf("\nsubroutines:\n  entry      calls  inclusive  exclusive\n")
+	for _, routine := range profiler.Routines() {
+		report.printf("  0x%.3X %10v %10v %10v%s\n", routine.Entry, routine.Calls, routine.Inclusive, routine.Exclusive, profiler.location(routine.Entry))
+	}
+
+	if len(profiler.waits) > 0 {
+		report.printf("\nFX0A waits:\n")
+		for _, address := range sortedAddresses(profiler.waits) {
+			report.printf("  0x%.3X %10v cycles%s\n", address, profiler.waits[address], profiler.location(address))
+		}
+	}
+	return report.err
+}
+
+//Writes a gzipped pprof profile for use with go tool pprof
+//Functions are subroutines named by their label or entry address and line numbers are rom addresses
+func (profiler *Profiler) WriteProfile(writer io.Writer) error {
+	builder := newPprofBuilder([][2]string{{"instructions", "count"}, {"fx0a_wait", "cycles"}}, "gChip8 rom profile")
+
+	samples := make([]*stackSample, 0, len(profiler.stacks))
+	for _, sample := range profiler.stacks {
+		samples = append(samples, sample)
+	}
+	sort.Slice(samples, func(i, j int) bool { //Deterministic output
+		return fmt.Sprint(samples[i].pc, samples[i].frames, samples[i].returns) < fmt.Sprint(samples[j].pc, samples[j].frames, samples[j].returns)
+	})
+
+	for _, sample := range samples {
+		//Leaf first, each frame is attributed to the subroutine that was active there
+		depth := len(sample.frames) - 1
+		locations := []uint64{builder.location(sample.pc, builder.function(sample.frames[depth], profiler.routineName(sample.frames[depth])))}
+		for i := len(sample.returns) - 1; i >= 0; i-- {
+			callSite := sample.returns[i] - instructionSize
+			locations = append(locations, builder.location(callSite, builder.function(sample.frames[i], profiler.routineName(sample.frames[i]))))
+		}
+		builder.sample(locations, []int64{int64(sample.instructions), int64(sample.waitCycles)})
+	}
+	return builder.write(writer)
+}
+
+func (profiler *Profiler) percent(count uint64) float64 {
+	if profiler.instructions == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(profiler.instructions)
+}
+
+func containsAddress(addresses []Address, address Address) bool {
+	for _, other := range addresses {
+		if other == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (profiler *Profiler) routineName(entry Address) string {
+	if profiler.symbols != nil {
+		if name, start, ok := profiler.symbols.Label(entry); ok && start == entry {
+			return name
+		}
+	}
+	return fmt.Sprintf("sub_%.3X", entry)
+}
+
+//Report suffix naming an address when there are symbols
+func (profiler *Profiler) location(address Address) string {
+	if profiler.symbols == nil {
+		return ""
+	}
+	return "  " + Symbolize(profiler.symbols, address)
+}
+
+func sortedAddresses(counts map[Address]uint64) []Address {
+	result := make([]Address, 0, len(counts))
+	for address := range counts {
+		result = append(result, address)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+//Keeps the first write error so reports don't need to check every line
+type reportWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (report *reportWriter) printf(format string, args ...interface{}) {
+	if report.err == nil {
+		_, report.err = fmt.Fprintf(report.writer, format, args...)
+	}
+}
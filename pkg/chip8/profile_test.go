@@ -0,0 +1,67 @@
+package chip8
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+//0x200 CALL 0x206, 0x202 JP 0x202, 0x206 ADD V0, 1, 0x208 RET
+var profiledProgram = []byte{0x22, 0x06, 0x12, 0x02, 0, 0, 0x70, 0x01, 0x00, 0xEE}
+
+func TestProfilerCounts(t *testing.T) {
+	profiler := runProfiler(t, 10)
+
+	if profiler.Count(0x202) != 7 || profiler.Count(0x206) != 1 {
+		t.Errorf("FAIL count[0x202]=%v (expected 7) count[0x206]=%v (expected 1)", profiler.Count(0x202), profiler.Count(0x206))
+	}
+	if profiler.ClassCount("1NNN") != 7 || profiler.ClassCount("2NNN") != 1 {
+		t.Errorf("FAIL 1NNN=%v (expected 7) 2NNN=%v (expected 1)", profiler.ClassCount("1NNN"), profiler.ClassCount("2NNN"))
+	}
+
+	routines := profiler.Routines()
+	if len(routines) != 2 {
+		t.Fatalf("FAIL %v routines (expected 2)", len(routines))
+	}
+	main, sub := routines[0], routines[1]
+	if main.Entry != 0x200 || main.Inclusive != 10 || main.Exclusive != 8 {
+		t.Errorf("FAIL main=%+v (expected entry 0x200 inclusive 10 exclusive 8)", main)
+	}
+	if sub.Entry != 0x206 || sub.Calls != 1 || sub.Inclusive != 2 || sub.Exclusive != 2 {
+		t.Errorf("FAIL sub=%+v (expected entry 0x206 calls 1 inclusive 2 exclusive 2)", sub)
+	}
+}
+
+func TestProfilerOutput(t *testing.T) {
+	profiler := runProfiler(t, 10)
+
+	var report bytes.Buffer
+	if err := profiler.WriteReport(&report, 5); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "0x202          7  70.00%  JP 0x202") {
+		t.Errorf("FAIL report missing hot address:\n%v", report.String())
+	}
+
+	var profile bytes.Buffer
+	if err := profiler.WriteProfile(&profile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gzip.NewReader(&profile); err != nil {
+		t.Errorf("FAIL profile is not gzipped: %v", err)
+	}
+}
+
+func runProfiler(t *testing.T, cycles int) *Profiler {
+	system := createNewSystem(profiledProgram)
+	profiler := NewProfiler()
+	system.AddObserver(profiler)
+
+	for i := 0; i < cycles; i++ {
+		if err := system.cpu.cycle(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return profiler
+}
@@ -0,0 +1,40 @@
+package chip8
+
+//Behaviour that differs between chip8 interpreters
+//The zero value keeps this interpreter's original behaviour, VIPQuirks matches the COSMAC VIP
+type Quirks struct {
+	//FX0A completes as soon as a key is pressed instead of waiting for it to be released
+	KeyWaitOnPress bool
+	//8XY6 and 8XYE shift VY into VX instead of shifting VX in place
+	ShiftVY bool
+	//FX55 and FX65 leave I at I+X+1
+	IncrementI bool
+	//FX55 and FX65 leave I at I+X, used by SUPER-CHIP 1.0
+	IncrementIByX bool
+	//8XY1, 8XY2 and 8XY3 reset VF
+	ResetVF bool
+	//BXNN jumps to XNN+VX instead of BNNN jumping to NNN+V0
+	JumpVX bool
+	//DXYN waits for the vertical blank so at most one sprite is drawn per frame
+	DisplayWait bool
+	//DXYN wraps the parts of a sprite past the edges around to the other side instead of clipping them
+	WrapSprites bool
+}
+
+func VIPQuirks() Quirks {
+	return Quirks{ShiftVY: true, IncrementI: true, ResetVF: true, DisplayWait: true}
+}
+
+func (quirks Quirks) memoryIncrement(vX byte) Address {
+	switch {
+	case quirks.IncrementI:
+		return Address(vX) + 1
+	case quirks.IncrementIByX:
+		return Address(vX)
+	}
+	return 0
+}
+
+func (system *Chip8) SetQuirks(quirks Quirks) {
+	system.cpu.quirks = quirks
+}
@@ -0,0 +1,58 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestQuirks(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []byte
+		quirks  Quirks
+		check   func(system *Chip8) bool
+	}{
+		//Registers start as Vn=n and I=0x300
+		{"shift in place", []byte{0x84, 0x56}, Quirks{}, func(s *Chip8) bool { return s.cpu.Registers[4] == 2 && s.cpu.Registers[0xF] == 0 }},
+		{"shift VY", []byte{0x84, 0x56}, Quirks{ShiftVY: true}, func(s *Chip8) bool { return s.cpu.Registers[4] == 2 && s.cpu.Registers[0xF] == 1 }},
+		{"shift left VY", []byte{0x84, 0x5E}, Quirks{ShiftVY: true}, func(s *Chip8) bool { return s.cpu.Registers[4] == 10 }},
+		{"I unchanged", []byte{0xF3, 0x55}, Quirks{}, func(s *Chip8) bool { return s.cpu.RegisterI == 0x300 }},
+		{"increment I", []byte{0xF3, 0x55}, Quirks{IncrementI: true}, func(s *Chip8) bool { return s.cpu.RegisterI == 0x304 }},
+		{"increment I by X", []byte{0xF3, 0x65}, Quirks{IncrementIByX: true}, func(s *Chip8) bool { return s.cpu.RegisterI == 0x303 }},
+		{"keep VF", []byte{0x81, 0x21}, Quirks{}, func(s *Chip8) bool { return s.cpu.Registers[0xF] == 0xF }},
+		{"reset VF", []byte{0x81, 0x21}, Quirks{ResetVF: true}, func(s *Chip8) bool { return s.cpu.Registers[1] == 3 && s.cpu.Registers[0xF] == 0 }},
+		{"jump V0", []byte{0xB3, 0x00}, Quirks{}, func(s *Chip8) bool { return s.cpu.programCounter == 0x300 }},
+		{"jump VX", []byte{0xB3, 0x00}, Quirks{JumpVX: true}, func(s *Chip8) bool { return s.cpu.programCounter == 0x303 }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			system := createNewSystem(test.program)
+			system.SetQuirks(test.quirks)
+			cycle(t, system)
+			if !test.check(system) {
+				t.Errorf("FAIL registers=%v I=0x%.3X pc=0x%.3X", system.cpu.Registers, system.cpu.RegisterI, system.cpu.programCounter)
+			}
+		})
+	}
+}
+
+func TestDisplayWait(t *testing.T) {
+	//DRW V0, V0, 1 then ADD V1, 1 looping
+	program := []byte{0xD0, 0x01, 0x71, 0x01, 0x12, 0x00}
+
+	for _, wait := range []bool{false, true} {
+		system := createNewSystem(program)
+		system.Configure(Config{Quirks: Quirks{DisplayWait: wait}, TickRate: 9})
+		if err := system.stepFrame(); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := byte(1 + 3) //Three loops in nine instructions
+		if wait {
+			expected = 1 //Nothing runs after the draw
+		}
+		if system.cpu.Registers[1] != expected {
+			t.Errorf("FAIL wait=%v v1=%v (expected %v)", wait, system.cpu.Registers[1], expected)
+		}
+	}
+}
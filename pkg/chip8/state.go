@@ -0,0 +1,79 @@
+package chip8
+
+//Snapshot of the visible cpu state
+type State struct {
+	PC        Address
+	Registers [registerCount]byte
+	I         Address
+	SP        byte
+	Stack     [maxSubroutineLevel]Address
+	Delay     byte
+	Sound     byte
+
+	WaitingForKey bool
+}
+
+//Observers are notified every cycle before the instruction is executed
+//While FX0A is waiting for a key the same instruction is reported with WaitingForKey set
+type InstructionObserver interface {
+	ObserveInstruction(state *State, opcode Instruction)
+}
+
+func (cpu *cpu) state() State {
+	return State{
+		PC:            cpu.programCounter,
+		Registers:     cpu.Registers,
+		I:             cpu.RegisterI,
+		SP:            cpu.stackPointer,
+		Stack:         cpu.stack,
+		Delay:         cpu.DelayRegister,
+		Sound:         cpu.SoundRegister,
+		WaitingForKey: cpu.isWaitingForInput,
+	}
+}
+
+func (cpu *cpu) notifyObservers() {
+	if len(cpu.observers) == 0 {
+		return
+	}
+
+	state := cpu.state()
+	state.PC = cpu.instructionAddress //Report the instruction being executed not the next one
+	for _, observer := range cpu.observers {
+		observer.ObserveInstruction(&state, cpu.opcode)
+	}
+}
+
+func (system *Chip8) AddObserver(observer InstructionObserver) {
+	system.cpu.observers = append(system.cpu.observers, observer)
+}
+
+//Frame observers are notified at the end of every 60hz frame
+type FrameObserver interface {
+	ObserveFrame(frame uint64)
+}
+
+func (system *Chip8) AddFrameObserver(observer FrameObserver) {
+	system.frameObservers = append(system.frameObservers, observer)
+}
+
+//Frame observers that also implement it hear when a reset or a loaded state breaks the run of frames
+type ResetObserver interface {
+	ObserveReset()
+}
+
+func (system *Chip8) notifyReset() {
+	for _, observer := range system.frameObservers {
+		if resetObserver, ok := observer.(ResetObserver); ok {
+			resetObserver.ObserveReset()
+		}
+	}
+}
+
+func (system *Chip8) endFrame() {
+	system.applyFrozen()
+	for _, observer := range system.frameObservers {
+		observer.ObserveFrame(system.frame)
+	}
+	system.frame++
+}
@@ -0,0 +1,49 @@
+package chip8
+
+//Summary of the running system for frontends such as a debug overlay
+type Status struct {
+	State
+	Input     Input
+	LastKey   KeyEvent //Last press or release with the frame it was applied on
+	Frame     uint64
+	Cycles    uint64  //Instructions executed since the system started
+	Frequency float64 //Instructions per second the system aims for at normal speed, 0 under VIP timing
+	IsPaused  bool
+	Speed     int
+
+	HasSavedState bool
+}
+
+//Channel that gets a status at the end of every 60hz tick, must be called before Run
+//Updates are dropped while the channel is full so a slow reader never holds up the system
+func (system *Chip8) StatusChannel() <-chan Status {
+	if system.statusChannel == nil {
+		system.statusChannel = make(chan Status, 1)
+	}
+	return system.statusChannel
+}
+
+func (system *Chip8) status() Status {
+	return Status{
+		State:     system.cpu.state(),
+		Input:     system.input,
+		LastKey:   system.lastKey,
+		Frame:     system.frame,
+		Cycles:    system.cycles,
+		Frequency: system.frequency,
+		IsPaused:  system.isPaused,
+		Speed:     system.speed,
+
+		HasSavedState: system.savedState != nil,
+	}
+}
+
+func (system *Chip8) sendStatus() {
+	if system.statusChannel == nil {
+		return
+	}
+	select {
+	case system.statusChannel <- system.status():
+	default:
+	}
+}
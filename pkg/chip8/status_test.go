@@ -0,0 +1,37 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	system := createNewSystem([]byte{0x70, 0x01, 0x12, 0x00})
+	system.Configure(Config{TickRate: 10})
+	statusChannel := system.StatusChannel()
+	system.input.PressKey(0xA)
+
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	system.sendStatus()
+	system.sendStatus() //Dropped as the channel is full
+
+	status := <-statusChannel
+	if status.Cycles != 10 || status.Frame != 1 || status.Frequency != 600 || status.Registers[0] != 5 {
+		t.Errorf("FAIL cycles=%v frame=%v frequency=%v v0=%v (expected 10, 1, 600 and 5)", status.Cycles, status.Frame, status.Frequency, status.Registers[0])
+	}
+	if !status.Input.IsPressed(0xA) || status.Input.IsPressed(0xB) || status.HasSavedState {
+		t.Errorf("FAIL input=%016b saved=%v", status.Input, status.HasSavedState)
+	}
+	select {
+	case <-statusChannel:
+		t.Errorf("FAIL second status was not dropped")
+	default:
+	}
+
+	//VIP timing ignores the tick rate, so there is no frequency to aim for
+	system.Configure(Config{TickRate: 10, Timing: TimingVIP})
+	if status := system.status(); status.Frequency != 0 {
+		t.Errorf("FAIL frequency=%v under VIP timing (expected 0)", status.Frequency)
+	}
+}
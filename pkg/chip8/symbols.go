@@ -0,0 +1,38 @@
+package chip8
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+SYMBOLS
+Debug output names addresses with the labels from a rom's symbol file when there is one, see pkg/symbols
+An address past a label is shown as an offset from it like main_loop+0x6, without a label it stays in hex
+*/
+
+type Symbols interface {
+	//Closest label at or before address and the address of that label
+	Label(address Address) (name string, start Address, ok bool)
+}
+
+func Symbolize(symbols Symbols, address Address) string {
+	if symbols != nil {
+		if name, start, ok := symbols.Label(address); ok {
+			if start == address {
+				return name
+			}
+			return fmt.Sprintf("%s+0x%X", name, uint16(address-start))
+		}
+	}
+	return fmt.Sprintf("0x%.3X", uint16(address))
+}
+
+//Current instruction then each call on the stack, innermost first e.g. main_loop+0x6 ← draw_player
+func StackTrace(symbols Symbols, state State) string {
+	frames := []string{Symbolize(symbols, state.PC)}
+	for i := int(state.SP) - 1; i >= 0; i-- {
+		frames = append(frames, Symbolize(symbols, state.Stack[i]-instructionSize))
+	}
+	return strings.Join(frames, " ← ")
+}
@@ -0,0 +1,74 @@
+package chip8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+//Labels for profiledProgram
+type testSymbols map[Address]string
+
+func (symbols testSymbols) Label(address Address) (string, Address, bool) {
+	for start := address; start >= programStart; start-- {
+		if name, ok := symbols[start]; ok {
+			return name, start, true
+		}
+	}
+	return "", 0, false
+}
+
+var profiledSymbols = testSymbols{0x200: "main", 0x202: "main_loop", 0x206: "draw_player"}
+
+func TestSymbolize(t *testing.T) {
+	tests := []struct {
+		symbols  Symbols
+		address  Address
+		expected string
+	}{
+		{nil, 0x2A6, "0x2A6"},
+		{profiledSymbols, 0x1FE, "0x1FE"},
+		{profiledSymbols, 0x202, "main_loop"},
+		{profiledSymbols, 0x20C, "draw_player+0x6"},
+	}
+	for _, test := range tests {
+		if result := Symbolize(test.symbols, test.address); result != test.expected {
+			t.Errorf("FAIL Symbolize(0x%.3X)=%v (expected %v)", test.address, result, test.expected)
+		}
+	}
+
+	state := State{PC: 0x208, SP: 2, Stack: [maxSubroutineLevel]Address{0x202, 0x208}}
+	if trace := StackTrace(profiledSymbols, state); trace != "draw_player+0x2 ← draw_player ← main" {
+		t.Errorf("FAIL StackTrace=%v (expected draw_player+0x2 ← draw_player ← main)", trace)
+	}
+	if mnemonic := DisassembleSymbols(0x2206, profiledSymbols); mnemonic != "CALL draw_player" {
+		t.Errorf("FAIL DisassembleSymbols(2206)=%v (expected CALL draw_player)", mnemonic)
+	}
+}
+
+func TestSymbolOutput(t *testing.T) {
+	var buffer bytes.Buffer
+	system := createNewSystem(profiledProgram)
+	tracer := NewTracer(&buffer, TraceText)
+	tracer.SetSymbols(profiledSymbols)
+	system.AddObserver(tracer)
+	for i := 0; i < 2; i++ {
+		if err := system.cpu.cycle(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tracer.Flush()
+	if lines := strings.Split(buffer.String(), "\n"); !strings.HasSuffix(lines[1], "; draw_player: ADD V0, 0x01") {
+		t.Errorf("FAIL trace line=%q (expected the draw_player label)", lines[1])
+	}
+
+	profiler := runProfiler(t, 10)
+	profiler.SetSymbols(profiledSymbols)
+	var report bytes.Buffer
+	if err := profiler.WriteReport(&report, 5); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(report.String(), "JP main_loop  main_loop\n") || !strings.Contains(report.String(), "  draw_player\n") {
+		t.Errorf("FAIL report missing labels:\n%v", report.String())
+	}
+}
@@ -1,7 +1,9 @@
 package chip8
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 	"time"
 )
 
@@ -10,70 +12,257 @@ const (
 	counterFrequency = 60.0    //hz
 
 	channelBuffer
+
+	speedUpFactor = 4 //Frames run per tick while sped up
 )
 
 type Chip8 struct {
 	cpu     cpu
 	ram     memory
 	input   Input
+	lastKey KeyEvent
 	display Display
 
 	displayChannel chan<- Display
-	inputChannel   <-chan Input
-	powerChannel   <-chan bool
+	inputChannel   <-chan KeyEvent
+	commandChannel <-chan Command
+	statusChannel  chan Status
+
+	snapshotChannel chan Snapshot
+	debugChannel    chan DebugEvent
+	breakpoints     map[Address]bool
+	isResuming      bool  //The next instruction runs even if it has a breakpoint
+	debugError      error //Why the last debug event was rejected
+
+	cheatChannel chan []Cheat
+	cheats       []Cheat //Freezes and the pokes already written
+	cheatError   error   //Why the last cheats from the channel were rejected
 
 	IsRunning      bool
 	frequency      float64
 	cyclesPerFrame int
+	isPaused       bool
+	speed          int //Frames run per tick
+
+	program      []byte
+	savedState   *savedState
+	font         Font
+	fontLocation Address
+
+	frame          uint64
+	cycles         uint64
+	timing         Timing
+	seed           int64
+	isSeeded       bool
+	cycleDebt      int  //Machine cycles the last frame overran by, VIP timing only
+	frameSteps     int  //Instructions run in the current frame
+	frameBudget    int  //Machine cycles left in the current frame, VIP timing only
+	isFrameOpen    bool //A frame has started and its timers haven't counted down yet
+	isFrameDone    bool //The open frame has no instructions or cycles left
+	frameObservers []FrameObserver
+}
+
+//Settings applied when a system is constructed
+type Config struct {
+	Quirks   Quirks
+	TickRate int //Instructions per 60hz frame
+
+	Font         Font    //Zero value is the first of Fonts
+	FontLocation Address //Where the small digits start, the large digits follow them
+
+	Timing Timing //TickRate only applies to flat timing
+}
+
+//Checks the font fits in memory
+func (config Config) Validate() error {
+	font := config.Font
+	if font.isZero() {
+		font = Fonts[0]
+	}
+	if len(font.Small) != smallFontSize || (len(font.Large) != 0 && len(font.Large) != largeFontSize) {
+		return fmt.Errorf("font error: %s has %v small and %v large bytes (expected %v and %v)", font.Name, len(font.Small), len(font.Large), smallFontSize, largeFontSize)
+	}
+	if int(config.FontLocation)+font.size() > RamSize {
+		return fmt.Errorf("font error: font at 0x%.3X runs past the end of memory", uint16(config.FontLocation))
+	}
+	return nil
 }
 
-func New() (*Chip8, <-chan Display, chan<- Input, chan<- bool) {
+func DefaultConfig() Config {
+	return Config{TickRate: int(math.Floor(defaultFrequency / counterFrequency))}
+}
+
+func New() (*Chip8, <-chan Display, chan<- KeyEvent, chan<- Command) {
+	system, displayChannel, inputChannel, commandChannel, _ := NewWithConfig(DefaultConfig()) //Always valid
+	return system, displayChannel, inputChannel, commandChannel
+}
+
+func NewWithConfig(config Config) (*Chip8, <-chan Display, chan<- KeyEvent, chan<- Command, error) {
 	system := Chip8{}
-	system.ram.loadFont()
 	system.cpu.initialize(&system.ram, &system.input, &system.display)
-	system.frequency = defaultFrequency
-	system.cyclesPerFrame = int(math.Floor(defaultFrequency / counterFrequency))
+	if err := system.Configure(config); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	system.speed = 1
 
-	displayChan, inputChan, powerChan := make(chan Display, channelBuffer), make(chan Input, channelBuffer), make(chan bool, channelBuffer)
+	displayChan, inputChan, commandChan := make(chan Display, channelBuffer), make(chan KeyEvent, channelBuffer), make(chan Command, channelBuffer)
 
 	system.displayChannel = displayChan
 	system.inputChannel = inputChan
-	system.powerChannel = powerChan
+	system.commandChannel = commandChan
+
+	return &system, displayChan, inputChan, commandChan, nil
+}
+
+//Applies a config, an invalid one is an error and leaves the system as it was
+func (system *Chip8) Configure(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if config.TickRate <= 0 {
+		config.TickRate = DefaultConfig().TickRate
+	}
+	if config.Font.isZero() {
+		config.Font = Fonts[0]
+	}
+	system.cpu.quirks = config.Quirks
+	system.cyclesPerFrame = config.TickRate
+	system.frequency = float64(config.TickRate) * counterFrequency
+	if config.Timing == TimingVIP {
+		system.frequency = 0 //Instructions take different times so there is no rate to aim for
+	}
+	system.font, system.fontLocation = config.Font, config.FontLocation
+	system.loadFont()
+	system.timing, system.cycleDebt, system.isFrameOpen = config.Timing, 0, false
+	return nil
+}
+
+//Makes CXNN repeatable, the same seed gives the same numbers after every reset
+func (system *Chip8) Seed(seed int64) {
+	system.seed, system.isSeeded = seed, true
+	system.cpu.random = rand.New(rand.NewSource(seed))
+}
 
-	return &system, displayChan, inputChan, powerChan
+//Copies the font into memory and points FX29 and FX30 at it
+func (system *Chip8) loadFont() {
+	system.ram.loadFont(system.font, system.fontLocation)
+	system.cpu.fontLocation = system.fontLocation
+	system.cpu.largeFontLocation = system.fontLocation + Address(len(system.font.Small))
 }
 
-func (system *Chip8) LoadProgram(program []byte) {
-	system.ram.loadProgam(program)
+func (system *Chip8) LoadProgram(program []byte) error {
+	if err := system.ram.loadProgam(program); err != nil {
+		return err
+	}
+	system.program = program
+	return nil
 }
 
 func (system *Chip8) Run() error {
 	system.IsRunning = true
 
-	delayTicker := time.NewTicker(time.Second / counterFrequency)
+	frameTicker := time.NewTicker(time.Second / counterFrequency)
+	defer frameTicker.Stop()
 	for system.IsRunning {
 		select {
-		case <-delayTicker.C:
-			if system.cpu.SoundRegister > 0 {
-				system.cpu.SoundRegister--
-			}
-			if system.cpu.DelayRegister > 0 {
-				system.cpu.DelayRegister--
-			}
-
-			if system.display.hasChanged {
-				system.displayChannel <- system.display
-				system.display.hasChanged = false
+		case <-frameTicker.C:
+			if !system.isPaused {
+				for i := 0; i < system.speed && !system.isPaused; i++ {
+					if err := system.stepFrame(); err != nil {
+						return err
+					}
+				}
+				system.sendDisplay()
 			}
-		case system.input = <-system.inputChannel:
-			// fmt.Printf("%.16b\n", system.input)
-		default:
-			err := system.cpu.cycle()
-			if err != nil {
+			system.sendStatus()
+			system.sendSnapshot()
+		case event := <-system.inputChannel:
+			system.applyKeyEvent(event)
+		case command := <-system.commandChannel:
+			if err := system.handleCommand(command); err != nil {
 				return err
 			}
+			system.sendDisplay()
+			system.sendSnapshot()
+		case event := <-system.debugChannel:
+			system.debugError = system.applyDebugEvent(event) //A bad edit is shown to the debugger rather than stopping the system
+			system.sendSnapshot()
+		case cheats := <-system.cheatChannel:
+			system.cheatError = system.SetCheats(cheats) //Rejected cheats leave the last ones in place and the system running
+			system.sendSnapshot()
 		}
 	}
 
 	return nil
 }
+
+func (system *Chip8) sendDisplay() {
+	if system.display.hasChanged {
+		system.displayChannel <- system.display
+		system.display.hasChanged = false
+		system.display.hasDrawn = false
+	}
+}
+
+//Runs one 60hz frame worth of cycles and then counts down the timers
+//A breakpoint leaves the frame open and the next call or Step carries on with it
+func (system *Chip8) stepFrame() error {
+	system.startFrame()
+	for !system.isFrameDone {
+		if system.hitBreakpoint() {
+			return nil
+		}
+		if err := system.runInstruction(); err != nil {
+			return err
+		}
+	}
+	system.finishFrame()
+	return nil
+}
+
+//Starts a frame unless one is open already
+func (system *Chip8) startFrame() {
+	if system.isFrameOpen {
+		return
+	}
+	system.isFrameOpen, system.isFrameDone, system.frameSteps = true, false, 0
+	system.cpu.isWaitingForVBlank = false
+	if system.timing == TimingVIP {
+		system.startVIPFrame()
+	}
+}
+
+//Runs an instruction and takes it out of what is left of the frame
+func (system *Chip8) runInstruction() error {
+	vX := system.nextVX()
+	if err := system.cpu.cycle(); err != nil {
+		return err
+	}
+	system.cycles++
+	system.frameSteps++
+
+	if system.timing == TimingVIP {
+		system.chargeVIPCycles(vX)
+	} else {
+		system.isFrameDone = system.frameSteps >= system.cyclesPerFrame || system.cpu.isWaitingForVBlank
+	}
+	return nil
+}
+
+func (system *Chip8) finishFrame() {
+	if system.timing == TimingVIP {
+		system.finishVIPFrame()
+	}
+	system.isFrameOpen = false
+	system.countDownTimers()
+	system.endFrame()
+}
+
+func (system *Chip8) countDownTimers() {
+	if system.cpu.SoundRegister > 0 {
+		system.cpu.SoundRegister--
+	}
+	if system.cpu.DelayRegister > 0 {
+		system.cpu.DelayRegister--
+	}
+}
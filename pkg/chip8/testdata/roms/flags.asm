@@ -0,0 +1,94 @@
+; Results and VF of the arithmetic instructions, each test prints VX and then VF, two tests a row
+;  1+2         200+100
+;  5-3         3-5
+;  3 SUBN 5    5 SUBN 3
+;  0x81 SHR    0x81 SHL
+;  VF=200+100  10+VF=20
+  LD VA, 0
+  LD VB, 0
+
+  LD V3, 1
+  LD V4, 2
+  ADD V3, V4
+  CALL show
+  LD V3, 200
+  LD V4, 100
+  ADD V3, V4
+  CALL show
+
+  LD V3, 5
+  LD V4, 3
+  SUB V3, V4
+  CALL show
+  LD V3, 3
+  LD V4, 5
+  SUB V3, V4
+  CALL show
+
+  LD V3, 3
+  LD V4, 5
+  SUBN V3, V4
+  CALL show
+  LD V3, 5
+  LD V4, 3
+  SUBN V3, V4
+  CALL show
+
+  LD V3, 0x81
+  LD V4, 0x02
+  SHR V3, V4
+  CALL show
+  LD V3, 0x81
+  LD V4, 0x02
+  SHL V3, V4
+  CALL show
+
+  LD VF, 200    ; VF as VX, whether the flag or the sum is left in VF
+  LD V4, 100
+  ADD VF, V4
+  LD V3, VF
+  CALL show
+  LD V3, 10     ; VF as VY
+  LD VF, 20
+  ADD V3, VF
+  CALL show
+
+end:
+  JP end
+
+; Prints V3 and VF, called straight after the instruction being tested
+show:
+  LD V5, VF
+  LD V9, V3
+  CALL print
+  LD F, V5
+  DRW VA, VB, 5
+  ADD VA, 16
+  SE VA, 64
+  RET
+  JP newline
+
+; Prints V9 in decimal at VA, VB and moves VA along 16, uses V0-V2
+print:
+  LD I, scratch
+  LD B, V9
+  LD V2, [I]
+  LD F, V0
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V1
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V2
+  DRW VA, VB, 5
+  ADD VA, 6
+  RET
+
+; Starts a new row of numbers
+newline:
+  LD VA, 0
+  ADD VB, 6
+  RET
+
+scratch:
+  DB 0, 0, 0
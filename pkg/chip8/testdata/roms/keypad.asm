@@ -0,0 +1,42 @@
+; Waits for a key with FX0A and prints it, then waits for B to be held with EX9E and prints 11
+  LD VA, 0
+  LD VB, 0
+  LD V3, K
+  LD V9, V3
+  CALL print
+  CALL newline
+
+  LD V4, 0xB
+wait:
+  SKP V4
+  JP wait
+  LD V9, V4
+  CALL print
+
+end:
+  JP end
+
+; Prints V9 in decimal at VA, VB and moves VA along 16, uses V0-V2
+print:
+  LD I, scratch
+  LD B, V9
+  LD V2, [I]
+  LD F, V0
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V1
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V2
+  DRW VA, VB, 5
+  ADD VA, 6
+  RET
+
+; Starts a new row of numbers
+newline:
+  LD VA, 0
+  ADD VB, 6
+  RET
+
+scratch:
+  DB 0, 0, 0
@@ -0,0 +1,73 @@
+; Memory instructions
+;  FX33 of 137
+;  FX55 then FX65 of V0-V3 = 1 2 3 4
+;  FX1E I+2 then FX65 of V0 = 3
+  LD VA, 0
+  LD VB, 0
+  LD V9, 137
+  CALL print
+  CALL newline
+
+  LD V0, 1
+  LD V1, 2
+  LD V2, 3
+  LD V3, 4
+  LD I, buffer
+  LD [I], V3
+  LD V0, 0
+  LD V1, 0
+  LD V2, 0
+  LD V3, 0
+  LD I, buffer
+  LD V3, [I]
+  LD V4, V0
+  LD V5, V1
+  LD V6, V2
+  LD V7, V3
+  LD V9, V4
+  CALL print
+  LD V9, V5
+  CALL print
+  LD V9, V6
+  CALL print
+  LD V9, V7
+  CALL print
+  CALL newline
+
+  LD I, buffer
+  LD V3, 2
+  ADD I, V3
+  LD V0, [I]
+  LD V9, V0
+  CALL print
+
+end:
+  JP end
+
+; Prints V9 in decimal at VA, VB and moves VA along 16, uses V0-V2
+print:
+  LD I, scratch
+  LD B, V9
+  LD V2, [I]
+  LD F, V0
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V1
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V2
+  DRW VA, VB, 5
+  ADD VA, 6
+  RET
+
+; Starts a new row of numbers
+newline:
+  LD VA, 0
+  ADD VB, 6
+  RET
+
+scratch:
+  DB 0, 0, 0
+
+buffer:
+  DB 0, 0, 0, 0
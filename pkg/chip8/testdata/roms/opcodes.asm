@@ -0,0 +1,154 @@
+; Control flow and register instructions, each test draws a tick if it passed or a cross, 8 to a row
+;  SE VX,NN taken      SE VX,NN not taken  SNE VX,NN taken     SNE VX,NN not taken
+;  SE VX,VY taken      SE VX,VY not taken  SNE VX,VY taken     SNE VX,VY not taken
+;  CALL and RET        ADD VX,NN wraps     LD VX,VY            OR
+;  AND                 XOR                 JP V0,NNN           LD DT and LD VX,DT
+  LD VA, 0
+  LD VB, 0
+
+  LD V7, 1
+  LD V3, 5
+  SE V3, 5
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  SE V3, 6
+  JP t2
+  LD V7, 0
+t2:
+  CALL mark
+
+  LD V7, 1
+  SNE V3, 6
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  SNE V3, 5
+  JP t4
+  LD V7, 0
+t4:
+  CALL mark
+
+  LD V7, 1
+  LD V4, 5
+  SE V3, V4
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V4, 6
+  SE V3, V4
+  JP t6
+  LD V7, 0
+t6:
+  CALL mark
+
+  LD V7, 1
+  SNE V3, V4
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V4, 5
+  SNE V3, V4
+  JP t8
+  LD V7, 0
+t8:
+  CALL mark
+
+  LD V7, 1
+  LD V8, 0
+  CALL set_v8
+  SE V8, 7
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD VF, 5
+  LD V3, 0xFF
+  ADD V3, 2
+  SE V3, 1
+  LD V7, 0
+  SE VF, 5      ; ADD VX,NN leaves VF alone
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V4, 0x3C
+  LD V3, V4
+  SE V3, 0x3C
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V3, 0x0F
+  LD V4, 0xF0
+  OR V3, V4
+  SE V3, 0xFF
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V3, 0x3C
+  LD V4, 0x0F
+  AND V3, V4
+  SE V3, 0x0C
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V3, 0x3C
+  LD V4, 0xFF
+  XOR V3, V4
+  SE V3, 0xC3
+  LD V7, 0
+  CALL mark
+
+  LD V7, 1
+  LD V0, 2
+  LD V2, 2      ; Same offset for the jump quirk, the table is in 0x2XX
+  JP V0, table
+table:
+  JP t15_fail
+  JP t15
+t15_fail:
+  LD V7, 0
+t15:
+  CALL mark
+
+  LD V7, 1
+  LD V3, 0x20
+  LD DT, V3
+  LD V4, DT
+  SNE V4, 0
+  LD V7, 0
+  CALL mark
+
+end:
+  JP end
+
+set_v8:
+  LD V8, 7
+  RET
+
+; Draws a tick if V7 is 1 or a cross
+mark:
+  LD I, cross
+  SE V7, 1
+  JP mark_draw
+  LD I, tick
+mark_draw:
+  DRW VA, VB, 5
+  ADD VA, 8
+  SE VA, 64
+  RET
+  LD VA, 0
+  ADD VB, 7
+  RET
+
+tick:
+  DB 0x04, 0x04, 0x88, 0x50, 0x20
+cross:
+  DB 0x88, 0x50, 0x20, 0x50, 0x88
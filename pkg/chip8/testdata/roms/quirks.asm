@@ -0,0 +1,78 @@
+; Behaviour that differs between interpreters, run once per quirk preset
+;  0x10 SHR V4=3: 8 in place, 1 from VY    0x10 SHL V4=3: 32 in place, 6 from VY
+;  VF after OR: 5 kept, 0 reset            V0 after FX55 then FX65: 1 I unchanged, 4 I+X, 99 I+X+1
+;  JP V0,NNN: 0 V0 used, 1 VX used
+  LD VA, 0
+  LD VB, 0
+
+  LD V3, 0x10
+  LD V4, 3
+  SHR V3, V4
+  LD V9, V3
+  CALL print
+  LD V3, 0x10
+  LD V4, 3
+  SHL V3, V4
+  LD V9, V3
+  CALL print
+  CALL newline
+
+  LD VF, 5
+  LD V3, 1
+  LD V4, 2
+  OR V3, V4
+  LD V9, VF
+  CALL print
+
+  LD V0, 1
+  LD V1, 2
+  LD V2, 3
+  LD V3, 4
+  LD I, buffer
+  LD [I], V3
+  LD V0, [I]
+  LD V9, V0
+  CALL print
+  CALL newline
+
+  LD V9, 0
+  LD V0, 2
+  LD V2, 4
+  JP V0, table
+table:
+  JP jumped
+  JP jumped
+  LD V9, 1
+jumped:
+  CALL print
+
+end:
+  JP end
+
+; Prints V9 in decimal at VA, VB and moves VA along 16, uses V0-V2
+print:
+  LD I, scratch
+  LD B, V9
+  LD V2, [I]
+  LD F, V0
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V1
+  DRW VA, VB, 5
+  ADD VA, 5
+  LD F, V2
+  DRW VA, VB, 5
+  ADD VA, 6
+  RET
+
+; Starts a new row of numbers
+newline:
+  LD VA, 0
+  ADD VB, 6
+  RET
+
+scratch:
+  DB 0, 0, 0
+
+buffer:
+  DB 0, 0, 0, 0, 99
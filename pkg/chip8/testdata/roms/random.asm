@@ -0,0 +1,15 @@
+; Draws 24 dots at random positions, only repeatable with a seeded generator
+  LD I, dot
+  LD V5, 24
+loop:
+  RND V3, 0x3F
+  RND V4, 0x1F
+  DRW V3, V4, 1
+  ADD V5, 0xFF
+  SE V5, 0
+  JP loop
+end:
+  JP end
+
+dot:
+  DB 0xC0
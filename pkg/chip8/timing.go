@@ -0,0 +1,150 @@
+package chip8
+
+import "fmt"
+
+//How much work the system does in a 60hz frame
+type Timing int
+
+const (
+	TimingFlat Timing = iota //TickRate instructions a frame whatever they are
+	TimingVIP                //Instructions cost COSMAC VIP machine cycles
+)
+
+var timingNames = []string{"flat", "vip"}
+
+func (timing Timing) String() string {
+	if timing < 0 || int(timing) >= len(timingNames) {
+		return fmt.Sprintf("Timing(%d)", int(timing))
+	}
+	return timingNames[timing]
+}
+
+func ParseTiming(name string) (Timing, error) {
+	for i, timingName := range timingNames {
+		if timingName == name {
+			return Timing(i), nil
+		}
+	}
+	return TimingFlat, fmt.Errorf("timing error: no timing named %q", name)
+}
+
+/*
+COSMAC VIP TIMING
+The 1802 runs at 1.7609 MHz with 8 clocks to a machine cycle, 3668 machine cycles every 60hz frame.
+The CDP1861 takes one cycle of DMA per byte it displays, 8 bytes a line for the 128 lines of the display,
+and the interrupt routine that counts down the timers runs every frame. The interpreter gets what is left.
+Instruction costs follow the published analysis of the VIP interpreter listing, variable costs such as
+the shifting in DXYN are modelled from the loops in the listing rather than measured
+*/
+const (
+	vipCyclesPerFrame     = 3668
+	vipDMACycles          = 128 * 8
+	vipInterruptCycles    = 46
+	vipInterpreterCycles  = vipCyclesPerFrame - vipDMACycles - vipInterruptCycles
+	vipFetchCycles        = 40 //Fetch and dispatch loop run before every instruction
+	vipSkipCycles         = 4  //Extra cost of a skip that is taken
+	vipClearCycles        = 3078
+	vipDrawCycles         = 26 //Setting up DXYN
+	vipDrawRowCycles      = 46 //Each sprite row
+	vipDrawShiftCycles    = 8  //Each bit a sprite row is shifted by to reach its X position
+	vipStoreCycles        = 14 //FX55 and FX65 set up, and again for each register
+	vipBCDCycles          = 84 //FX33 set up
+	vipBCDSubtractCycles  = 16 //FX33 counts each digit down one subtraction at a time
+	vipKeyWaitCycles      = 18 //Every poll of the keypad while FX0A waits
+	vipDefaultInstruction = 10
+)
+
+//Machine cycles for an instruction that has just run
+//skipped is whether a skip instruction skipped, vX the value of VX before it ran
+func vipCost(opcode Instruction, skipped bool, vX byte) int {
+	cost := vipFetchCycles
+	if skipped {
+		cost += vipSkipCycles
+	}
+
+	x := byte(opcode>>8) & 0xF
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			return cost + vipClearCycles
+		case 0x00EE:
+			return cost + 10
+		}
+		return cost + 26 //Machine code subroutine
+	case 0x1000, 0xA000:
+		return cost + 12
+	case 0x2000:
+		return cost + 26
+	case 0x3000, 0x4000:
+		return cost + 10
+	case 0x5000, 0x9000, 0xE000:
+		return cost + 14
+	case 0x6000:
+		return cost + 6
+	case 0x8000:
+		return cost + 44
+	case 0xB000:
+		return cost + 22
+	case 0xC000:
+		return cost + 36
+	case 0xD000:
+		rows := int(opcode & 0xF)
+		return cost + vipDrawCycles + rows*(vipDrawRowCycles+vipDrawShiftCycles*int(vX%8))
+	case 0xF000:
+		switch byte(opcode) {
+		case 0x0A:
+			return vipKeyWaitCycles
+		case 0x1E, 0x29, 0x30:
+			return cost + 16
+		case 0x33:
+			digits := int(vX/100 + vX/10%10 + vX%10)
+			return cost + vipBCDCycles + digits*vipBCDSubtractCycles
+		case 0x55, 0x65:
+			return cost + vipStoreCycles*(int(x)+2)
+		}
+	}
+	return cost + vipDefaultInstruction
+}
+
+//The interpreter's share of the frame less what the last one overran by
+func (system *Chip8) startVIPFrame() {
+	system.frameBudget = vipInterpreterCycles - system.cycleDebt
+	system.cycleDebt = 0
+	system.isFrameDone = system.frameBudget <= 0
+}
+
+//Takes an instruction that has just run out of the frame's machine cycles, vX is from before it ran
+//DXYN on the VIP waits for the interrupt before drawing, so it ends the frame and its cost comes out of the next one
+func (system *Chip8) chargeVIPCycles(vX byte) {
+	cpu := &system.cpu
+	if cpu.isWaitingForInput {
+		system.frameBudget -= vipKeyWaitCycles
+	} else {
+		skipped := cpu.programCounter == cpu.instructionAddress+4 && isSkip(cpu.opcode)
+		cost := vipCost(cpu.opcode, skipped, vX)
+		if cpu.opcode&0xF000 == 0xD000 {
+			system.cycleDebt = cost
+			system.isFrameDone = true
+			return
+		}
+		system.frameBudget -= cost
+	}
+	system.isFrameDone = system.frameBudget <= 0
+}
+
+//Overrunning the frame delays the next one
+func (system *Chip8) finishVIPFrame() {
+	if system.frameBudget < 0 {
+		system.cycleDebt -= system.frameBudget
+	}
+}
+
+//VX of the instruction about to run, read first as DXYN with VF as X overwrites it with the collision flag
+func (system *Chip8) nextVX() byte {
+	address := system.cpu.programCounter
+	if int(address) >= RamSize {
+		return 0 //The fetch fails
+	}
+	return system.cpu.Registers[system.ram[address]&0xF]
+}
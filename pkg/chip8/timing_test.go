@@ -0,0 +1,122 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestVIPCost(t *testing.T) {
+	tests := []struct {
+		opcode   Instruction
+		skipped  bool
+		vX       byte
+		expected int
+	}{
+		{0x6012, false, 0, vipFetchCycles + 6},
+		{0x3012, false, 0, vipFetchCycles + 10},
+		{0x3012, true, 0, vipFetchCycles + 10 + vipSkipCycles},
+		{0x00E0, false, 0, vipFetchCycles + vipClearCycles},
+		{0xD015, false, 8, vipFetchCycles + vipDrawCycles + 5*vipDrawRowCycles},                        //Byte aligned
+		{0xD015, false, 3, vipFetchCycles + vipDrawCycles + 5*(vipDrawRowCycles+3*vipDrawShiftCycles)}, //Shifted 3 bits
+		{0xF355, false, 0, vipFetchCycles + 5*vipStoreCycles},
+		{0xF033, false, 255, vipFetchCycles + vipBCDCycles + 12*vipBCDSubtractCycles},
+	}
+
+	for _, test := range tests {
+		if cost := vipCost(test.opcode, test.skipped, test.vX); cost != test.expected {
+			t.Errorf("FAIL %.4X skipped=%v vX=%v cost=%v (expected %v)", uint16(test.opcode), test.skipped, test.vX, cost, test.expected)
+		}
+	}
+}
+
+func TestVIPTiming(t *testing.T) {
+	//ADD V0, 1 and JP 0x200 looping, 102 machine cycles a loop
+	system := createNewSystem([]byte{0x70, 0x01, 0x12, 0x00})
+	system.Configure(Config{Timing: TimingVIP})
+
+	const frames = 60
+	for i := 0; i < frames; i++ {
+		if err := system.stepFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loopCycles := 2*vipFetchCycles + 10 + 12
+	expected := uint64(frames * vipInterpreterCycles * 2 / loopCycles)
+	if system.cycles < expected-1 || system.cycles > expected+1 {
+		t.Errorf("FAIL %v instructions in %v frames (expected %v)", system.cycles, frames, expected)
+	}
+}
+
+func TestVIPDrawWaitsForInterrupt(t *testing.T) {
+	//CLS takes more than a frame, then DRW ends the frame it runs in
+	system := createNewSystem([]byte{0x00, 0xE0, 0xD0, 0x05, 0x71, 0x01, 0x12, 0x04})
+	system.Configure(Config{Timing: TimingVIP})
+
+	clearCost := vipFetchCycles + vipClearCycles
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.cycles != 1 || system.cycleDebt != clearCost-vipInterpreterCycles {
+		t.Fatalf("FAIL cycles=%v debt=%v (expected 1 and %v)", system.cycles, system.cycleDebt, clearCost-vipInterpreterCycles)
+	}
+
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.cycles != 2 || system.cpu.programCounter != 0x204 {
+		t.Errorf("FAIL cycles=%v pc=0x%.3X (expected the frame to end after DRW)", system.cycles, system.cpu.programCounter)
+	}
+	if system.cycleDebt != vipCost(0xD005, false, 0) {
+		t.Errorf("FAIL debt=%v (expected the cost of DRW)", system.cycleDebt)
+	}
+}
+
+func TestVIPCostUsesVXBeforeRunning(t *testing.T) {
+	//DRW VF, V1, 5 at x=3 leaves 0 in VF as nothing collides
+	system := createNewSystem([]byte{0xDF, 0x15, 0x12, 0x02})
+	system.Configure(Config{Timing: TimingVIP})
+	system.cpu.Registers[0xF] = 3
+
+	if err := system.stepFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if system.cpu.Registers[0xF] != 0 || system.cycleDebt != vipCost(0xDF15, false, 3) {
+		t.Errorf("FAIL vF=%v debt=%v (expected 0 and the cost of drawing shifted 3 bits)", system.cpu.Registers[0xF], system.cycleDebt)
+	}
+}
+
+func TestStepCountsFramesLikeRun(t *testing.T) {
+	//CLS overruns a VIP frame, then DRW and ADD V1, 1 loop
+	program := []byte{0x00, 0xE0, 0xD0, 0x05, 0x71, 0x01, 0x12, 0x02}
+	for _, timing := range []Timing{TimingFlat, TimingVIP} {
+		running, stepping := createNewSystem(program), createNewSystem(program)
+		running.Configure(Config{Timing: timing, TickRate: 7})
+		stepping.Configure(Config{Timing: timing, TickRate: 7})
+
+		for frame := 0; frame < 20; frame++ {
+			cycles := running.cycles
+			if err := running.stepFrame(); err != nil {
+				t.Fatal(err)
+			}
+			for i := cycles; i < running.cycles; i++ {
+				if err := stepping.Step(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if running.cycles > cycles && (stepping.frame != running.frame || stepping.cycleDebt != running.cycleDebt) {
+				t.Fatalf("FAIL %v frame %v stepped to frame %v with debt %v (expected frame %v with debt %v)", timing, frame, stepping.frame, stepping.cycleDebt, running.frame, running.cycleDebt)
+			}
+		}
+	}
+}
+
+func TestParseTiming(t *testing.T) {
+	for _, timing := range []Timing{TimingFlat, TimingVIP} {
+		if parsed, err := ParseTiming(timing.String()); err != nil || parsed != timing {
+			t.Errorf("FAIL %v parsed as %v, %v", timing, parsed, err)
+		}
+	}
+	if _, err := ParseTiming("fast"); err == nil {
+		t.Errorf("FAIL unknown timing was parsed")
+	}
+}
@@ -0,0 +1,306 @@
+package chip8
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type TraceFormat int
+
+const (
+	TraceText TraceFormat = iota
+	TraceBinary
+)
+
+const (
+	traceMagic      = "C8TR" //Header written at the start of binary traces
+	traceRecordSize = 2 + 2 + registerCount + 2 + 3
+)
+
+//One executed instruction and the cpu state before it ran
+type TraceRecord struct {
+	PC        Address
+	Opcode    Instruction
+	Registers [registerCount]byte
+	I         Address
+	SP        byte
+	DT        byte
+	ST        byte
+}
+
+//Tracer writes a TraceRecord for every executed instruction
+//Add it to a system with AddObserver
+type Tracer struct {
+	writer  *bufio.Writer
+	format  TraceFormat
+	ranges  [][2]Address
+	symbols Symbols
+	err     error
+
+	wroteHeader bool
+}
+
+func NewTracer(writer io.Writer, format TraceFormat) *Tracer {
+	return &Tracer{writer: bufio.NewWriter(writer), format: format}
+}
+
+//Only trace instructions with start <= PC <= end, can be called multiple times to trace several ranges
+func (tracer *Tracer) AddRange(start, end Address) {
+	tracer.ranges = append(tracer.ranges, [2]Address{start, end})
+}
+
+//Text traces name the instruction's location and operands with symbols, binary traces are unchanged
+func (tracer *Tracer) SetSymbols(symbols Symbols) {
+	tracer.symbols = symbols
+}
+
+func (tracer *Tracer) ObserveInstruction(state *State, opcode Instruction) {
+	if tracer.err != nil || state.WaitingForKey || !tracer.inRange(state.PC) {
+		return
+	}
+
+	record := TraceRecord{
+		PC:        state.PC,
+		Opcode:    opcode,
+		Registers: state.Registers,
+		I:         state.I,
+		SP:        state.SP,
+		DT:        state.Delay,
+		ST:        state.Sound,
+	}
+	if tracer.format == TraceBinary {
+		tracer.err = tracer.writeBinary(&record)
+	} else {
+		_, tracer.err = fmt.Fprintln(tracer.writer, record.Format(tracer.symbols))
+	}
+}
+
+//Flush buffered records to the underlying writer, returns the first error encountered while tracing
+func (tracer *Tracer) Flush() error {
+	if tracer.err != nil {
+		return tracer.err
+	}
+	return tracer.writer.Flush()
+}
+
+func (tracer *Tracer) inRange(pc Address) bool {
+	if len(tracer.ranges) == 0 {
+		return true
+	}
+	for _, addressRange := range tracer.ranges {
+		if pc >= addressRange[0] && pc <= addressRange[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (tracer *Tracer) writeBinary(record *TraceRecord) error {
+	if !tracer.wroteHeader {
+		if _, err := tracer.writer.WriteString(traceMagic); err != nil {
+			return err
+		}
+		tracer.wroteHeader = true
+	}
+
+	_, err := tracer.writer.Write(record.marshal())
+	return err
+}
+
+//Text form of a record, one line without the newline
+func (record *TraceRecord) String() string {
+	return record.Format(nil)
+}
+
+//Text form with the location and operands named by symbols in the comment, which parsing skips
+func (record *TraceRecord) Format(symbols Symbols) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "PC=%.4X OP=%.4X", record.PC, uint16(record.Opcode))
+	for i, value := range record.Registers {
+		fmt.Fprintf(&builder, " V%X=%.2X", i, value)
+	}
+	fmt.Fprintf(&builder, " I=%.4X SP=%.2X DT=%.2X ST=%.2X ; ", record.I, record.SP, record.DT, record.ST)
+	if symbols != nil {
+		fmt.Fprintf(&builder, "%s: ", Symbolize(symbols, record.PC))
+	}
+	builder.WriteString(DisassembleSymbols(record.Opcode, symbols))
+	return builder.String()
+}
+
+//Names of the fields that differ between two records
+func (record *TraceRecord) Diff(other *TraceRecord) []string {
+	var fields []string
+	if record.PC != other.PC {
+		fields = append(fields, "PC")
+	}
+	if record.Opcode != other.Opcode {
+		fields = append(fields, "OP")
+	}
+	for i := range record.Registers {
+		if record.Registers[i] != other.Registers[i] {
+			fields = append(fields, fmt.Sprintf("V%X", i))
+		}
+	}
+	if record.I != other.I {
+		fields = append(fields, "I")
+	}
+	if record.SP != other.SP {
+		fields = append(fields, "SP")
+	}
+	if record.DT != other.DT {
+		fields = append(fields, "DT")
+	}
+	if record.ST != other.ST {
+		fields = append(fields, "ST")
+	}
+	return fields
+}
+
+func (record *TraceRecord) marshal() []byte {
+	data := make([]byte, traceRecordSize)
+	binary.BigEndian.PutUint16(data[0:], uint16(record.PC))
+	binary.BigEndian.PutUint16(data[2:], uint16(record.Opcode))
+	copy(data[4:], record.Registers[:])
+	binary.BigEndian.PutUint16(data[4+registerCount:], uint16(record.I))
+	data[6+registerCount] = record.SP
+	data[7+registerCount] = record.DT
+	data[8+registerCount] = record.ST
+	return data
+}
+
+func (record *TraceRecord) unmarshal(data []byte) {
+	record.PC = Address(binary.BigEndian.Uint16(data[0:]))
+	record.Opcode = Instruction(binary.BigEndian.Uint16(data[2:]))
+	copy(record.Registers[:], data[4:])
+	record.I = Address(binary.BigEndian.Uint16(data[4+registerCount:]))
+	record.SP = data[6+registerCount]
+	record.DT = data[7+registerCount]
+	record.ST = data[8+registerCount]
+}
+
+//Reads traces in either format, binary traces are detected by their header
+type TraceReader struct {
+	reader *bufio.Reader
+	format TraceFormat
+	line   int
+}
+
+func NewTraceReader(reader io.Reader) (*TraceReader, error) {
+	result := &TraceReader{reader: bufio.NewReader(reader)}
+
+	header, err := result.reader.Peek(len(traceMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(header, []byte(traceMagic)) {
+		result.format = TraceBinary
+		result.reader.Discard(len(traceMagic))
+	}
+	return result, nil
+}
+
+//Returns io.EOF once every record has been read
+func (reader *TraceReader) Next() (TraceRecord, error) {
+	record := TraceRecord{}
+	reader.line++
+
+	if reader.format == TraceBinary {
+		data := make([]byte, traceRecordSize)
+		_, err := io.ReadFull(reader.reader, data)
+		if err == io.ErrUnexpectedEOF {
+			return record, fmt.Errorf("trace error: record %v truncated", reader.line)
+		} else if err != nil {
+			return record, err
+		}
+		record.unmarshal(data)
+		return record, nil
+	}
+
+	line, err := reader.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return record, io.EOF
+	} else if err != nil && err != io.EOF {
+		return record, err
+	}
+	return record, record.parse(line, reader.line)
+}
+
+func (record *TraceRecord) parse(line string, lineNumber int) error {
+	if end := strings.Index(line, ";"); end >= 0 {
+		line = line[:end] //The mnemonic is derived from the opcode
+	}
+
+	for _, field := range strings.Fields(line) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("trace error: line %v: malformed field %q", lineNumber, field)
+		}
+		value, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			return fmt.Errorf("trace error: line %v: %v", lineNumber, err)
+		}
+
+		switch name := parts[0]; {
+		case name == "PC":
+			record.PC = Address(value)
+		case name == "OP":
+			record.Opcode = Instruction(value)
+		case name == "I":
+			record.I = Address(value)
+		case name == "SP":
+			record.SP = byte(value)
+		case name == "DT":
+			record.DT = byte(value)
+		case name == "ST":
+			record.ST = byte(value)
+		case len(name) == 2 && name[0] == 'V':
+			index, err := strconv.ParseUint(name[1:], 16, 8)
+			if err != nil {
+				return fmt.Errorf("trace error: line %v: unknown register %q", lineNumber, name)
+			}
+			record.Registers[index] = byte(value)
+		default:
+			return fmt.Errorf("trace error: line %v: unknown field %q", lineNumber, name)
+		}
+	}
+	return nil
+}
+
+//First point where two traces differ
+type TraceDivergence struct {
+	Index  int          //Zero based record number
+	A, B   *TraceRecord //nil when that trace ended first
+	Fields []string
+}
+
+//Compares two traces record by record, returns nil if they are identical
+func DiffTraces(a, b *TraceReader) (*TraceDivergence, error) {
+	for index := 0; ; index++ {
+		recordA, errA := a.Next()
+		if errA != nil && errA != io.EOF {
+			return nil, errA
+		}
+		recordB, errB := b.Next()
+		if errB != nil && errB != io.EOF {
+			return nil, errB
+		}
+
+		switch {
+		case errA == io.EOF && errB == io.EOF:
+			return nil, nil
+		case errA == io.EOF:
+			return &TraceDivergence{Index: index, B: &recordB}, nil
+		case errB == io.EOF:
+			return &TraceDivergence{Index: index, A: &recordA}, nil
+		}
+
+		if fields := recordA.Diff(&recordB); len(fields) > 0 {
+			return &TraceDivergence{Index: index, A: &recordA, B: &recordB, Fields: fields}, nil
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTraceRoundTrip(t *testing.T) {
+	for _, format := range []TraceFormat{TraceText, TraceBinary} {
+		buffer := traceProgram(t, []byte{0x6A, 0x02, 0x7A, 0x01, 0xA3, 0x00}, format, nil)
+
+		reader, err := NewTraceReader(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedPCs := []Address{0x200, 0x202, 0x204}
+		for i, expected := range expectedPCs {
+			record, err := reader.Next()
+			if err != nil {
+				t.Fatalf("FAIL format %v record %v: %v", format, i, err)
+			}
+			if record.PC != expected {
+				t.Errorf("FAIL format %v pc=0x%.3X (expected 0x%.3X)", format, record.PC, expected)
+			}
+		}
+	}
+}
+
+func TestTraceRange(t *testing.T) {
+	buffer := traceProgram(t, []byte{0x6A, 0x02, 0x7A, 0x01, 0xA3, 0x00}, TraceText, [][2]Address{{0x202, 0x202}})
+
+	expected := "PC=0202 OP=7A01 V0=00 V1=01 V2=02 V3=03 V4=04 V5=05 V6=06 V7=07 V8=08 V9=09 VA=02 VB=0B VC=0C VD=0D VE=0E VF=0F I=0300 SP=00 DT=00 ST=00 ; ADD VA, 0x01\n"
+	if buffer.String() != expected {
+		t.Errorf("FAIL trace=\n%q\n(expected)\n%q", buffer.String(), expected)
+	}
+}
+
+func TestDiffTraces(t *testing.T) {
+	a := traceProgram(t, []byte{0x6A, 0x02, 0x7A, 0x01, 0xA3, 0x00}, TraceText, nil)
+	b := traceProgram(t, []byte{0x6A, 0x02, 0x7A, 0x02, 0xA3, 0x00}, TraceBinary, nil)
+
+	readerA, _ := NewTraceReader(&a)
+	readerB, _ := NewTraceReader(&b)
+	divergence, err := DiffTraces(readerA, readerB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if divergence == nil || divergence.Index != 1 || len(divergence.Fields) != 1 || divergence.Fields[0] != "OP" {
+		t.Errorf("FAIL divergence=%+v (expected OP at index 1)", divergence)
+	}
+}
+
+func traceProgram(t *testing.T, program []byte, format TraceFormat, ranges [][2]Address) bytes.Buffer {
+	var buffer bytes.Buffer
+	system := createNewSystem(program)
+	tracer := NewTracer(&buffer, format)
+	for _, addressRange := range ranges {
+		tracer.AddRange(addressRange[0], addressRange[1])
+	}
+	system.AddObserver(tracer)
+
+	for i := 0; i < len(program)/instructionSize; i++ {
+		if err := system.cpu.cycle(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer
+}
@@ -0,0 +1,395 @@
+//Debug Adapter Protocol server so editors can launch and debug roms
+//Breakpoints and stack frames use source lines from the rom's symbol file
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/symbols"
+)
+
+const (
+	threadID = 1 //The system is the only thread
+
+	registersReference = 1
+	stackReference     = 2
+
+	frameRate = 60
+)
+
+//Creates the system a launched rom runs on, so the caller picks the quirks and tick rate
+type SystemFactory func(program []byte) (*chip8.Chip8, error)
+
+type Server struct {
+	newSystem SystemFactory
+}
+
+func NewServer(newSystem SystemFactory) *Server {
+	return &Server{newSystem: newSystem}
+}
+
+//Accepts one editor at a time until the listener is closed
+func (server *Server) ServeListener(listener net.Listener) error {
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		server.Serve(connection, connection)
+		connection.Close()
+	}
+}
+
+//What a running system does until it stops by itself
+type runMode int
+
+const (
+	runContinue runMode = iota
+	runStepIn
+	runStepOver
+	runStepOut
+)
+
+type session struct {
+	server *Server
+	writer *messageWriter
+
+	system      *chip8.Chip8
+	symbols     *symbols.Table
+	breakpoints map[string][]chip8.Address //Addresses of the source breakpoints in each file
+	stopOnEntry bool
+
+	isRunning  bool
+	isResuming bool //The instruction the system stopped on runs even if it has a breakpoint
+	mode       runMode
+	startSP    byte         //Stack depth when a step started
+	startLine  symbols.Line //Source line a step started on, when there are symbols
+	lastError  error        //Error that stopped the system, it can't run again
+	isDone     bool
+}
+
+//Runs one debug session over a pair of streams, such as stdin and stdout
+//The reader is closed when the session ends if it is an io.Closer, so the goroutine reading requests stops too
+func (server *Server) Serve(reader io.Reader, writer io.Writer) error {
+	session := &session{server: server, writer: &messageWriter{writer: writer}, breakpoints: map[string][]chip8.Address{}}
+
+	requests, errs, done := make(chan request), make(chan error, 1), make(chan struct{})
+	defer close(done)
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	go func() {
+		bufferedReader := bufio.NewReader(reader)
+		for {
+			data, err := readMessage(bufferedReader)
+			if err != nil {
+				errs <- err
+				return
+			}
+			message := request{}
+			if err := json.Unmarshal(data, &message); err != nil {
+				errs <- fmt.Errorf("dap error: %v", err)
+				return
+			}
+			select {
+			case requests <- message:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second / frameRate)
+	defer ticker.Stop()
+	for !session.isDone {
+		if session.isRunning {
+			select {
+			case message := <-requests:
+				session.handle(message)
+			case err := <-errs:
+				return ignoreEOF(err)
+			case <-ticker.C:
+				session.runFrame()
+			}
+		} else {
+			select {
+			case message := <-requests:
+				session.handle(message)
+			case err := <-errs:
+				return ignoreEOF(err)
+			}
+		}
+	}
+	return nil
+}
+
+func ignoreEOF(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (session *session) respond(message request, body interface{}) {
+	session.writer.write(&response{RequestSeq: message.Seq, Success: true, Command: message.Command, Body: body})
+}
+
+func (session *session) fail(message request, err error) {
+	session.writer.write(&response{RequestSeq: message.Seq, Success: false, Command: message.Command, Message: err.Error()})
+}
+
+func (session *session) sendEvent(name string, body interface{}) {
+	session.writer.write(&event{Event: name, Body: body})
+}
+
+func (session *session) stop(reason string, description string) {
+	session.isRunning = false
+	session.sendEvent("stopped", stoppedBody{Reason: reason, Text: description, Description: description, ThreadID: threadID, AllThreadsStopped: true})
+}
+
+func (session *session) handle(message request) {
+	if session.system == nil && message.Command != "initialize" && message.Command != "launch" && message.Command != "disconnect" {
+		session.fail(message, fmt.Errorf("dap error: no rom has been launched"))
+		return
+	}
+
+	switch message.Command {
+	case "initialize":
+		session.respond(message, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+		})
+	case "launch":
+		arguments := launchArguments{}
+		if err := json.Unmarshal(message.Arguments, &arguments); err != nil {
+			session.fail(message, err)
+			return
+		}
+		if err := session.launch(arguments); err != nil {
+			session.fail(message, err)
+			return
+		}
+		session.respond(message, nil)
+		session.sendEvent("initialized", nil) //Breakpoints can be resolved now the symbols are loaded
+	case "setBreakpoints":
+		arguments := setBreakpointsArguments{}
+		if err := json.Unmarshal(message.Arguments, &arguments); err != nil {
+			session.fail(message, err)
+			return
+		}
+		session.respond(message, map[string]interface{}{"breakpoints": session.setBreakpoints(arguments)})
+	case "configurationDone":
+		session.respond(message, nil)
+		if session.stopOnEntry {
+			session.stop("entry", "")
+		} else {
+			session.resume(runContinue)
+			session.isResuming = false //Nothing stopped on the first instruction, so its breakpoint counts
+		}
+	case "threads":
+		session.respond(message, map[string]interface{}{"threads": []map[string]interface{}{{"id": threadID, "name": "chip8"}}})
+	case "stackTrace":
+		frames := session.stackFrames()
+		session.respond(message, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+	case "scopes":
+		session.respond(message, map[string]interface{}{"scopes": []scope{
+			{Name: "Registers", VariablesReference: registersReference},
+			{Name: "Stack", VariablesReference: stackReference},
+		}})
+	case "variables":
+		arguments := struct {
+			VariablesReference int `json:"variablesReference"`
+		}{}
+		json.Unmarshal(message.Arguments, &arguments)
+		session.respond(message, map[string]interface{}{"variables": session.variables(arguments.VariablesReference)})
+	case "evaluate":
+		arguments := struct {
+			Expression string `json:"expression"`
+		}{}
+		json.Unmarshal(message.Arguments, &arguments)
+		result, err := evaluate(session.system, session.symbols, arguments.Expression)
+		if err != nil {
+			session.fail(message, err)
+			return
+		}
+		session.respond(message, map[string]interface{}{"result": result, "variablesReference": 0})
+	case "continue":
+		session.respond(message, map[string]interface{}{"allThreadsContinued": true})
+		session.resume(runContinue)
+	case "next":
+		session.respond(message, nil)
+		session.resume(runStepOver)
+	case "stepIn":
+		session.respond(message, nil)
+		session.resume(runStepIn)
+	case "stepOut":
+		session.respond(message, nil)
+		session.resume(runStepOut)
+	case "pause":
+		session.respond(message, nil)
+		session.stop("pause", "")
+	case "disconnect", "terminate":
+		session.respond(message, nil)
+		session.sendEvent("terminated", nil)
+		session.isDone = true
+	default:
+		session.fail(message, fmt.Errorf("dap error: %v isn't supported", message.Command))
+	}
+}
+
+func (session *session) launch(arguments launchArguments) error {
+	program, err := ioutil.ReadFile(arguments.Program)
+	if err != nil {
+		return err
+	}
+	session.system, err = session.server.newSystem(program)
+	if err != nil {
+		return err
+	}
+
+	if arguments.Symbols != "" {
+		session.symbols, err = symbols.Load(arguments.Symbols)
+	} else {
+		session.symbols, err = symbols.LoadFor(arguments.Program)
+	}
+	if err != nil {
+		return err
+	}
+	session.stopOnEntry = arguments.StopOnEntry
+	return nil
+}
+
+//Replaces the breakpoints of one source file
+func (session *session) setBreakpoints(arguments setBreakpointsArguments) []breakpoint {
+	addresses := []chip8.Address{}
+	result := []breakpoint{}
+	for _, requested := range arguments.Breakpoints {
+		line, ok := session.symbols.AddressOf(arguments.Source.Path, requested.Line)
+		if !ok {
+			result = append(result, breakpoint{Verified: false, Line: requested.Line, Message: "no code at this line"})
+			continue
+		}
+		addresses = append(addresses, line.Address)
+		result = append(result, breakpoint{Verified: true, Line: line.Line})
+	}
+	session.breakpoints[arguments.Source.Path] = addresses
+	return result
+}
+
+func (session *session) isBreakpoint(address chip8.Address) bool {
+	for _, addresses := range session.breakpoints {
+		for _, breakpoint := range addresses {
+			if breakpoint == address {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (session *session) resume(mode runMode) {
+	if session.lastError != nil {
+		session.stop("exception", session.lastError.Error())
+		return
+	}
+	state := session.system.State()
+	session.mode, session.startSP = mode, state.SP
+	session.startLine, _ = session.symbols.LineAt(state.PC)
+	session.isRunning, session.isResuming = true, true
+}
+
+//Runs up to a frame of instructions, stopping early for a breakpoint, a finished step or an error
+func (session *session) runFrame() {
+	system := session.system
+	frame := system.Frame()
+	for session.isRunning && system.Frame() == frame {
+		if !session.isResuming && session.isBreakpoint(system.State().PC) {
+			session.stop("breakpoint", "")
+			return
+		}
+		session.isResuming = false
+
+		if err := system.Step(); err != nil {
+			session.lastError = err
+			session.stop("exception", err.Error())
+			return
+		}
+
+		state := system.State()
+		if session.isBreakpoint(state.PC) {
+			session.stop("breakpoint", "")
+		} else if session.isStepDone(state) {
+			session.stop("step", "")
+		}
+	}
+}
+
+//Steps end on a new source line when there are symbols and after one instruction when there aren't
+func (session *session) isStepDone(state chip8.State) bool {
+	if session.mode == runContinue {
+		return false
+	}
+	if session.mode == runStepOut {
+		return state.SP < session.startSP
+	}
+	if session.mode == runStepOver && state.SP > session.startSP {
+		return false //Inside a call
+	}
+
+	if session.symbols == nil {
+		return true
+	}
+	line, ok := session.symbols.LineAt(state.PC)
+	return ok && (line.Line != session.startLine.Line || line.File != session.startLine.File || state.SP != session.startSP)
+}
+
+//The current instruction then the call of each subroutine on the stack, the return address is just after its call
+func (session *session) stackFrames() []stackFrame {
+	state := session.system.State()
+	addresses := []chip8.Address{state.PC}
+	for i := int(state.SP) - 1; i >= 0; i-- {
+		addresses = append(addresses, state.Stack[i]-2)
+	}
+
+	frames := []stackFrame{}
+	for i, address := range addresses {
+		frame := stackFrame{ID: i, Name: chip8.Symbolize(session.symbols, address), InstructionPointerReference: fmt.Sprintf("0x%X", uint16(address))}
+		if line, ok := session.symbols.LineAt(address); ok {
+			frame.Source = &source{Name: filepath.Base(line.File), Path: line.File}
+			frame.Line, frame.Column = line.Line, 1
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func (session *session) variables(reference int) []variable {
+	state := session.system.State()
+	result := []variable{}
+	switch reference {
+	case registersReference:
+		for i, value := range state.Registers {
+			result = append(result, variable{Name: fmt.Sprintf("V%X", i), Value: fmt.Sprintf("0x%.2X", value)})
+		}
+		result = append(result,
+			variable{Name: "I", Value: fmt.Sprintf("0x%.3X", uint16(state.I))},
+			variable{Name: "PC", Value: fmt.Sprintf("0x%.3X", uint16(state.PC))},
+			variable{Name: "SP", Value: fmt.Sprint(state.SP)},
+			variable{Name: "DT", Value: fmt.Sprint(state.Delay)},
+			variable{Name: "ST", Value: fmt.Sprint(state.Sound)},
+		)
+	case stackReference:
+		for i := 0; i < int(state.SP); i++ {
+			result = append(result, variable{Name: fmt.Sprint(i), Value: fmt.Sprintf("0x%.3X", uint16(state.Stack[i]))})
+		}
+	}
+	return result
+}
@@ -0,0 +1,306 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Editor end of a session
+type client struct {
+	t      *testing.T
+	writer *messageWriter
+	read   chan map[string]interface{}
+	seq    int
+}
+
+func startSession(t *testing.T) *client {
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+	server := NewServer(func(program []byte) (*chip8.Chip8, error) {
+		system, _, _, _ := chip8.New()
+		return system, system.LoadProgram(program)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(requestReader, responseWriter)
+		responseWriter.Close()
+	}()
+	t.Cleanup(func() {
+		requestWriter.Close()
+		if err := <-done; err != nil {
+			t.Errorf("FAIL Serve err=%v (expected nil)", err)
+		}
+	})
+
+	client := &client{t: t, writer: &messageWriter{writer: requestWriter}, read: make(chan map[string]interface{}, 16)}
+	go func() {
+		reader := bufio.NewReader(responseReader)
+		for {
+			data, err := readMessage(reader)
+			if err != nil {
+				close(client.read)
+				return
+			}
+			message := map[string]interface{}{}
+			json.Unmarshal(data, &message)
+			client.read <- message
+		}
+	}()
+	return client
+}
+
+func (client *client) receive() map[string]interface{} {
+	client.t.Helper()
+	select {
+	case message, ok := <-client.read:
+		if !ok {
+			client.t.Fatal("FAIL session closed (expected a message)")
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		client.t.Fatal("FAIL timed out (expected a message)")
+	}
+	return nil
+}
+
+//Sends a request and returns the body of its successful response
+func (client *client) request(command string, arguments interface{}) map[string]interface{} {
+	client.t.Helper()
+	client.seq++
+	data, _ := json.Marshal(arguments)
+	client.writer.write(&request{Seq: client.seq, Type: "request", Command: command, Arguments: data})
+
+	message := client.receive()
+	if message["type"] != "response" || message["command"] != command || message["request_seq"] != float64(client.seq) {
+		client.t.Fatalf("FAIL %v message=%v (expected its response)", command, message)
+	}
+	if message["success"] != true {
+		client.t.Fatalf("FAIL %v message=%v (expected success)", command, message["message"])
+	}
+	body, _ := message["body"].(map[string]interface{})
+	return body
+}
+
+func (client *client) expectEvent(name string) map[string]interface{} {
+	client.t.Helper()
+	message := client.receive()
+	if message["type"] != "event" || message["event"] != name {
+		client.t.Fatalf("FAIL message=%v (expected a %v event)", message, name)
+	}
+	body, _ := message["body"].(map[string]interface{})
+	return body
+}
+
+func (client *client) expectStopped(reason string) {
+	client.t.Helper()
+	if body := client.expectEvent("stopped"); body["reason"] != reason {
+		client.t.Errorf("FAIL stopped reason=%v (expected %v)", body["reason"], reason)
+	}
+}
+
+//Lines of the frames in a stack trace, outermost last
+func (client *client) stackLines() []float64 {
+	client.t.Helper()
+	lines := []float64{}
+	for _, frame := range client.stackFrames() {
+		lines = append(lines, frame["line"].(float64))
+	}
+	return lines
+}
+
+func (client *client) stackFrames() []map[string]interface{} {
+	client.t.Helper()
+	frames := []map[string]interface{}{}
+	for _, frame := range client.request("stackTrace", map[string]interface{}{"threadId": threadID})["stackFrames"].([]interface{}) {
+		frames = append(frames, frame.(map[string]interface{}))
+	}
+	return frames
+}
+
+func TestSession(t *testing.T) {
+	directory := t.TempDir()
+	program := []byte{
+		0x61, 0x00, //1	LD V1, 0
+		0x22, 0x08, //2	loop: CALL count
+		0x72, 0x01, //3	ADD V2, 1
+		0x12, 0x02, //4	JP loop
+		0x71, 0x01, //6	count: ADD V1, 1
+		0x00, 0xEE, //7	RET
+	}
+	symbols := "# game.8o\nlabel 202 loop\nlabel 208 count\nline 200 game.8o 1\nline 202 game.8o 2\nline 204 game.8o 3\nline 206 game.8o 4\nline 208 game.8o 6\nline 20A game.8o 7\n"
+	if err := ioutil.WriteFile(filepath.Join(directory, "game.ch8"), program, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(directory, "game.sym"), []byte(symbols), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sourcePath := filepath.Join(directory, "game.8o")
+
+	client := startSession(t)
+	if body := client.request("initialize", map[string]interface{}{"adapterID": "gchip8"}); body["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("FAIL initialize body=%v (expected configurationDone support)", body)
+	}
+	client.request("launch", launchArguments{Program: filepath.Join(directory, "game.ch8"), StopOnEntry: true})
+	client.expectEvent("initialized")
+
+	breakpoints := client.request("setBreakpoints", map[string]interface{}{
+		"source":      source{Path: sourcePath},
+		"breakpoints": []map[string]int{{"line": 5}, {"line": 9}},
+	})["breakpoints"].([]interface{})
+	if first := breakpoints[0].(map[string]interface{}); first["verified"] != true || first["line"] != 6.0 {
+		t.Errorf("FAIL breakpoint on line 5=%v (expected verified on line 6)", first)
+	}
+	if second := breakpoints[1].(map[string]interface{}); second["verified"] != false {
+		t.Errorf("FAIL breakpoint on line 9=%v (expected unverified)", second)
+	}
+
+	client.request("configurationDone", nil)
+	client.expectStopped("entry")
+	if lines := client.stackLines(); len(lines) != 1 || lines[0] != 1 {
+		t.Errorf("FAIL stack on entry=%v (expected [1])", lines)
+	}
+
+	client.request("continue", map[string]interface{}{"threadId": threadID})
+	client.expectStopped("breakpoint")
+	if lines := client.stackLines(); len(lines) != 2 || lines[0] != 6 || lines[1] != 2 {
+		t.Errorf("FAIL stack at breakpoint=%v (expected [6 2])", lines)
+	}
+	if frames := client.stackFrames(); frames[0]["name"] != "count" || frames[1]["name"] != "loop" {
+		t.Errorf("FAIL frame names=%v, %v (expected count, loop)", frames[0]["name"], frames[1]["name"])
+	}
+
+	variables := client.request("variables", map[string]interface{}{"variablesReference": registersReference})["variables"].([]interface{})
+	if pc := variables[17].(map[string]interface{}); pc["name"] != "PC" || pc["value"] != "0x208" {
+		t.Errorf("FAIL register %v=%v (expected PC=0x208)", pc["name"], pc["value"])
+	}
+	stack := client.request("variables", map[string]interface{}{"variablesReference": stackReference})["variables"].([]interface{})
+	if len(stack) != 1 || stack[0].(map[string]interface{})["value"] != "0x204" {
+		t.Errorf("FAIL stack variables=%v (expected the return address 0x204)", stack)
+	}
+
+	client.request("next", map[string]interface{}{"threadId": threadID})
+	client.expectStopped("step")
+	if lines := client.stackLines(); len(lines) != 2 || lines[0] != 7 {
+		t.Errorf("FAIL stack after next=%v (expected [7 2])", lines)
+	}
+
+	client.request("stepOut", map[string]interface{}{"threadId": threadID})
+	client.expectStopped("step")
+	if lines := client.stackLines(); len(lines) != 1 || lines[0] != 3 {
+		t.Errorf("FAIL stack after stepOut=%v (expected [3])", lines)
+	}
+	if frames := client.stackFrames(); frames[0]["name"] != "loop+0x2" {
+		t.Errorf("FAIL frame name after stepOut=%v (expected loop+0x2)", frames[0]["name"])
+	}
+
+	for expression, expected := range map[string]string{
+		"v1":          "0x1 (1)",
+		"PC":          "0x204 (516)",
+		"[0x200, 4]":  "61 00 22 08",
+		"[pc+v1+1,2]": "12 02",
+		"count+2":     "0x20A (522)",
+		"[loop, 2]":   "22 08",
+	} {
+		if result := client.request("evaluate", map[string]interface{}{"expression": expression})["result"]; result != expected {
+			t.Errorf("FAIL evaluate %q=%v (expected %q)", expression, result, expected)
+		}
+	}
+
+	client.request("continue", map[string]interface{}{"threadId": threadID})
+	client.expectStopped("breakpoint")
+	client.request("disconnect", nil)
+	client.expectEvent("terminated")
+}
+
+//A breakpoint where the system starts or resumes stops it before the instruction runs
+func TestBreakpointAtEntry(t *testing.T) {
+	directory := t.TempDir()
+	program := []byte{
+		0x71, 0x01, //1	loop: ADD V1, 1
+		0x12, 0x00, //2	JP loop
+	}
+	symbols := "# game.8o\nline 200 game.8o 1\nline 202 game.8o 2\n"
+	if err := ioutil.WriteFile(filepath.Join(directory, "game.ch8"), program, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(directory, "game.sym"), []byte(symbols), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := startSession(t)
+	client.request("initialize", map[string]interface{}{"adapterID": "gchip8"})
+	client.request("launch", launchArguments{Program: filepath.Join(directory, "game.ch8")})
+	client.expectEvent("initialized")
+	client.request("setBreakpoints", map[string]interface{}{
+		"source":      source{Path: filepath.Join(directory, "game.8o")},
+		"breakpoints": []map[string]int{{"line": 1}},
+	})
+	client.request("configurationDone", nil)
+
+	for _, expected := range []string{"0x0 (0)", "0x1 (1)"} {
+		client.expectStopped("breakpoint")
+		if lines := client.stackLines(); len(lines) != 1 || lines[0] != 1 {
+			t.Errorf("FAIL stack at breakpoint=%v (expected [1])", lines)
+		}
+		if result := client.request("evaluate", map[string]interface{}{"expression": "v1"})["result"]; result != expected {
+			t.Errorf("FAIL v1=%v (expected %v)", result, expected)
+		}
+		client.request("continue", map[string]interface{}{"threadId": threadID})
+	}
+	client.expectStopped("breakpoint")
+	client.request("disconnect", nil)
+	client.expectEvent("terminated")
+}
+
+//Ending the session closes the request stream, so nothing is left reading it
+func TestSessionEndClosesReader(t *testing.T) {
+	requestReader, requestWriter := io.Pipe()
+	server := NewServer(nil)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(requestReader, ioutil.Discard)
+	}()
+
+	writer := &messageWriter{writer: requestWriter}
+	if err := writer.write(&request{Seq: 1, Type: "request", Command: "disconnect"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("FAIL Serve err=%v (expected nil)", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("FAIL timed out (expected Serve to return after disconnect)")
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		written <- writer.write(&request{Seq: 2, Type: "request", Command: "threads"})
+	}()
+	select {
+	case err := <-written:
+		if err != io.ErrClosedPipe {
+			t.Errorf("FAIL write after the session err=%v (expected %v)", err, io.ErrClosedPipe)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("FAIL write after the session blocked (expected %v)", io.ErrClosedPipe)
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	system, _, _, _ := chip8.New()
+	for _, expression := range []string{"vg", "[0xFFF, 2]", "[i, 0]", "nothing", "[0x1000]", "[0x10000]", "[0xFFFF+1]"} {
+		if result, err := evaluate(system, nil, expression); err == nil {
+			t.Errorf("FAIL evaluate %q=%v (expected an error)", expression, result)
+		}
+	}
+}
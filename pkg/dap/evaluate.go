@@ -0,0 +1,93 @@
+package dap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/symbols"
+)
+
+/*
+EXPRESSIONS
+	v0-vf, i, pc, sp, dt, st	a register
+	42, 0x2A					a number
+	main_loop					the address of a label
+	[address] or [address, n]	n bytes of memory, 1 by default
+Addresses are registers, numbers and labels joined with +, like [i+2]
+*/
+
+func evaluate(system *chip8.Chip8, table *symbols.Table, expression string) (string, error) {
+	expression = strings.TrimSpace(expression)
+	state := system.State()
+
+	if strings.HasPrefix(expression, "[") && strings.HasSuffix(expression, "]") {
+		parts := strings.SplitN(expression[1:len(expression)-1], ",", 2)
+		address, err := evaluateSum(state, table, parts[0])
+		if err != nil {
+			return "", err
+		}
+		if address < 0 || address >= chip8.RamSize { //Checked before it's cut down to an Address and wraps around
+			return "", fmt.Errorf("evaluate error: address 0x%X is past the end of memory", address)
+		}
+		length := 1
+		if len(parts) == 2 {
+			if length, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil || length < 1 {
+				return "", fmt.Errorf("evaluate error: bad length %q", strings.TrimSpace(parts[1]))
+			}
+		}
+		data, err := system.ReadMemory(chip8.Address(address), length)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("% X", data), nil
+	}
+
+	value, err := evaluateSum(state, table, expression)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%X (%v)", value, value), nil
+}
+
+func evaluateSum(state chip8.State, table *symbols.Table, expression string) (int, error) {
+	sum := 0
+	for _, term := range strings.Split(expression, "+") {
+		value, err := evaluateTerm(state, table, strings.TrimSpace(term))
+		if err != nil {
+			return 0, err
+		}
+		sum += value
+	}
+	return sum, nil
+}
+
+//Labels win over registers with the same name
+func evaluateTerm(state chip8.State, table *symbols.Table, term string) (int, error) {
+	if address, ok := table.Lookup(term); ok {
+		return int(address), nil
+	}
+	term = strings.ToLower(term)
+	switch term {
+	case "i":
+		return int(state.I), nil
+	case "pc":
+		return int(state.PC), nil
+	case "sp":
+		return int(state.SP), nil
+	case "dt":
+		return int(state.Delay), nil
+	case "st":
+		return int(state.Sound), nil
+	}
+	if len(term) == 2 && term[0] == 'v' {
+		if register, err := strconv.ParseUint(term[1:], 16, 8); err == nil {
+			return int(state.Registers[register]), nil
+		}
+	}
+	if value, err := strconv.ParseUint(term, 0, 16); err == nil {
+		return int(value), nil
+	}
+	return 0, fmt.Errorf("evaluate error: unknown value %q", term)
+}
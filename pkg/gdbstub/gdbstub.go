@@ -0,0 +1,338 @@
+//GDB remote serial protocol stub so programs can be debugged with gdb or anything else that speaks the protocol
+//The stub owns a system that isn't running and steps it itself
+package gdbstub
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+REGISTERS
+Numbered V0-VF 0-15, I 16, PC 17, SP 18, DT 19 and ST 20
+I and PC are 16 bits and big endian like the rest of CHIP-8, the others are 8 bits
+*/
+const (
+	registerI = 16 + iota
+	registerPC
+	registerSP
+	registerDT
+	registerST
+	registerCount
+)
+
+var registerNames = []string{"v0", "v1", "v2", "v3", "v4", "v5", "v6", "v7", "v8", "v9", "va", "vb", "vc", "vd", "ve", "vf", "i", "pc", "sp", "dt", "st"}
+
+//Signals reported when the target stops
+const (
+	signalInterrupt = 0x02
+	signalIllegal   = 0x04
+	signalTrap      = 0x05
+	signalSegfault  = 0x0B
+)
+
+//Instructions run between checks for an interrupt while continuing
+const interruptCheckSteps = 1024
+
+type Server struct {
+	system      *chip8.Chip8
+	breakpoints map[chip8.Address]bool
+}
+
+func NewServer(system *chip8.Chip8) *Server {
+	return &Server{system: system, breakpoints: map[chip8.Address]bool{}}
+}
+
+//Accepts one debugger at a time until the listener is closed
+func (server *Server) Serve(listener net.Listener) error {
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		server.serveConnection(connection)
+	}
+}
+
+func (server *Server) ListenAndServe(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return server.Serve(listener)
+}
+
+//State of one debugger connection
+type session struct {
+	server   *Server
+	conn     net.Conn
+	messages chan message
+	noAck    int32 //Set atomically as the reader checks it
+	pending  []string
+}
+
+func (server *Server) serveConnection(conn net.Conn) {
+	session := &session{server: server, conn: conn, messages: make(chan message)}
+	go readMessages(bufio.NewReader(conn), conn, func() bool { return atomic.LoadInt32(&session.noAck) != 0 }, session.messages)
+	defer func() {
+		//Closing stops the reader, which is drained so it doesn't block sending
+		conn.Close()
+		for range session.messages {
+		}
+	}()
+
+	for {
+		var packet string
+		if len(session.pending) > 0 {
+			packet, session.pending = session.pending[0], session.pending[1:]
+		} else {
+			message, ok := <-session.messages
+			if !ok || message.err != nil {
+				return
+			}
+			if message.interrupt {
+				session.reply(stopReply(signalInterrupt))
+				continue
+			}
+			packet = message.packet
+		}
+
+		reply, isDone := session.handle(packet)
+		if reply != nil {
+			session.reply(*reply)
+		}
+		if isDone {
+			return
+		}
+	}
+}
+
+func (session *session) reply(data string) {
+	session.conn.Write(encodePacket(data))
+}
+
+func stopReply(signal byte) string {
+	return fmt.Sprintf("S%02x", signal)
+}
+
+func replyString(reply string) *string {
+	return &reply
+}
+
+//Returns the reply to send, nil for none, and whether the connection is finished
+func (session *session) handle(packet string) (*string, bool) {
+	server := session.server
+	system := server.system
+	if packet == "" {
+		return replyString(""), false
+	}
+
+	command, arguments := packet[0], packet[1:]
+	switch command {
+	case '?':
+		return replyString(stopReply(signalTrap)), false
+	case 'g':
+		return replyString(encodeRegisters(system.State())), false
+	case 'G':
+		data, err := hex.DecodeString(arguments)
+		if err != nil || len(data) != registerBytes {
+			return replyString("E01"), false
+		}
+		return replyString(errorReply(system.SetState(decodeRegisters(system.State(), data)))), false
+	case 'p':
+		number, err := strconv.ParseUint(arguments, 16, 8)
+		if err != nil || number >= registerCount {
+			return replyString("E01"), false
+		}
+		return replyString(hex.EncodeToString(registerValue(system.State(), int(number)))), false
+	case 'P':
+		parts := strings.SplitN(arguments, "=", 2)
+		if len(parts) != 2 {
+			return replyString("E01"), false
+		}
+		number, err := strconv.ParseUint(parts[0], 16, 8)
+		data, dataErr := hex.DecodeString(parts[1])
+		if err != nil || dataErr != nil || number >= registerCount || len(data) != registerSize(int(number)) {
+			return replyString("E01"), false
+		}
+		state := system.State()
+		setRegister(&state, int(number), data)
+		return replyString(errorReply(system.SetState(state))), false
+	case 'm':
+		address, length, err := parseRange(arguments)
+		if err != nil {
+			return replyString("E01"), false
+		}
+		data, err := system.ReadMemory(address, length)
+		if err != nil {
+			return replyString("E02"), false
+		}
+		return replyString(hex.EncodeToString(data)), false
+	case 'M':
+		parts := strings.SplitN(arguments, ":", 2)
+		if len(parts) != 2 {
+			return replyString("E01"), false
+		}
+		address, length, err := parseRange(parts[0])
+		data, dataErr := hex.DecodeString(parts[1])
+		if err != nil || dataErr != nil || len(data) != length {
+			return replyString("E01"), false
+		}
+		if err := system.WriteMemory(address, data); err != nil {
+			return replyString("E02"), false
+		}
+		return replyString("OK"), false
+	case 'Z', 'z':
+		return replyString(server.setBreakpoint(command == 'Z', arguments)), false
+	case 's', 'c':
+		if arguments != "" {
+			address, err := strconv.ParseUint(arguments, 16, 16)
+			if err != nil {
+				return replyString("E01"), false
+			}
+			state := system.State()
+			state.PC = chip8.Address(address)
+			system.SetState(state)
+		}
+		return replyString(session.resume(command == 's')), false
+	case 'H', 'T':
+		return replyString("OK"), false
+	case 'D':
+		return replyString("OK"), true
+	case 'k':
+		return nil, true
+	case 'q', 'Q', 'v':
+		return replyString(session.query(packet)), false
+	}
+	return replyString(""), false //Empty replies tell the debugger a packet isn't supported
+}
+
+func (session *session) query(packet string) string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return "PacketSize=1000;qXfer:features:read+;swbreak+;QStartNoAckMode+"
+	case packet == "QStartNoAckMode":
+		atomic.StoreInt32(&session.noAck, 1)
+		return "OK"
+	case packet == "qAttached":
+		return "1"
+	case packet == "qC":
+		return "QC1"
+	case packet == "qfThreadInfo":
+		return "m1"
+	case packet == "qsThreadInfo":
+		return "l"
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return readChunk(targetDescription, strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+	}
+	return ""
+}
+
+//Steps once, or runs until a breakpoint, an error or an interrupt from the debugger
+func (session *session) resume(isStep bool) string {
+	server := session.server
+	for steps := 1; ; steps++ {
+		if err := server.system.Step(); err != nil {
+			return stopReply(errorSignal(err))
+		}
+		if isStep {
+			return stopReply(signalTrap)
+		}
+		if server.breakpoints[server.system.State().PC] {
+			return fmt.Sprintf("T%02xswbreak:;", signalTrap)
+		}
+
+		if steps%interruptCheckSteps == 0 {
+			select {
+			case message, ok := <-session.messages:
+				if !ok || message.err != nil || message.interrupt {
+					return stopReply(signalInterrupt)
+				}
+				session.pending = append(session.pending, message.packet)
+			default:
+			}
+		}
+	}
+}
+
+func errorSignal(err error) byte {
+	switch {
+	case errors.Is(err, chip8.ErrUnsupportedOpcode):
+		return signalIllegal
+	case errors.Is(err, chip8.ErrOutOfMemory), errors.Is(err, chip8.ErrStackOverflow), errors.Is(err, chip8.ErrStackEmpty):
+		return signalSegfault
+	}
+	return signalTrap
+}
+
+func errorReply(err error) string {
+	if err != nil {
+		return "E01"
+	}
+	return "OK"
+}
+
+//Z0 and Z1 are both kept by the stub, memory isn't patched
+func (server *Server) setBreakpoint(isSet bool, arguments string) string {
+	parts := strings.Split(arguments, ",")
+	if len(parts) < 2 || (parts[0] != "0" && parts[0] != "1") {
+		return ""
+	}
+	address, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "E01"
+	}
+	if isSet {
+		server.breakpoints[chip8.Address(address)] = true
+	} else {
+		delete(server.breakpoints, chip8.Address(address))
+	}
+	return "OK"
+}
+
+//addr,length in hex
+func parseRange(arguments string) (chip8.Address, int, error) {
+	parts := strings.Split(arguments, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("gdb error: malformed range %q", arguments)
+	}
+	address, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	return chip8.Address(address), int(length), nil
+}
+
+//offset,length of a qXfer object, l marks the last chunk
+func readChunk(object string, arguments string) string {
+	parts := strings.Split(arguments, ",")
+	if len(parts) != 2 {
+		return "E01"
+	}
+	offset, err := strconv.ParseUint(parts[0], 16, 32)
+	length, lengthErr := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil || lengthErr != nil {
+		return "E01"
+	}
+	if offset >= uint64(len(object)) {
+		return "l"
+	}
+	end := offset + length
+	if end >= uint64(len(object)) {
+		return "l" + object[offset:]
+	}
+	return "m" + object[offset:end]
+}
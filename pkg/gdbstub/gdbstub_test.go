@@ -0,0 +1,136 @@
+package gdbstub
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Debugger end of a loopback connection
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (client *client) send(data string) {
+	fmt.Fprintf(client.conn, "$%s#%02x", data, checksum(data))
+	if ack, err := client.reader.ReadByte(); err != nil || ack != '+' {
+		client.t.Fatalf("FAIL %q ack=%q err=%v (expected +)", data, ack, err)
+	}
+}
+
+func (client *client) receive() string {
+	client.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := client.reader.ReadString('$'); err != nil {
+		client.t.Fatal(err)
+	}
+	data, err := client.reader.ReadString('#')
+	if err != nil {
+		client.t.Fatal(err)
+	}
+	checksumText := make([]byte, 2)
+	if _, err := client.reader.Read(checksumText); err != nil {
+		client.t.Fatal(err)
+	}
+	client.conn.Write([]byte{'+'})
+	return data[:len(data)-1]
+}
+
+//Sends a packet and checks the reply
+func (client *client) expect(data string, expected string) {
+	client.t.Helper()
+	client.send(data)
+	if reply := client.receive(); reply != expected {
+		client.t.Errorf("FAIL %q reply=%q (expected %q)", data, reply, expected)
+	}
+}
+
+func startServer(t *testing.T, program []byte) *client {
+	system, _, _, _ := chip8.New()
+	if err := system.LoadProgram(program); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(system).Serve(listener)
+	t.Cleanup(func() { listener.Close() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func TestSession(t *testing.T) {
+	//LD V1, 0x12; LD I, 0x300; ADD V1, 1; JP 0x204
+	client := startServer(t, []byte{0x61, 0x12, 0xA3, 0x00, 0x71, 0x01, 0x12, 0x04})
+
+	client.send("qSupported:multiprocess+;swbreak+")
+	if reply := client.receive(); !strings.Contains(reply, "qXfer:features:read+") {
+		t.Errorf("FAIL qSupported reply=%q", reply)
+	}
+	client.send("qXfer:features:read:target.xml:0,1000")
+	if reply := client.receive(); !strings.HasPrefix(reply, "l<?xml") || !strings.Contains(reply, `name="pc"`) {
+		t.Errorf("FAIL target description reply=%q", reply)
+	}
+	client.expect("?", "S05")
+	client.expect("g", strings.Repeat("00", 16)+"0000"+"0200"+"000000")
+
+	//Single step
+	client.expect("s", "S05")
+	client.expect("p1", "12")
+	client.expect("p11", "0202")
+
+	//Breakpoint on the ADD, continuing from it runs the loop once
+	client.expect("Z0,204,2", "OK")
+	client.expect("c", "T05swbreak:;")
+	client.expect("p10", "0300")
+	client.expect("c", "T05swbreak:;")
+	client.expect("p1", "13")
+	client.expect("z0,204,2", "OK")
+
+	//Registers and memory
+	client.expect("P1=7f", "OK")
+	client.expect("p1", "7f")
+	client.expect("m200,4", "6112a300")
+	client.expect("M300,3:abcdef", "OK")
+	client.expect("m300,3", "abcdef")
+	client.expect("mfff,2", "E02")
+
+	//Interrupting the loop
+	client.send("c")
+	time.Sleep(10 * time.Millisecond)
+	client.conn.Write([]byte{interruptByte})
+	if reply := client.receive(); reply != "S02" {
+		t.Errorf("FAIL interrupt reply=%q (expected S02)", reply)
+	}
+
+	client.expect("D", "OK")
+}
+
+func TestStopOnError(t *testing.T) {
+	//RET with nothing on the stack
+	client := startServer(t, []byte{0x00, 0xEE})
+	client.expect("c", "S0b")
+}
+
+func TestNoAckMode(t *testing.T) {
+	client := startServer(t, []byte{0x61, 0x12})
+	client.expect("QStartNoAckMode", "OK")
+
+	fmt.Fprintf(client.conn, "$%s#%02x", "p11", checksum("p11"))
+	if reply := client.receive(); reply != "0200" {
+		t.Errorf("FAIL reply=%q (expected 0200)", reply)
+	}
+}
@@ -0,0 +1,80 @@
+package gdbstub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const interruptByte = 0x03 //Ctrl-C from the debugger while the target runs
+
+//Packet or interrupt read from the debugger
+type message struct {
+	packet    string
+	interrupt bool
+	err       error
+}
+
+//Reads $packet#checksum frames, acking each one unless no ack mode was turned on
+//Acks sent by the debugger are skipped, a bad checksum is nacked so the debugger resends
+func readMessages(reader *bufio.Reader, writer io.Writer, noAck func() bool, messages chan<- message) {
+	defer close(messages)
+	for {
+		start, err := reader.ReadByte()
+		if err != nil {
+			messages <- message{err: err}
+			return
+		}
+
+		switch start {
+		case interruptByte:
+			messages <- message{interrupt: true}
+		case '$':
+			data, err := reader.ReadString('#')
+			if err != nil {
+				messages <- message{err: err}
+				return
+			}
+			data = data[:len(data)-1]
+			checksumText := make([]byte, 2)
+			if _, err := io.ReadFull(reader, checksumText); err != nil {
+				messages <- message{err: err}
+				return
+			}
+
+			var expected byte
+			if _, err := fmt.Sscanf(string(checksumText), "%02x", &expected); err != nil || checksum(data) != expected {
+				if !noAck() {
+					writer.Write([]byte{'-'})
+				}
+				continue
+			}
+			if !noAck() {
+				writer.Write([]byte{'+'})
+			}
+			messages <- message{packet: data}
+		}
+	}
+}
+
+func checksum(data string) byte {
+	sum := byte(0)
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	return sum
+}
+
+//Frames a reply, characters the protocol reserves are escaped
+func encodePacket(data string) []byte {
+	escaped := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '$', '#', '}', '*':
+			escaped = append(escaped, '}', data[i]^0x20)
+		default:
+			escaped = append(escaped, data[i])
+		}
+	}
+	return []byte(fmt.Sprintf("$%s#%02x", escaped, checksum(string(escaped))))
+}
@@ -0,0 +1,95 @@
+package gdbstub
+
+import (
+	"fmt"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Bytes in a g packet
+const registerBytes = 16 + 2 + 2 + 1 + 1 + 1
+
+func registerSize(number int) int {
+	if number == registerI || number == registerPC {
+		return 2
+	}
+	return 1
+}
+
+func registerValue(state chip8.State, number int) []byte {
+	switch number {
+	case registerI:
+		return []byte{byte(state.I >> 8), byte(state.I)}
+	case registerPC:
+		return []byte{byte(state.PC >> 8), byte(state.PC)}
+	case registerSP:
+		return []byte{state.SP}
+	case registerDT:
+		return []byte{state.Delay}
+	case registerST:
+		return []byte{state.Sound}
+	}
+	return []byte{state.Registers[number]}
+}
+
+func setRegister(state *chip8.State, number int, data []byte) {
+	switch number {
+	case registerI:
+		state.I = chip8.Address(data[0])<<8 | chip8.Address(data[1])
+	case registerPC:
+		state.PC = chip8.Address(data[0])<<8 | chip8.Address(data[1])
+	case registerSP:
+		state.SP = data[0]
+	case registerDT:
+		state.Delay = data[0]
+	case registerST:
+		state.Sound = data[0]
+	default:
+		state.Registers[number] = data[0]
+	}
+}
+
+func encodeRegisters(state chip8.State) string {
+	builder := strings.Builder{}
+	for number := 0; number < registerCount; number++ {
+		for _, value := range registerValue(state, number) {
+			fmt.Fprintf(&builder, "%02x", value)
+		}
+	}
+	return builder.String()
+}
+
+//data must be registerBytes long
+func decodeRegisters(state chip8.State, data []byte) chip8.State {
+	for number := 0; number < registerCount; number++ {
+		size := registerSize(number)
+		setRegister(&state, number, data[:size])
+		data = data[size:]
+	}
+	return state
+}
+
+//Target description gdb asks for so it knows the register set
+var targetDescription = buildTargetDescription()
+
+func buildTargetDescription() string {
+	builder := strings.Builder{}
+	builder.WriteString(`<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.gongaware.chip8">
+`)
+	for number, name := range registerNames {
+		kind := "uint8"
+		switch number {
+		case registerI:
+			kind = "data_ptr"
+		case registerPC:
+			kind = "code_ptr"
+		}
+		fmt.Fprintf(&builder, "    <reg name=%q bitsize=\"%v\" regnum=\"%v\" type=%q/>\n", name, registerSize(number)*8, number, kind)
+	}
+	builder.WriteString("  </feature>\n</target>\n")
+	return builder.String()
+}
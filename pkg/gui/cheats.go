@@ -0,0 +1,319 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+
+	"gioui.org/app"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gongaware.org/gChip8/pkg/cheats"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+CHEATS WINDOW
+A text grid like the debugger's
+	row 0		search buttons, each narrows the candidates by comparing memory with the last search
+	row 1		value the equal search looks for, type hex digits to change it
+	rows 4-15	candidates, click one to add a cheat that freezes it at its value now
+	rows 19-31	cheats, click [ ] to turn one on or off, its kind to switch between freeze and poke, DEL to remove it
+				click a value then type hex digits to change it
+Every change is sent to the system straight away and saved when there is a cheat file, cheats the system rejects are reported above the list
+*/
+
+const (
+	cheatColumns = 60
+	cheatRows    = 32
+
+	candidateTop  = 4
+	candidateRows = 12
+
+	cheatTop       = 19
+	cheatListRows  = 13
+	cheatValue     = 8 //Columns of the parts of a cheat line that can be clicked
+	cheatKind      = 11
+	cheatKindWidth = 6
+	cheatDelete    = 18
+)
+
+var cheatEnabled = color.NRGBA{0x20, 0x70, 0x30, 0xFF}
+
+//Clickable searches on the toolbar, restart has no comparison
+type searchButton struct {
+	column     int
+	label      string
+	comparison cheats.Comparison
+	isRestart  bool
+}
+
+var searchButtons = []searchButton{
+	{0, "RESTART", 0, true},
+	{9, "EQUAL", cheats.Equal, false},
+	{16, "CHANGED", cheats.Changed, false},
+	{25, "SAME", cheats.Unchanged, false},
+	{31, "UP", cheats.Increased, false},
+	{35, "DOWN", cheats.Decreased, false},
+}
+
+type cheatWindow struct {
+	window *app.Window
+	ops    *op.Ops
+	scale  int //Pixels per dot when last drawn
+
+	channel  chan<- []chip8.Cheat
+	file     *cheats.File //nil when the cheats aren't saved
+	filename string
+	romHash  string
+	cheats   []cheats.Cheat
+
+	memory    [chip8.RamSize]byte
+	hasMemory bool
+	search    *cheats.Search //nil until the first snapshot
+
+	value    byte //Looked for by the equal search
+	selected int  //Cheat whose value is being typed, -1 types the search value
+	nibble   int  //High nibble typed, -1 before one is typed
+	message  string
+
+	cheatError error //Last rejection the system reported, so it is only shown once
+}
+
+func newCheatWindow(channel chan<- []chip8.Cheat, file *cheats.File, filename, romHash string) *cheatWindow {
+	window := &cheatWindow{channel: channel, file: file, filename: filename, romHash: romHash, selected: -1, nibble: -1}
+	if file != nil {
+		window.cheats = append(window.cheats, file.Cheats(romHash)...)
+	}
+	return window
+}
+
+func (window *cheatWindow) open() {
+	window.window = app.NewWindow(
+		app.Title("gChip8 cheats"),
+		app.Size(unit.Dp(600), unit.Dp(440)),
+	)
+	window.ops = new(op.Ops)
+}
+
+//nil without a cheat window so selecting on it never fires
+func (window *cheatWindow) windowEvents() <-chan event.Event {
+	if window == nil {
+		return nil
+	}
+	return window.window.Events()
+}
+
+//Returns false once the window is closed
+func (window *cheatWindow) handleWindowEvent(windowEvent event.Event) bool {
+	switch windowEvent := windowEvent.(type) {
+	case system.DestroyEvent:
+		return false
+	case system.FrameEvent:
+		gtx := layout.NewContext(window.ops, windowEvent)
+		for _, pointerEvent := range gtx.Events(window) {
+			if pointerEvent, ok := pointerEvent.(pointer.Event); ok && pointerEvent.Type == pointer.Press && window.scale > 0 {
+				window.click(gridCell(pointerEvent.Position, window.scale))
+			}
+		}
+		window.draw(gtx.Ops, windowEvent.Size)
+		windowEvent.Frame(gtx.Ops)
+	case key.Event:
+		if windowEvent.State == key.Press && window.handleKey(windowEvent.Name) {
+			window.window.Invalidate()
+		}
+	}
+	return true
+}
+
+//Keeps the latest memory for the next search, the first snapshot starts one
+func (window *cheatWindow) observe(snapshot chip8.Snapshot) {
+	if snapshot.CheatError != window.cheatError {
+		window.cheatError = snapshot.CheatError
+		if window.cheatError != nil {
+			window.message = window.cheatError.Error()
+		}
+		window.invalidate()
+	}
+	if window.hasMemory && snapshot.Memory == window.memory {
+		return
+	}
+	window.memory, window.hasMemory = snapshot.Memory, true
+	if window.search == nil {
+		window.search = cheats.NewSearch(snapshot.Memory)
+	}
+	window.invalidate()
+}
+
+func (window *cheatWindow) invalidate() {
+	if window.window != nil {
+		window.window.Invalidate()
+	}
+}
+
+func (window *cheatWindow) click(column, row int) {
+	defer window.invalidate()
+	switch {
+	case row == 0 && window.search != nil:
+		for _, button := range searchButtons {
+			if column < button.column || column >= button.column+len(button.label) {
+				continue
+			}
+			if button.isRestart {
+				window.search.Reset(window.memory)
+			} else {
+				window.search.Filter(window.memory, button.comparison, window.value)
+			}
+		}
+	case row >= candidateTop && row < candidateTop+candidateRows && window.search != nil:
+		if i := row - candidateTop; i < len(window.search.Candidates()) {
+			address := window.search.Candidates()[i]
+			cheat := cheats.Cheat{Name: fmt.Sprintf("%.3X", uint16(address)), Address: address, Value: window.memory[address], Freeze: true, Enabled: true}
+			window.cheats = append(window.cheats, cheat)
+			window.apply()
+		}
+	case row >= cheatTop && row < cheatTop+cheatListRows && row-cheatTop < len(window.cheats):
+		i := row - cheatTop
+		cheat := &window.cheats[i]
+		switch {
+		case column < 3:
+			cheat.Enabled = !cheat.Enabled
+			window.apply()
+		case column >= cheatValue && column < cheatValue+2:
+			window.selected, window.nibble = i, -1
+		case column >= cheatKind && column < cheatKind+cheatKindWidth:
+			cheat.Freeze = !cheat.Freeze
+			window.apply()
+		case column >= cheatDelete && column < cheatDelete+3:
+			window.cheats = append(window.cheats[:i], window.cheats[i+1:]...)
+			window.selected = -1
+			window.apply()
+		}
+	}
+}
+
+//Returns whether the window needs redrawing
+func (window *cheatWindow) handleKey(name string) bool {
+	if name == key.NameEscape {
+		window.selected, window.nibble = -1, -1
+		return true
+	}
+
+	digit, err := strconv.ParseUint(name, 16, 8)
+	if err != nil || len(name) != 1 {
+		return false
+	}
+	if window.nibble < 0 {
+		window.nibble = int(digit)
+		return true
+	}
+	value := byte(window.nibble)<<4 | byte(digit)
+	window.nibble = -1
+	if window.selected < 0 {
+		window.value = value
+		return true
+	}
+
+	cheat := &window.cheats[window.selected]
+	cheat.Value = value
+	window.selected = -1
+	window.apply()
+	return true
+}
+
+//Sends the enabled cheats, the system only pokes those that are new or changed, then saves the cheats
+func (window *cheatWindow) apply() {
+	window.channel <- cheats.Enabled(window.cheats)
+
+	window.message = ""
+	if window.file != nil {
+		window.file.SetCheats(window.romHash, append([]cheats.Cheat{}, window.cheats...))
+		if err := window.file.Save(window.filename); err != nil {
+			window.message = err.Error()
+		}
+	}
+}
+
+//Text of every row and the cells highlighted behind it
+func (window *cheatWindow) screen() ([]string, []cellHighlight) {
+	grid := newTextGrid(cheatColumns, cheatRows)
+	for _, button := range searchButtons {
+		grid.highlight(button.column, 0, len(button.label), gridButton)
+		grid.write(button.column, 0, button.label)
+	}
+
+	value := fmt.Sprintf("%.2X", window.value)
+	if window.selected < 0 && window.nibble >= 0 {
+		value = fmt.Sprintf("%X_", window.nibble)
+	}
+	grid.write(0, 1, fmt.Sprintf("VALUE %s  TYPE HEX DIGITS FOR EQUAL", value))
+
+	if window.search == nil {
+		grid.write(0, 2, "WAITING FOR MEMORY")
+	} else {
+		candidates := window.search.Candidates()
+		grid.write(0, 2, fmt.Sprintf("%v CANDIDATES", len(candidates)))
+		grid.write(0, candidateTop-1, "CLICK AN ADDRESS TO FREEZE IT")
+		for i, address := range candidates {
+			if i == candidateRows {
+				grid.write(2, candidateTop+i, fmt.Sprintf("AND %v MORE", len(candidates)-candidateRows))
+				break
+			}
+			grid.write(2, candidateTop+i, fmt.Sprintf("%.3X  NOW %.2X  WAS %.2X", uint16(address), window.memory[address], window.search.Previous(address)))
+		}
+	}
+
+	saved := "NOT SAVED"
+	if window.file != nil {
+		saved = "SAVED TO " + window.filename
+	}
+	if window.message != "" {
+		saved = window.message
+	}
+	grid.write(0, cheatTop-1, truncate("CHEATS  "+saved, cheatColumns))
+	for i, cheat := range window.cheats {
+		if i == cheatListRows {
+			break
+		}
+		row := cheatTop + i
+		enabled, kind := " ", "POKE"
+		if cheat.Enabled {
+			enabled = "X"
+			grid.highlight(0, row, 3, cheatEnabled)
+		}
+		if cheat.Freeze {
+			kind = "FREEZE"
+		}
+		value := fmt.Sprintf("%.2X", cheat.Value)
+		if i == window.selected {
+			grid.highlight(cheatValue, row, 2, debuggerSelection)
+			if window.nibble >= 0 {
+				value = fmt.Sprintf("%X_", window.nibble)
+			}
+		}
+		grid.highlight(cheatDelete, row, 3, gridButton)
+		grid.write(0, row, fmt.Sprintf("[%s] %.3X=%s %-6s DEL  %s", enabled, uint16(cheat.Address), value, kind, cheat.Name))
+	}
+	return grid.lines(), grid.highlights
+}
+
+//Draws the grid as large as fits the window and registers it for clicks
+func (window *cheatWindow) draw(ops *op.Ops, windowSize image.Point) {
+	paint.Fill(ops, gridBackground)
+
+	window.scale = gridScale(windowSize, cheatColumns, cheatRows)
+	lines, highlights := window.screen()
+	screen := renderGrid(cheatColumns, cheatRows, lines, highlights, window.scale)
+	defer clip.Rect(screen.Bounds()).Push(ops).Pop()
+	paint.NewImageOp(screen).Add(ops)
+	paint.PaintOp{}.Add(ops)
+	pointer.InputOp{Tag: window, Types: pointer.Press}.Add(ops)
+}
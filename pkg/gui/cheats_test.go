@@ -0,0 +1,84 @@
+package gui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/cheats"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+const cheatRomHash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestCheatWindow(t *testing.T) {
+	channel := make(chan []chip8.Cheat, 4)
+	filename := filepath.Join(t.TempDir(), "cheats.json")
+	window := newCheatWindow(channel, &cheats.File{}, filename, cheatRomHash)
+
+	var snapshot chip8.Snapshot
+	snapshot.Memory[0x3F0], snapshot.Memory[0x3F1] = 3, 3
+	window.observe(snapshot)
+
+	//Lose a life then search for the byte that went down
+	snapshot.Memory[0x3F0] = 2
+	window.observe(snapshot)
+	window.click(searchButtons[5].column, 0)
+	lines, _ := window.screen()
+	if lines[2] != "1 CANDIDATES" || !strings.HasPrefix(lines[candidateTop], "  3F0  NOW 02  WAS 02") {
+		t.Errorf("FAIL after searching down:\n%v", strings.Join(lines[:candidateTop+1], "\n"))
+	}
+
+	window.click(2, candidateTop)
+	if cheats := <-channel; len(cheats) != 1 || cheats[0] != (chip8.Cheat{Address: 0x3F0, Value: 2, Freeze: true}) {
+		t.Errorf("FAIL cheats sent=%+v (expected 3F0 frozen at 02)", cheats)
+	}
+
+	//Change the value to 09 and make it a poke
+	window.click(cheatValue, cheatTop)
+	window.handleKey("0")
+	window.handleKey("9")
+	<-channel
+	window.click(cheatKind, cheatTop)
+	if cheats := <-channel; len(cheats) != 1 || cheats[0] != (chip8.Cheat{Address: 0x3F0, Value: 9}) {
+		t.Errorf("FAIL cheats sent=%+v after switching to a poke (expected the 09 poke)", cheats)
+	}
+	window.click(0, cheatTop)
+	window.click(0, cheatTop)
+	if cheats := <-channel; len(cheats) != 0 {
+		t.Errorf("FAIL cheats sent=%+v after turning the poke off (expected none)", cheats)
+	}
+	if cheats := <-channel; len(cheats) != 1 || cheats[0] != (chip8.Cheat{Address: 0x3F0, Value: 9}) {
+		t.Errorf("FAIL cheats sent=%+v after turning the poke on (expected the 09 poke)", cheats)
+	}
+
+	lines, _ = window.screen()
+	if !strings.HasPrefix(lines[cheatTop], "[X] 3F0=09 POKE   DEL  3F0") {
+		t.Errorf("FAIL cheat line=%q", lines[cheatTop])
+	}
+	file, err := cheats.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved := file.Cheats(cheatRomHash); len(saved) != 1 || saved[0].Value != 9 || saved[0].Freeze || !saved[0].Enabled {
+		t.Errorf("FAIL saved cheats=%+v (expected the enabled 09 poke)", saved)
+	}
+
+	window.click(cheatDelete, cheatTop)
+	<-channel
+	if file, _ := cheats.Load(filename); len(file.Cheats(cheatRomHash)) != 0 {
+		t.Errorf("FAIL deleted cheat is still saved")
+	}
+}
+
+func TestCheatWindowShowsRejectedCheats(t *testing.T) {
+	window := newCheatWindow(make(chan []chip8.Cheat, 4), nil, "", cheatRomHash)
+	var snapshot chip8.Snapshot
+	snapshot.CheatError = fmt.Errorf("cheat error: 0x1000 out of memory")
+	window.observe(snapshot)
+
+	if lines, _ := window.screen(); !strings.HasPrefix(lines[cheatTop-1], "CHEATS  cheat error: 0x1000") {
+		t.Errorf("FAIL row %v=%q (expected the rejected cheats)", cheatTop-1, lines[cheatTop-1])
+	}
+}
@@ -0,0 +1,369 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"gioui.org/app"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/io/system"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+DEBUGGER WINDOW
+A grid of text drawn with the overlay font, row and column numbers below are cells of that grid
+	row 0		toolbar, F5 pauses or continues and F10 steps
+	rows 2-21	disassembly around PC on the left, click a line to toggle its breakpoint
+				registers and the stack on the right
+	rows 24-39	memory, click a byte then type hex digits to change it, a rejected change is shown beside the title
+Up and down scroll the disassembly, page up and down scroll memory and the arrows move the selected byte
+*/
+
+const (
+	debuggerColumns = 80
+	debuggerRows    = 40
+
+	codeTop     = 2
+	codeRows    = 20
+	codeColumns = 40
+	codeContext = 4 //Instructions shown before PC when the disassembly follows it
+
+	registersColumn = 41
+	stackColumn     = 61 //Second column of the stack, the first is under the registers
+	stackTop        = 10
+
+	memoryTop        = 24
+	memoryRows       = 16
+	memoryRowBytes   = 16
+	memoryByteColumn = 5 //Column of the first byte on a memory line
+)
+
+var (
+	debuggerPC         = color.NRGBA{0x30, 0x50, 0x90, 0xFF}
+	debuggerBreakpoint = color.NRGBA{0x90, 0x20, 0x20, 0xFF}
+	debuggerSelection  = color.NRGBA{0xE0, 0x80, 0x20, 0xFF}
+)
+
+//Clickable commands on the toolbar
+type toolbarButton struct {
+	column, width int
+	command       chip8.Command
+}
+
+var toolbarButtons = []toolbarButton{
+	{0, 11, chip8.CommandPause},
+	{13, 8, chip8.CommandStep},
+}
+
+type debugger struct {
+	window *app.Window
+	ops    *op.Ops
+	scale  int //Pixels per dot when last drawn
+
+	events   chan<- chip8.DebugEvent
+	commands chan<- chip8.Command
+	symbols  chip8.Symbols
+
+	snapshot    chip8.Snapshot
+	hasSnapshot bool
+	breakpoints map[chip8.Address]bool
+
+	codeStart    chip8.Address //Address of the first line of disassembly
+	memoryStart  chip8.Address //Address of the first line of memory
+	selected     chip8.Address //Byte being edited
+	hasSelection bool
+	nibble       int //High nibble typed for the selected byte, -1 before one is typed
+}
+
+func newDebugger(events chan<- chip8.DebugEvent, commands chan<- chip8.Command, symbols chip8.Symbols) *debugger {
+	return &debugger{
+		events:      events,
+		commands:    commands,
+		symbols:     symbols,
+		codeStart:   0x200,
+		memoryStart: 0x200,
+		nibble:      -1,
+		breakpoints: map[chip8.Address]bool{},
+	}
+}
+
+func (debugger *debugger) open() {
+	debugger.window = app.NewWindow(
+		app.Title("gChip8 debugger"),
+		app.Size(unit.Dp(800), unit.Dp(560)),
+	)
+	debugger.ops = new(op.Ops)
+}
+
+//nil without a debugger so selecting on it never fires
+func (debugger *debugger) windowEvents() <-chan event.Event {
+	if debugger == nil {
+		return nil
+	}
+	return debugger.window.Events()
+}
+
+//Returns false once the window is closed
+func (debugger *debugger) handleWindowEvent(windowEvent event.Event) bool {
+	switch windowEvent := windowEvent.(type) {
+	case system.DestroyEvent:
+		return false
+	case system.FrameEvent:
+		gtx := layout.NewContext(debugger.ops, windowEvent)
+		for _, pointerEvent := range gtx.Events(debugger) {
+			if pointerEvent, ok := pointerEvent.(pointer.Event); ok && pointerEvent.Type == pointer.Press && debugger.scale > 0 {
+				debugger.click(gridCell(pointerEvent.Position, debugger.scale))
+			}
+		}
+		debugger.draw(gtx.Ops, windowEvent.Size)
+		windowEvent.Frame(gtx.Ops)
+	case key.Event:
+		if windowEvent.State == key.Press && debugger.handleKey(windowEvent.Name) {
+			debugger.window.Invalidate()
+		}
+	}
+	return true
+}
+
+//Redraws only when something shown has changed, snapshots come every tick even while paused
+func (debugger *debugger) observe(snapshot chip8.Snapshot) {
+	changed := !debugger.hasSnapshot || snapshot.State != debugger.snapshot.State || snapshot.Memory != debugger.snapshot.Memory ||
+		snapshot.IsPaused != debugger.snapshot.IsPaused || snapshot.DebugError != debugger.snapshot.DebugError ||
+		len(snapshot.Breakpoints) != len(debugger.breakpoints)
+	for _, address := range snapshot.Breakpoints {
+		changed = changed || !debugger.breakpoints[address]
+	}
+	if !changed {
+		return
+	}
+
+	debugger.snapshot, debugger.hasSnapshot = snapshot, true
+	debugger.breakpoints = map[chip8.Address]bool{}
+	for _, address := range snapshot.Breakpoints {
+		debugger.breakpoints[address] = true
+	}
+	debugger.follow(snapshot.PC)
+	if debugger.window != nil {
+		debugger.window.Invalidate()
+	}
+}
+
+//Scrolls the disassembly when PC isn't on one of its lines
+func (debugger *debugger) follow(pc chip8.Address) {
+	start, end := debugger.codeStart, debugger.codeStart+2*codeRows
+	if pc >= start && pc < end && (pc-start)%2 == 0 {
+		return
+	}
+	debugger.codeStart = clampAddress(int(pc)-2*codeContext, chip8.RamSize-2*codeRows)
+}
+
+func clampAddress(address int, last int) chip8.Address {
+	if address > last {
+		address = last
+	}
+	if address < 0 {
+		address = 0
+	}
+	return chip8.Address(address)
+}
+
+func (debugger *debugger) click(column, row int) {
+	switch {
+	case row == 0:
+		for _, button := range toolbarButtons {
+			if column >= button.column && column < button.column+button.width {
+				debugger.commands <- button.command
+			}
+		}
+	case row >= codeTop && row < codeTop+codeRows && column < codeColumns:
+		address := debugger.codeStart + chip8.Address(2*(row-codeTop))
+		eventType := chip8.DebugSetBreakpoint
+		if debugger.breakpoints[address] {
+			eventType = chip8.DebugClearBreakpoint
+		}
+		debugger.events <- chip8.DebugEvent{Type: eventType, Address: address}
+	case row >= memoryTop && row < memoryTop+memoryRows && column >= memoryByteColumn:
+		offset := column - memoryByteColumn
+		if offset%3 == 2 || offset/3 >= memoryRowBytes {
+			return //Between bytes
+		}
+		debugger.selected = debugger.memoryStart + chip8.Address((row-memoryTop)*memoryRowBytes+offset/3)
+		debugger.hasSelection, debugger.nibble = true, -1
+		if debugger.window != nil {
+			debugger.window.Invalidate()
+		}
+	}
+}
+
+//Returns whether the window needs redrawing
+func (debugger *debugger) handleKey(name string) bool {
+	switch name {
+	case key.NameF5:
+		debugger.commands <- chip8.CommandPause
+		return false
+	case key.NameF10:
+		debugger.commands <- chip8.CommandStep
+		return false
+	case key.NamePageUp:
+		debugger.memoryStart = clampAddress(int(debugger.memoryStart)-memoryRows*memoryRowBytes, chip8.RamSize-memoryRows*memoryRowBytes)
+		return true
+	case key.NamePageDown:
+		debugger.memoryStart = clampAddress(int(debugger.memoryStart)+memoryRows*memoryRowBytes, chip8.RamSize-memoryRows*memoryRowBytes)
+		return true
+	case key.NameEscape:
+		debugger.hasSelection = false
+		return true
+	}
+
+	if !debugger.hasSelection {
+		switch name {
+		case key.NameUpArrow:
+			debugger.codeStart = clampAddress(int(debugger.codeStart)-2, chip8.RamSize-2*codeRows)
+		case key.NameDownArrow:
+			debugger.codeStart = clampAddress(int(debugger.codeStart)+2, chip8.RamSize-2*codeRows)
+		default:
+			return false
+		}
+		return true
+	}
+
+	moves := map[string]int{key.NameLeftArrow: -1, key.NameRightArrow: 1, key.NameUpArrow: -memoryRowBytes, key.NameDownArrow: memoryRowBytes}
+	if move, ok := moves[name]; ok {
+		debugger.selectByte(int(debugger.selected) + move)
+		return true
+	}
+
+	digit, err := strconv.ParseUint(name, 16, 8)
+	if err != nil || len(name) != 1 {
+		return false
+	}
+	if debugger.nibble < 0 {
+		debugger.nibble = int(digit)
+		return true
+	}
+	value := byte(debugger.nibble)<<4 | byte(digit)
+	debugger.events <- chip8.DebugEvent{Type: chip8.DebugWriteMemory, Address: debugger.selected, Data: []byte{value}}
+	debugger.snapshot.Memory[debugger.selected] = value //Shown straight away, the next snapshot agrees
+	debugger.selectByte(int(debugger.selected) + 1)
+	return true
+}
+
+//Selects a byte and scrolls memory so it can be seen
+func (debugger *debugger) selectByte(address int) {
+	debugger.selected, debugger.nibble = clampAddress(address, chip8.RamSize-1), -1
+	if debugger.selected < debugger.memoryStart {
+		debugger.memoryStart -= memoryRowBytes
+	} else if debugger.selected >= debugger.memoryStart+memoryRows*memoryRowBytes {
+		debugger.memoryStart += memoryRowBytes
+	}
+}
+
+//Text of every row and the cells highlighted behind it
+func (debugger *debugger) screen() ([]string, []cellHighlight) {
+	grid := newTextGrid(debuggerColumns, debuggerRows)
+	write, highlight := grid.write, grid.highlight
+
+	snapshot := &debugger.snapshot
+	action, state := "F5 PAUSE", "RUNNING"
+	if snapshot.IsPaused {
+		action, state = "F5 CONTINUE", "PAUSED"
+	}
+	if snapshot.WaitingForKey {
+		state += ", WAITING FOR KEY"
+	}
+	for i, label := range []string{action, "F10 STEP"} {
+		highlight(toolbarButtons[i].column, 0, toolbarButtons[i].width, gridButton)
+		write(toolbarButtons[i].column, 0, label)
+	}
+	write(24, 0, fmt.Sprintf("%s  FRAME %v", state, snapshot.Frame))
+
+	write(0, 1, "DISASSEMBLY")
+	for i := 0; i < codeRows; i++ {
+		address := debugger.codeStart + chip8.Address(2*i)
+		row := codeTop + i
+		marker := "  "
+		if debugger.breakpoints[address] {
+			marker = "* "
+			highlight(0, row, 1, debuggerBreakpoint)
+		}
+		if address == snapshot.PC {
+			marker = marker[:1] + ">"
+			highlight(1, row, codeColumns-1, debuggerPC)
+		}
+		opcode := chip8.Instruction(snapshot.Memory[address])<<8 | chip8.Instruction(snapshot.Memory[address+1])
+		line := fmt.Sprintf("%s%.3X %.4X %s", marker, uint16(address), uint16(opcode), chip8.DisassembleSymbols(opcode, debugger.symbols))
+		write(0, row, truncate(line, codeColumns-1))
+	}
+
+	write(registersColumn, 1, "REGISTERS")
+	for start := 0; start < len(snapshot.Registers); start += 4 {
+		registers := make([]string, 4)
+		for i := range registers {
+			registers[i] = fmt.Sprintf("V%X %.2X", start+i, snapshot.Registers[start+i])
+		}
+		write(registersColumn, codeTop+start/4, strings.Join(registers, "  "))
+	}
+	write(registersColumn, codeTop+4, fmt.Sprintf("I %.3X  PC %.3X  SP %X", uint16(snapshot.I), uint16(snapshot.PC), snapshot.SP))
+	write(registersColumn, codeTop+5, fmt.Sprintf("DT %.2X  ST %.2X", snapshot.Delay, snapshot.Sound))
+
+	write(registersColumn, stackTop-1, "STACK")
+	for level, address := range snapshot.Stack {
+		column, row := registersColumn, stackTop+level
+		if level >= len(snapshot.Stack)/2 {
+			column, row = stackColumn, row-len(snapshot.Stack)/2
+		}
+		text := fmt.Sprintf("%X -", level)
+		if level < int(snapshot.SP) {
+			text = fmt.Sprintf("%X %s", level, chip8.Symbolize(debugger.symbols, address))
+		}
+		write(column, row, truncate(text, stackColumn-registersColumn-1))
+	}
+
+	write(0, memoryTop-1, "MEMORY")
+	if snapshot.DebugError != nil {
+		write(8, memoryTop-1, truncate(snapshot.DebugError.Error(), debuggerColumns-8))
+	}
+	for i := 0; i < memoryRows; i++ {
+		start := debugger.memoryStart + chip8.Address(i*memoryRowBytes)
+		row := memoryTop + i
+		write(0, row, fmt.Sprintf("%.3X", uint16(start)))
+		for j := 0; j < memoryRowBytes; j++ {
+			address := start + chip8.Address(j)
+			column := memoryByteColumn + 3*j
+			text := fmt.Sprintf("%.2X", snapshot.Memory[address])
+			if debugger.hasSelection && address == debugger.selected {
+				highlight(column, row, 2, debuggerSelection)
+				if debugger.nibble >= 0 {
+					text = fmt.Sprintf("%X_", debugger.nibble)
+				}
+			}
+			write(column, row, text)
+		}
+	}
+
+	return grid.lines(), grid.highlights
+}
+
+//Draws the grid as large as fits the window and registers it for clicks
+func (debugger *debugger) draw(ops *op.Ops, windowSize image.Point) {
+	paint.Fill(ops, gridBackground)
+
+	debugger.scale = gridScale(windowSize, debuggerColumns, debuggerRows)
+	lines, highlights := debugger.screen()
+	screen := renderGrid(debuggerColumns, debuggerRows, lines, highlights, debugger.scale)
+	defer clip.Rect(screen.Bounds()).Push(ops).Pop()
+	paint.NewImageOp(screen).Add(ops)
+	paint.PaintOp{}.Add(ops)
+	pointer.InputOp{Tag: debugger, Types: pointer.Press}.Add(ops)
+}
@@ -0,0 +1,131 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+func testDebugger() (*debugger, chan chip8.DebugEvent, chan chip8.Command) {
+	events, commands := make(chan chip8.DebugEvent, 4), make(chan chip8.Command, 4)
+	debugger := newDebugger(events, commands, nil)
+
+	snapshot := chip8.Snapshot{Breakpoints: []chip8.Address{0x204}}
+	copy(snapshot.Memory[0x200:], []byte{0x22, 0x06, 0x12, 0x02, 0x70, 0x01, 0x00, 0xEE})
+	snapshot.PC, snapshot.SP, snapshot.IsPaused = 0x206, 1, true
+	snapshot.Stack[0] = 0x202
+	snapshot.Registers[0xA] = 0x3C
+	debugger.observe(snapshot)
+	return debugger, events, commands
+}
+
+func TestDebuggerScreen(t *testing.T) {
+	debugger, _, _ := testDebugger()
+	lines, highlights := debugger.screen()
+
+	expected := map[int]string{
+		0:           "F5 CONTINUE  F10 STEP   PAUSED  FRAME 0",
+		codeTop:     "  200 2206 CALL 0x206",
+		codeTop + 2: "* 204 7001 ADD V0, 0x01",
+		codeTop + 3: " >206 00EE RET",
+		memoryTop:   "200  22 06 12 02 70 01 00 EE 00 00 00 00 00 00 00 00",
+	}
+	for row, text := range expected {
+		if line := lines[row]; !strings.HasPrefix(line, text) {
+			t.Errorf("FAIL row %v=%q (expected %q)", row, line, text)
+		}
+	}
+	if !strings.Contains(lines[codeTop+2], "VA 3C") || !strings.Contains(lines[stackTop], "0 0x202") || !strings.Contains(lines[stackTop+1], "1 -") {
+		t.Errorf("FAIL registers and stack:\n%v", strings.Join(lines[codeTop:stackTop+2], "\n"))
+	}
+
+	hasPC := false
+	for _, highlight := range highlights {
+		hasPC = hasPC || (highlight.color == debuggerPC && highlight.cells.Min.Y == codeTop+3)
+	}
+	if !hasPC {
+		t.Errorf("FAIL PC line isn't highlighted")
+	}
+}
+
+func TestDebuggerShowsRejectedWrite(t *testing.T) {
+	debugger, _, _ := testDebugger()
+	snapshot := debugger.snapshot
+	snapshot.DebugError = fmt.Errorf("write error: 2 bytes at 0x0FFF out of memory")
+	debugger.observe(snapshot)
+
+	if lines, _ := debugger.screen(); !strings.HasPrefix(lines[memoryTop-1], "MEMORY  write error: 2 bytes") {
+		t.Errorf("FAIL row %v=%q (expected the rejected write)", memoryTop-1, lines[memoryTop-1])
+	}
+	snapshot.DebugError = nil
+	debugger.observe(snapshot)
+	if lines, _ := debugger.screen(); strings.TrimSpace(lines[memoryTop-1]) != "MEMORY" {
+		t.Errorf("FAIL row %v=%q (expected the error cleared)", memoryTop-1, lines[memoryTop-1])
+	}
+}
+
+func TestDebuggerClicks(t *testing.T) {
+	debugger, events, commands := testDebugger()
+
+	debugger.click(3, codeTop+2)
+	debugger.click(3, codeTop+1)
+	if event := <-events; event.Type != chip8.DebugClearBreakpoint || event.Address != 0x204 {
+		t.Errorf("FAIL click on a breakpoint=%+v (expected it cleared)", event)
+	}
+	if event := <-events; event.Type != chip8.DebugSetBreakpoint || event.Address != 0x202 {
+		t.Errorf("FAIL click on 0x202=%+v (expected a breakpoint set)", event)
+	}
+
+	debugger.click(14, 0)
+	if command := <-commands; command != chip8.CommandStep {
+		t.Errorf("FAIL toolbar command=%v (expected step)", command)
+	}
+
+	//Third byte of the second memory line then two hex digits
+	debugger.click(memoryByteColumn+2*3+1, memoryTop+1)
+	for _, name := range []string{"A", "7"} {
+		debugger.handleKey(name)
+	}
+	if event := <-events; event.Type != chip8.DebugWriteMemory || event.Address != 0x212 || len(event.Data) != 1 || event.Data[0] != 0xA7 {
+		t.Errorf("FAIL memory edit=%+v (expected 0xA7 written to 0x212)", event)
+	}
+	if debugger.selected != 0x213 || debugger.snapshot.Memory[0x212] != 0xA7 {
+		t.Errorf("FAIL selected=0x%.3X [0x212]=0x%.2X (expected 0x213 and 0xA7)", debugger.selected, debugger.snapshot.Memory[0x212])
+	}
+
+	debugger.click(memoryByteColumn+2, memoryTop) //Between bytes
+	if debugger.selected != 0x213 {
+		t.Errorf("FAIL click between bytes selected 0x%.3X", debugger.selected)
+	}
+	debugger.handleKey(key.NameDownArrow)
+	debugger.handleKey(key.NameLeftArrow)
+	if debugger.selected != 0x222 {
+		t.Errorf("FAIL selected=0x%.3X after moving (expected 0x222)", debugger.selected)
+	}
+}
+
+func TestDebuggerFollowsPC(t *testing.T) {
+	debugger, _, _ := testDebugger()
+	snapshot := debugger.snapshot
+	snapshot.PC = 0x300
+	debugger.observe(snapshot)
+	if debugger.codeStart != 0x300-2*codeContext {
+		t.Errorf("FAIL codeStart=0x%.3X (expected 0x%.3X)", debugger.codeStart, 0x300-2*codeContext)
+	}
+
+	//Still on screen so the disassembly stays put
+	snapshot.PC = 0x310
+	debugger.observe(snapshot)
+	if debugger.codeStart != 0x300-2*codeContext {
+		t.Errorf("FAIL codeStart=0x%.3X after a short jump (expected it unchanged)", debugger.codeStart)
+	}
+
+	snapshot.PC = 0xFFE
+	debugger.observe(snapshot)
+	if debugger.codeStart != chip8.RamSize-2*codeRows {
+		t.Errorf("FAIL codeStart=0x%.3X at the end of memory (expected 0x%.3X)", debugger.codeStart, chip8.RamSize-2*codeRows)
+	}
+}
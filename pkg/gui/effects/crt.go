@@ -0,0 +1,255 @@
+package effects
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io/ioutil"
+)
+
+//Post-processing step on a frame that has already been scaled up, several pixels to a dot
+type Transform func(source image.Image) image.Image
+
+//Runs the transforms in order, each on the result of the one before
+func Chain(transforms ...Transform) Transform {
+	return func(source image.Image) image.Image {
+		for _, transform := range transforms {
+			source = transform(source)
+		}
+		return source
+	}
+}
+
+//Settings of the CRT filters, a strength of 0 turns that filter off
+//Strengths go from 0 to 1, the zero value does nothing
+type CRTConfig struct {
+	Scanlines   float32 `json:"scanlines"`   //Darkening of the bottom line of each row of dots
+	Grid        float32 `json:"grid"`        //Darkening of the lines between dots
+	Bloom       float32 `json:"bloom"`       //Brightness of the glow around lit dots
+	BloomRadius int     `json:"bloomRadius"` //Size of the glow in pixels of the scaled up frame
+	Curvature   float32 `json:"curvature"`   //Bulge of the screen, 0.1 is slight
+	Vignette    float32 `json:"vignette"`    //Darkening towards the corners
+}
+
+//A look that works for most games
+func DefaultCRT() CRTConfig {
+	return CRTConfig{
+		Scanlines:   0.4,
+		Grid:        0.15,
+		Bloom:       0.35,
+		BloomRadius: 3,
+		Curvature:   0.06,
+		Vignette:    0.3,
+	}
+}
+
+//Loads a json file on top of the default settings, fields left out keep their defaults
+func LoadCRTConfig(filename string) (CRTConfig, error) {
+	config := DefaultCRT()
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("crt error: %s: %v", filename, err)
+	}
+	return config, nil
+}
+
+//The filters that are turned on, dots is the resolution of the display the frame shows
+func (config CRTConfig) Transform(dots image.Point) Transform {
+	transforms := []Transform{}
+	if config.Grid > 0 {
+		transforms = append(transforms, PixelGrid(config.Grid, dots))
+	}
+	if config.Scanlines > 0 {
+		transforms = append(transforms, Scanlines(config.Scanlines, dots.Y))
+	}
+	if config.Bloom > 0 && config.BloomRadius > 0 {
+		transforms = append(transforms, Bloom(config.Bloom, config.BloomRadius))
+	}
+	if config.Curvature > 0 {
+		transforms = append(transforms, Curvature(config.Curvature))
+	}
+	if config.Vignette > 0 {
+		transforms = append(transforms, Vignette(config.Vignette))
+	}
+	return Chain(transforms...)
+}
+
+//Darkens the last line of every row of dots, rows is the number of rows on the display
+//Dots less than 2 pixels high have no room for a gap so every other line is darkened instead
+func Scanlines(strength float32, rows int) Transform {
+	return func(source image.Image) image.Image {
+		result := toRGBA(source)
+		height := result.Bounds().Dy()
+		for y := 0; y < height; y++ {
+			if isGap(y, height, rows) {
+				scaleLine(result, y, 1-strength)
+			}
+		}
+		return result
+	}
+}
+
+//Darkens the lines between dots in both directions
+func PixelGrid(strength float32, dots image.Point) Transform {
+	return func(source image.Image) image.Image {
+		result := toRGBA(source)
+		size := result.Bounds().Size()
+		for y := 0; y < size.Y; y++ {
+			if isGap(y, size.Y, dots.Y) {
+				scaleLine(result, y, 1-strength)
+				continue
+			}
+			for x := 0; x < size.X; x++ {
+				if isGap(x, size.X, dots.X) {
+					scalePixel(result, x, y, 1-strength)
+				}
+			}
+		}
+		return result
+	}
+}
+
+//Whether pixel is the last one of its dot
+func isGap(pixel, pixels, dots int) bool {
+	if dots <= 0 || pixels < 2*dots {
+		return pixel%2 == 1
+	}
+	return (pixel*dots)/pixels != ((pixel+1)*dots)/pixels
+}
+
+//Adds a blurred copy of the frame so lit dots glow onto their surroundings
+func Bloom(strength float32, radius int) Transform {
+	return func(source image.Image) image.Image {
+		result := toRGBA(source)
+		glow := boxBlur(result, radius)
+		for i := range result.Pix {
+			if i%4 == 3 {
+				continue //Alpha
+			}
+			result.Pix[i] = clamp(float32(result.Pix[i]) + float32(glow.Pix[i])*strength)
+		}
+		return result
+	}
+}
+
+//Blurs with a box of radius pixels each way, horizontally and then vertically
+func boxBlur(source *image.RGBA, radius int) *image.RGBA {
+	size := source.Bounds().Size()
+	horizontal := image.NewRGBA(image.Rectangle{Max: size})
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	blurLines(source.Pix, horizontal.Pix, size.Y, size.X, source.Stride, 4, radius)
+	blurLines(horizontal.Pix, result.Pix, size.X, size.Y, 4, result.Stride, radius)
+	return result
+}
+
+//Running sum along lines of length pixels, lineStep apart with pixels step apart
+func blurLines(source, result []uint8, lines, length, lineStep, step, radius int) {
+	width := 2*radius + 1
+	for line := 0; line < lines; line++ {
+		start := line * lineStep
+		for channel := 0; channel < 3; channel++ {
+			at := func(i int) int {
+				if i < 0 || i >= length {
+					return 0 //Outside the frame counts as black
+				}
+				return int(source[start+i*step+channel])
+			}
+			sum := 0
+			for i := -radius; i <= radius; i++ {
+				sum += at(i)
+			}
+			for i := 0; i < length; i++ {
+				result[start+i*step+channel] = uint8(sum / width)
+				sum += at(i+radius+1) - at(i-radius)
+			}
+		}
+		for i := 0; i < length; i++ {
+			result[start+i*step+3] = 0xFF
+		}
+	}
+}
+
+//Bends the frame outwards like the glass of a CRT, corners that fall off the screen turn black
+func Curvature(amount float32) Transform {
+	return func(source image.Image) image.Image {
+		original := toRGBA(source)
+		size := original.Bounds().Size()
+		result := image.NewRGBA(image.Rectangle{Max: size})
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				u, v := centered(x, size.X), centered(y, size.Y)
+				bend := 1 + amount*(u*u+v*v)
+				sourceX, sourceY := uncentered(u*bend, size.X), uncentered(v*bend, size.Y)
+				if sourceX < 0 || sourceY < 0 || sourceX >= size.X || sourceY >= size.Y {
+					result.Pix[result.PixOffset(x, y)+3] = 0xFF
+					continue
+				}
+				copy(result.Pix[result.PixOffset(x, y):result.PixOffset(x, y)+4], original.Pix[original.PixOffset(sourceX, sourceY):])
+			}
+		}
+		return result
+	}
+}
+
+//Darkens the frame towards the edges, the corners lose strength of their brightness
+func Vignette(strength float32) Transform {
+	return func(source image.Image) image.Image {
+		result := toRGBA(source)
+		size := result.Bounds().Size()
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				u, v := centered(x, size.X), centered(y, size.Y)
+				scalePixel(result, x, y, 1-strength*(u*u+v*v)/2)
+			}
+		}
+		return result
+	}
+}
+
+//Position of the middle of a pixel from -1 to 1
+func centered(pixel, pixels int) float32 {
+	return (float32(pixel)+0.5)/float32(pixels)*2 - 1
+}
+
+func uncentered(position float32, pixels int) int {
+	pixel := (position + 1) / 2 * float32(pixels)
+	if pixel < 0 {
+		return -1 //Truncating would round small negatives up to 0
+	}
+	return int(pixel)
+}
+
+//Copy of the source that the transforms can change in place, starting at 0,0
+func toRGBA(source image.Image) *image.RGBA {
+	bounds := source.Bounds()
+	result := image.NewRGBA(image.Rectangle{Max: bounds.Size()})
+	draw.Draw(result, result.Bounds(), source, bounds.Min, draw.Src)
+	return result
+}
+
+func scaleLine(rgba *image.RGBA, y int, factor float32) {
+	for x := 0; x < rgba.Bounds().Dx(); x++ {
+		scalePixel(rgba, x, y, factor)
+	}
+}
+
+func scalePixel(rgba *image.RGBA, x, y int, factor float32) {
+	pixel := rgba.Pix[rgba.PixOffset(x, y):]
+	for channel := 0; channel < 3; channel++ {
+		pixel[channel] = clamp(float32(pixel[channel]) * factor)
+	}
+}
+
+func clamp(value float32) uint8 {
+	if value <= 0 {
+		return 0
+	}
+	if value >= 0xFF {
+		return 0xFF
+	}
+	return uint8(value)
+}
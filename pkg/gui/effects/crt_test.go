@@ -0,0 +1,111 @@
+package effects
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+//Checkerboard of 16x8 dots, each 6 pixels square
+var crtDots = image.Pt(16, 8)
+
+func crtSource() *image.RGBA {
+	const scale = 6
+	result := image.NewRGBA(image.Rectangle{Max: crtDots.Mul(scale)})
+	draw.Draw(result, result.Bounds(), image.NewUniform(color.RGBA{0x10, 0x10, 0x10, 0xFF}), image.Point{}, draw.Src)
+	on := image.NewUniform(color.RGBA{0xFF, 0xB0, 0x00, 0xFF})
+	for y := 0; y < crtDots.Y; y++ {
+		for x := 0; x < crtDots.X; x++ {
+			if (x+y)%2 == 0 || y == 3 {
+				draw.Draw(result, image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale), on, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return result
+}
+
+func TestCRTGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+	}{
+		{"scanlines", Scanlines(0.5, crtDots.Y)},
+		{"grid", PixelGrid(0.5, crtDots)},
+		{"bloom", Bloom(0.6, 3)},
+		{"curvature", Curvature(0.15)},
+		{"vignette", Vignette(0.6)},
+		{"default", DefaultCRT().Transform(crtDots)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := crtSource()
+			result := test.transform(source)
+			if result.Bounds() != source.Bounds() {
+				t.Fatalf("FAIL bounds=%v (expected %v)", result.Bounds(), source.Bounds())
+			}
+			if !bytes.Equal(source.Pix, crtSource().Pix) {
+				t.Fatalf("FAIL source was changed")
+			}
+			compareGolden(t, filepath.Join("testdata", "crt_"+test.name+".png"), result)
+		})
+	}
+}
+
+func TestCRTOff(t *testing.T) {
+	source := crtSource()
+	result := toRGBA(CRTConfig{}.Transform(crtDots)(source))
+	if !bytes.Equal(result.Pix, source.Pix) {
+		t.Errorf("FAIL the zero config changed the frame")
+	}
+}
+
+func TestLoadCRTConfig(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "crt.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"scanlines": 0.8, "curvature": 0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadCRTConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DefaultCRT()
+	expected.Scanlines, expected.Curvature = 0.8, 0
+	if config != expected {
+		t.Errorf("FAIL config=%+v (expected %+v)", config, expected)
+	}
+}
+
+func compareGolden(t *testing.T, filename string, result image.Image) {
+	encoded := bytes.Buffer{}
+	if err := png.Encode(&encoded, result); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := ioutil.WriteFile(filename, encoded.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("FAIL %v (run the tests with -update to create it)", err)
+	}
+	golden, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(toRGBA(golden).Pix, toRGBA(result).Pix) {
+		t.Errorf("FAIL %v doesn't match the golden image", filename)
+	}
+}
@@ -0,0 +1,163 @@
+//Display filters that run on the CPU before the frame reaches the window
+//Nothing here depends on the window toolkit so the filters can be tested on their own
+package effects
+
+import (
+	"fmt"
+	"image/color"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Brightness of every dot from 0 (off) to 1 (fully lit), indexed [row][column]
+type Levels [][]float32
+
+//One display sent by the core
+type Frame struct {
+	Dots  chip8.DotGrid
+	Drawn bool //A sprite was drawn, see chip8.Display.HasDrawn
+}
+
+//Anti-flicker filters turn the frames of the core into what is shown
+//Apply is called for every frame from the core, and with the last frame and Drawn unset at every 60hz tick
+//without a new one, so filters that change over time keep running while the display stays the same
+type Filter interface {
+	Apply(frame Frame) Levels
+}
+
+//Creates a filter with its default settings, nil for none
+func NewFilter(name string) (Filter, error) {
+	switch name {
+	case "none":
+		return nil, nil
+	case "blend":
+		return NewBlend(2), nil
+	case "phosphor":
+		return NewPhosphor(0.6), nil
+	case "display-wait":
+		return NewDisplayWait(), nil
+	}
+	return nil, fmt.Errorf("filter error: no filter named %q", name)
+}
+
+//Every dot fully on or off
+func DotLevels(dots *chip8.DotGrid) Levels {
+	result := newLevels(dots)
+	for y := range dots {
+		for x, isOn := range dots[y] {
+			if isOn {
+				result[y][x] = 1
+			}
+		}
+	}
+	return result
+}
+
+func newLevels(dots *chip8.DotGrid) Levels {
+	result := make(Levels, len(dots))
+	for y := range result {
+		result[y] = make([]float32, len(dots[y]))
+	}
+	return result
+}
+
+//Dots lit in any of the last frames stay lit, hides sprites erased and redrawn on different frames
+type Blend struct {
+	history []chip8.DotGrid
+	next    int
+}
+
+func NewBlend(frames int) *Blend {
+	if frames < 1 {
+		frames = 1
+	}
+	return &Blend{history: make([]chip8.DotGrid, 0, frames)}
+}
+
+func (blend *Blend) Apply(frame Frame) Levels {
+	if len(blend.history) < cap(blend.history) {
+		blend.history = append(blend.history, frame.Dots)
+	} else {
+		blend.history[blend.next] = frame.Dots
+		blend.next = (blend.next + 1) % len(blend.history)
+	}
+
+	result := newLevels(&frame.Dots)
+	for i := range blend.history {
+		for y, row := range blend.history[i] {
+			for x, isOn := range row {
+				if isOn {
+					result[y][x] = 1
+				}
+			}
+		}
+	}
+	return result
+}
+
+//Dots that turn off fade out like the phosphor of a CRT
+//Every frame a dot that is off keeps decay of its previous brightness
+type Phosphor struct {
+	decay  float32
+	levels Levels
+}
+
+//Below this a dot can't be told apart from off in 8 bit colour
+const phosphorCutoff = 1.0 / 256
+
+func NewPhosphor(decay float32) *Phosphor {
+	if decay < 0 {
+		decay = 0
+	} else if decay > 1 {
+		decay = 1
+	}
+	return &Phosphor{decay: decay}
+}
+
+func (phosphor *Phosphor) Apply(frame Frame) Levels {
+	if len(phosphor.levels) != len(frame.Dots) {
+		phosphor.levels = newLevels(&frame.Dots)
+	}
+
+	result := newLevels(&frame.Dots)
+	for y, row := range frame.Dots {
+		for x, isOn := range row {
+			level := phosphor.levels[y][x] * phosphor.decay
+			if isOn {
+				level = 1
+			} else if level < phosphorCutoff {
+				level = 0
+			}
+			phosphor.levels[y][x] = level
+			result[y][x] = level
+		}
+	}
+	return result
+}
+
+//Only presents frames in which a sprite was drawn, a CLS keeps showing the last drawn frame
+//until the screen is drawn again
+type DisplayWait struct {
+	levels Levels
+}
+
+func NewDisplayWait() *DisplayWait {
+	return &DisplayWait{}
+}
+
+func (wait *DisplayWait) Apply(frame Frame) Levels {
+	if frame.Drawn || wait.levels == nil {
+		wait.levels = DotLevels(&frame.Dots)
+	}
+	return wait.levels
+}
+
+//Colour between off and on for a brightness level
+func Mix(off, on color.Color, level float32) color.RGBA {
+	offR, offG, offB, offA := off.RGBA()
+	onR, onG, onB, onA := on.RGBA()
+	mix := func(a, b uint32) uint8 {
+		return uint8((float32(a) + (float32(b)-float32(a))*level) / 0x101)
+	}
+	return color.RGBA{mix(offR, onR), mix(offG, onG), mix(offB, onB), mix(offA, onA)}
+}
@@ -0,0 +1,108 @@
+package effects
+
+import (
+	"image/color"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Grid with the listed dots on row 0 lit
+func grid(columns ...int) chip8.DotGrid {
+	result := chip8.DotGrid{}
+	for _, column := range columns {
+		result[0][column] = true
+	}
+	return result
+}
+
+//Sprite at column 0 erased and redrawn at column 1 on the next frame, then left alone
+var moving = []Frame{
+	{grid(0), true},
+	{grid(), true},
+	{grid(1), true},
+	{grid(1), false},
+	{grid(1), false},
+}
+
+func TestBlend(t *testing.T) {
+	blend := NewBlend(2)
+	expected := [][2]float32{{1, 0}, {1, 0}, {0, 1}, {0, 1}, {0, 1}}
+	for i, frame := range moving {
+		levels := blend.Apply(frame)
+		if levels[0][0] != expected[i][0] || levels[0][1] != expected[i][1] {
+			t.Errorf("FAIL frame %v: %v (expected %v)", i, levels[0][:2], expected[i])
+		}
+	}
+}
+
+func TestPhosphor(t *testing.T) {
+	phosphor := NewPhosphor(0.5)
+	expected := [][2]float32{{1, 0}, {0.5, 0}, {0.25, 1}, {0.125, 1}, {0.0625, 1}}
+	for i, frame := range moving {
+		levels := phosphor.Apply(frame)
+		if levels[0][0] != expected[i][0] || levels[0][1] != expected[i][1] {
+			t.Errorf("FAIL frame %v: %v (expected %v)", i, levels[0][:2], expected[i])
+		}
+	}
+
+	//Dots fade out completely instead of staying faintly lit forever
+	var levels Levels
+	for i := 0; i < 10; i++ {
+		levels = phosphor.Apply(Frame{Dots: grid()})
+	}
+	if levels[0][0] != 0 {
+		t.Errorf("FAIL level=%v after fading (expected 0)", levels[0][0])
+	}
+}
+
+func TestDisplayWait(t *testing.T) {
+	wait := NewDisplayWait()
+	frames := []Frame{
+		{grid(0), true},
+		{grid(), false}, //CLS without a draw keeps the last drawn frame
+		{grid(1), true},
+		{grid(2), false},
+	}
+	expected := [][3]float32{{1, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 1, 0}}
+	for i, frame := range frames {
+		levels := wait.Apply(frame)
+		for column := range expected[i] {
+			if levels[0][column] != expected[i][column] {
+				t.Errorf("FAIL frame %v: %v (expected %v)", i, levels[0][:3], expected[i])
+				break
+			}
+		}
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	if filter, err := NewFilter("none"); filter != nil || err != nil {
+		t.Errorf("FAIL none=%v, %v (expected nil)", filter, err)
+	}
+	for _, name := range []string{"blend", "phosphor", "display-wait"} {
+		if filter, err := NewFilter(name); filter == nil || err != nil {
+			t.Errorf("FAIL %v=%v, %v", name, filter, err)
+		}
+	}
+	if _, err := NewFilter("scanlines"); err == nil {
+		t.Errorf("FAIL unknown filter was created")
+	}
+}
+
+func TestMix(t *testing.T) {
+	off, on := color.NRGBA{0x00, 0x20, 0xFF, 0xFF}, color.NRGBA{0xFF, 0x20, 0x00, 0xFF}
+	tests := []struct {
+		level    float32
+		expected color.RGBA
+	}{
+		{0, color.RGBA{0x00, 0x20, 0xFF, 0xFF}},
+		{1, color.RGBA{0xFF, 0x20, 0x00, 0xFF}},
+		{0.5, color.RGBA{0x7F, 0x20, 0x7F, 0xFF}},
+	}
+	for _, test := range tests {
+		if result := Mix(off, on, test.level); result != test.expected {
+			t.Errorf("FAIL level %v=%v (expected %v)", test.level, result, test.expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+//Tiny 3x5 bitmap font for the overlay, drawn on the CPU like the display so no font files are needed
+//Lower case is drawn as upper case and characters without a glyph as a box
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphAdvance = glyphWidth + 1
+	lineAdvance  = glyphHeight + 2
+)
+
+//Rows of 3 dots from the top
+var glyphs = map[rune]string{
+	'0': "111101101101111", '1': "010110010010111", '2': "110001010100111", '3': "110001010001110",
+	'4': "101101111001001", '5': "111100110001110", '6': "011100110101010", '7': "111001010010010",
+	'8': "111101111101111", '9': "010101011001110",
+	'A': "010101111101101", 'B': "110101110101110", 'C': "011100100100011", 'D': "110101101101110",
+	'E': "111100110100111", 'F': "111100110100100", 'G': "011100101101011", 'H': "101101111101101",
+	'I': "111010010010111", 'J': "001001001101010", 'K': "101101110101101", 'L': "100100100100111",
+	'M': "101111111101101", 'N': "110101101101101", 'O': "010101101101010", 'P': "110101110100100",
+	'Q': "010101101110011", 'R': "110101110101101", 'S': "011100010001110", 'T': "111010010010010",
+	'U': "101101101101111", 'V': "101101101101010", 'W': "101101111111101", 'X': "101101010101101",
+	'Y': "101101010010010", 'Z': "111001010100111",
+	' ': "000000000000000", '.': "000000000000010", ',': "000000000010100", ':': "000010000010000",
+	'-': "000000111000000", '+': "000010111010000", '/': "001001010100100", '%': "101001010100101",
+	'(': "001010010010001", ')': "100010010010100", '!': "010010010000010",
+	'[': "110100100100110", ']': "011001001001011", '_': "000000000000111", '*': "000101010101000",
+	'>': "100010001010100", '<': "001010100010001", '=': "000111000111000",
+}
+
+const unknownGlyph = "111101101101111"
+
+//Size of the lines drawn with dots of scale pixels
+func textSize(lines []string, scale int) image.Point {
+	width := 0
+	for _, line := range lines {
+		if length := len([]rune(line)); length > width {
+			width = length
+		}
+	}
+	if width == 0 {
+		return image.Point{}
+	}
+	return image.Pt(width*glyphAdvance-1, len(lines)*lineAdvance-2).Mul(scale)
+}
+
+//Draws text with its top left corner at origin
+func drawText(dst draw.Image, origin image.Point, text string, scale int, c color.Color) {
+	ink := image.NewUniform(c)
+	for i, character := range []rune(strings.ToUpper(text)) {
+		glyph, ok := glyphs[character]
+		if !ok {
+			glyph = unknownGlyph
+		}
+		for dot, value := range glyph {
+			if value != '1' {
+				continue
+			}
+			x := origin.X + (i*glyphAdvance+dot%glyphWidth)*scale
+			y := origin.Y + (dot/glyphWidth)*scale
+			draw.Draw(dst, image.Rect(x, y, x+scale, y+scale), ink, image.Point{}, draw.Over)
+		}
+	}
+}
+
+//Lines of text on a background with a margin of one dot
+func renderPanel(lines []string, scale int, background, foreground color.Color) *image.RGBA {
+	margin := image.Pt(scale, scale)
+	size := textSize(lines, scale).Add(margin.Mul(2))
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(result, result.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	for i, line := range lines {
+		drawText(result, margin.Add(image.Pt(0, i*lineAdvance*scale)), line, scale, foreground)
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"gioui.org/f32"
+)
+
+/*
+TEXT GRIDS
+Tool windows like the debugger lay out text with the overlay font in fixed cells
+Clicks are turned back into the cell under the pointer
+*/
+
+var (
+	gridBackground = color.NRGBA{0x10, 0x10, 0x18, 0xFF}
+	gridText       = color.NRGBA{0xE0, 0xE0, 0xE0, 0xFF}
+	gridButton     = color.NRGBA{0x50, 0x50, 0x50, 0xFF}
+)
+
+//Cells filled with a colour behind the text
+type cellHighlight struct {
+	cells image.Rectangle
+	color color.NRGBA
+}
+
+type textGrid struct {
+	cells      [][]rune
+	highlights []cellHighlight
+}
+
+func newTextGrid(columns, rows int) *textGrid {
+	grid := &textGrid{cells: make([][]rune, rows), highlights: []cellHighlight{}}
+	for row := range grid.cells {
+		grid.cells[row] = []rune(strings.Repeat(" ", columns))
+	}
+	return grid
+}
+
+//Text past the end of the row is cut off
+func (grid *textGrid) write(column, row int, text string) {
+	for i, character := range []rune(text) {
+		if column+i < len(grid.cells[row]) {
+			grid.cells[row][column+i] = character
+		}
+	}
+}
+
+func (grid *textGrid) highlight(column, row, width int, fill color.NRGBA) {
+	grid.highlights = append(grid.highlights, cellHighlight{image.Rect(column, row, column+width, row+1), fill})
+}
+
+func (grid *textGrid) lines() []string {
+	lines := make([]string, len(grid.cells))
+	for row := range grid.cells {
+		lines[row] = strings.TrimRight(string(grid.cells[row]), " ")
+	}
+	return lines
+}
+
+func truncate(text string, length int) string {
+	if runes := []rune(text); len(runes) > length {
+		return string(runes[:length])
+	}
+	return text
+}
+
+//Pixels per dot for the largest grid that fits the window
+func gridScale(windowSize image.Point, columns, rows int) int {
+	scale := windowSize.X / (columns * glyphAdvance)
+	if rowScale := windowSize.Y / (rows * lineAdvance); rowScale < scale {
+		scale = rowScale
+	}
+	if scale < 1 {
+		scale = 1
+	}
+	return scale
+}
+
+//Column and row of the cell at a pointer position
+func gridCell(position f32.Point, scale int) (int, int) {
+	return int(position.X) / (glyphAdvance * scale), int(position.Y) / (lineAdvance * scale)
+}
+
+func renderGrid(columns, rows int, lines []string, highlights []cellHighlight, scale int) *image.RGBA {
+	cell := image.Pt(glyphAdvance*scale, lineAdvance*scale)
+	result := image.NewRGBA(image.Rect(0, 0, columns*cell.X, rows*cell.Y))
+	draw.Draw(result, result.Bounds(), image.NewUniform(gridBackground), image.Point{}, draw.Src)
+
+	for _, highlight := range highlights {
+		area := image.Rect(highlight.cells.Min.X*cell.X, highlight.cells.Min.Y*cell.Y, highlight.cells.Max.X*cell.X, highlight.cells.Max.Y*cell.Y)
+		draw.Draw(result, area, image.NewUniform(highlight.color), image.Point{}, draw.Src)
+	}
+	for row, line := range lines {
+		drawText(result, image.Pt(scale, row*cell.Y+scale), line, scale, gridText)
+	}
+	return result
+}
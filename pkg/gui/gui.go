@@ -10,15 +10,24 @@ import (
 	"gioui.org/f32"
 	"gioui.org/io/event"
 	"gioui.org/io/key"
+	"gioui.org/io/pointer"
 	"gioui.org/io/system"
 	"gioui.org/layout"
 	"gioui.org/op"
+	"gioui.org/op/clip"
 	"gioui.org/op/paint"
 	"gioui.org/unit"
+	"gongaware.org/gChip8/pkg/cheats"
 	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/gui/effects"
+	"gongaware.org/gChip8/pkg/keymap"
 )
 
-const inputHz = 60.0
+//Colour of the bars around the display when it doesn't fill the window
+var letterboxColor = color.NRGBA{0x00, 0x00, 0x00, 0xFF}
+
+//Pixels each dot gets when the CRT filters run, their work doesn't grow with the window as the GPU scales the result
+const crtDotPixels = 8
 
 type GChipGUI struct {
 	window *app.Window
@@ -26,32 +35,63 @@ type GChipGUI struct {
 	currentFrame  image.Image
 	bufferedFrame image.Image
 	currentOps    *op.Ops
-	guiInput      chip8.Input
+	guiInput      chip8.Input //Keys held as last sent to the system
+	keyboardInput chip8.Input
+	keys          *keymap.Keymap
+	keypad        keypad
 
 	frameBuffered bool
 
+	palette    Palette
+	themeIndex int
+
+	filter      effects.Filter //nil shows every frame as it is
+	lastFrame   effects.Frame
+	lastLevels  effects.Levels
+	hasNewFrame bool //A frame came from the core since the last tick
+
+	scaleMode    ScaleMode
+	isFullscreen bool
+	scaledFrame  image.Image //currentFrame at the size it was last drawn
+	crt          effects.Transform
+
+	overlay       overlay
+	statusChannel <-chan chip8.Status
+	messages      <-chan string
+	snapshots     <-chan chip8.Snapshot
+	debugger      *debugger    //nil unless a debugger window is open
+	cheats        *cheatWindow //nil unless a cheat window is open
+
 	//channel to engine
-	inputChannel   chan<- chip8.Input
+	inputChannel   chan<- chip8.KeyEvent
+	commandChannel chan<- chip8.Command
 	displayChannel <-chan chip8.Display
 }
 
-func New(dispChan <-chan chip8.Display, inputChan chan<- chip8.Input) GChipGUI {
+func New(dispChan <-chan chip8.Display, inputChan chan<- chip8.KeyEvent, commandChan chan<- chip8.Command, keys *keymap.Keymap) GChipGUI {
 	result := GChipGUI{}
 	result.window = app.NewWindow(
 		app.Title("gChip8"),
-		app.Size(unit.Dp(500), unit.Dp(500)),
+		app.Size(unit.Dp(640), unit.Dp(320)),
+		app.MinSize(unit.Dp(64), unit.Dp(32)),
 	)
 
 	result.displayChannel = dispChan
 	result.inputChannel = inputChan
+	result.commandChannel = commandChan
+	result.keys = keys
 	result.currentOps = new(op.Ops)
-	result.currentFrame = &image.Uniform{color.Black}
+	result.palette = defaultPalette
+	result.lastLevels = effects.DotLevels(&result.lastFrame.Dots)
+	result.currentFrame = CreateImageFromLevels(result.lastLevels, result.palette)
 
 	return result
 }
 
 func (gui *GChipGUI) Run() error {
-	inputFrameTicker := time.NewTicker(time.Second / inputHz)
+	filterTicker := time.NewTicker(time.Second / 60)
+	defer filterTicker.Stop()
+
 	for {
 		select {
 		case event := <-gui.window.Events():
@@ -60,11 +100,34 @@ func (gui *GChipGUI) Run() error {
 				return err
 			}
 		case display := <-gui.displayChannel:
-			gui.bufferedFrame = CreateImageFromDisplay(&display)
-			gui.frameBuffered = true
-			gui.window.Invalidate()
-		case <-inputFrameTicker.C:
-			gui.inputChannel <- gui.guiInput
+			gui.present(effects.Frame{Dots: display.ToBoolArray(), Drawn: display.HasDrawn()})
+			gui.hasNewFrame = true
+		case status := <-gui.statusChannel:
+			gui.overlay.observeStatus(status, time.Now())
+		case message := <-gui.messages:
+			gui.toast(message)
+		case event := <-gui.debugger.windowEvents():
+			if !gui.debugger.handleWindowEvent(event) {
+				gui.debugger = nil
+			}
+		case event := <-gui.cheats.windowEvents():
+			if !gui.cheats.handleWindowEvent(event) {
+				gui.cheats = nil
+			}
+		case snapshot := <-gui.snapshotChannel():
+			if gui.debugger != nil {
+				gui.debugger.observe(snapshot)
+			}
+			if gui.cheats != nil {
+				gui.cheats.observe(snapshot)
+			}
+		case now := <-filterTicker.C:
+			if gui.filter != nil && !gui.hasNewFrame {
+				gui.present(effects.Frame{Dots: gui.lastFrame.Dots})
+			} else if gui.overlay.isActive(now) {
+				gui.window.Invalidate()
+			}
+			gui.hasNewFrame = false
 		}
 	}
 }
@@ -78,17 +141,245 @@ func (gui *GChipGUI) handleWindowEvent(event event.Event) error {
 
 		if gui.frameBuffered {
 			gui.currentFrame = gui.bufferedFrame
+			gui.scaledFrame = nil
 			gui.frameBuffered = false
 		}
-
-		imageOp := paint.NewImageOp(gui.currentFrame)
-		imageOp.Add(gtx.Ops)
-		op.Affine(f32.Affine2D{}.Scale(f32.Pt(0, 0), f32.Pt(4, 4)))
-		paint.PaintOp{}.Add(gtx.Ops)
+		displayArea := image.Rectangle{Max: event.Size}
+		if gui.keypad.isVisible {
+			var keypadArea image.Rectangle
+			displayArea, keypadArea = keypadAreas(event.Size)
+			for _, pointerEvent := range gtx.Events(&gui.keypad) {
+				if pointerEvent, ok := pointerEvent.(pointer.Event); ok {
+					gui.keypad.handlePointer(pointerEvent, keypadArea.Size())
+				}
+			}
+			gui.syncInput()
+			gui.drawFrame(gtx.Ops, displayArea)
+			gui.keypad.draw(gtx.Ops, keypadArea, gui.guiInput)
+		} else {
+			gui.drawFrame(gtx.Ops, displayArea)
+		}
+		gui.overlay.countFrame(event.Now)
+		gui.overlay.draw(gtx.Ops, event.Size, event.Now)
 
 		event.Frame(gtx.Ops)
 	case key.Event:
-		handleKeys(event, &gui.guiInput)
+		if _, changed := handleKeys(event, gui.keys, &gui.keyboardInput); changed {
+			gui.syncInput()
+		} else if action, ok := handleActions(event, gui.keys); ok {
+			gui.handleAction(action)
+		}
 	}
 	return nil
 }
+
+//Sends the keys that changed since the last call, keys held on the keyboard or keypad are combined
+func (gui *GChipGUI) syncInput() {
+	held := gui.keyboardInput | gui.keypad.input
+	for _, keyEvent := range inputChanges(gui.guiInput, held) {
+		gui.inputChannel <- keyEvent
+	}
+	if held != gui.guiInput {
+		gui.guiInput = held
+		gui.window.Invalidate() //Highlight the keys on the keypad
+	}
+}
+
+func inputChanges(from, to chip8.Input) []chip8.KeyEvent {
+	changes := []chip8.KeyEvent{}
+	for key := byte(0); key <= 0xF; key++ {
+		if from.IsPressed(key) != to.IsPressed(key) {
+			changes = append(changes, chip8.KeyEvent{Key: key, Pressed: to.IsPressed(key)})
+		}
+	}
+	return changes
+}
+
+//Draws the current frame scaled to the display area with the rest letterboxed
+//Without CRT filters it is scaled to the area here so the dots stay sharp, with them the GPU scales the filtered frame
+func (gui *GChipGUI) drawFrame(ops *op.Ops, displayArea image.Rectangle) {
+	paint.Fill(ops, letterboxColor)
+
+	area := ScaleRect(gui.scaleMode, gui.currentFrame.Bounds().Size(), displayArea.Size()).Add(displayArea.Min)
+	if area.Empty() {
+		return
+	}
+	size := area.Size()
+	if gui.crt != nil {
+		size = gui.currentFrame.Bounds().Size().Mul(crtDotPixels)
+	}
+	if gui.scaledFrame == nil || gui.scaledFrame.Bounds().Size() != size {
+		gui.scaledFrame = ScaleImage(gui.currentFrame, size)
+		if gui.crt != nil {
+			gui.scaledFrame = gui.crt(gui.scaledFrame)
+		}
+	}
+
+	defer op.Offset(f32.Pt(float32(area.Min.X), float32(area.Min.Y))).Push(ops).Pop()
+	if size != area.Size() {
+		factor := f32.Pt(float32(area.Dx())/float32(size.X), float32(area.Dy())/float32(size.Y))
+		defer op.Affine(f32.Affine2D{}.Scale(f32.Point{}, factor)).Push(ops).Pop()
+	}
+	defer clip.Rect(image.Rectangle{Max: size}).Push(ops).Pop()
+	paint.NewImageOp(gui.scaledFrame).Add(ops)
+	paint.PaintOp{}.Add(ops)
+}
+
+func (gui *GChipGUI) handleAction(action keymap.Action) {
+	if command, ok := actionCommands[action]; ok {
+		gui.commandChannel <- command
+		gui.toast(commandToast(command, &gui.overlay.status))
+		return
+	}
+
+	switch action {
+	case keymap.ActionCycleTheme:
+		gui.SetTheme((gui.themeIndex + 1) % len(Themes))
+		gui.toast("theme " + Themes[gui.themeIndex].Name)
+	case keymap.ActionCycleScale:
+		gui.SetScaleMode((gui.scaleMode + 1) % ScaleMode(len(scaleModeNames)))
+		gui.toast("scale " + gui.scaleMode.String())
+	case keymap.ActionFullscreen:
+		gui.SetFullscreen(!gui.isFullscreen)
+	case keymap.ActionKeypad:
+		gui.SetKeypad(!gui.keypad.isVisible)
+	case keymap.ActionOverlay:
+		gui.overlay.isVisible = !gui.overlay.isVisible
+		gui.window.Invalidate()
+	}
+}
+
+func (gui *GChipGUI) SetKeypad(visible bool) {
+	gui.keypad.isVisible = visible
+	if !visible {
+		gui.keypad.pointers, gui.keypad.input = nil, 0
+		gui.syncInput()
+	}
+	gui.window.Invalidate()
+}
+
+//Status updates from the system for the debug overlay
+func (gui *GChipGUI) SetStatusChannel(statusChannel <-chan chip8.Status) {
+	gui.statusChannel = statusChannel
+}
+
+//Messages from outside the system, like achievement unlocks, shown as toasts
+func (gui *GChipGUI) SetMessageChannel(messages <-chan string) {
+	gui.messages = messages
+}
+
+//Opens a second window with disassembly, registers, the stack and memory, symbols can be nil
+//The channels come from the system's DebugChannels
+func (gui *GChipGUI) OpenDebugger(snapshots <-chan chip8.Snapshot, events chan<- chip8.DebugEvent, symbols chip8.Symbols) {
+	gui.snapshots = snapshots
+	gui.debugger = newDebugger(events, gui.commandChannel, symbols)
+	gui.debugger.open()
+}
+
+//Opens a window to search memory and turn cheats on and off
+//Snapshots come from the system's DebugChannels and cheats go to its CheatChannel
+//The rom's cheats are saved to filename after every change, file can be nil to not save them
+func (gui *GChipGUI) OpenCheats(snapshots <-chan chip8.Snapshot, channel chan<- []chip8.Cheat, file *cheats.File, filename, romHash string) {
+	gui.snapshots = snapshots
+	gui.cheats = newCheatWindow(channel, file, filename, romHash)
+	gui.cheats.open()
+}
+
+//nil once every window that uses snapshots is closed
+func (gui *GChipGUI) snapshotChannel() <-chan chip8.Snapshot {
+	if gui.debugger == nil && gui.cheats == nil {
+		return nil
+	}
+	return gui.snapshots
+}
+
+//Shows a message for a moment
+func (gui *GChipGUI) toast(text string) {
+	gui.overlay.addToast(text, time.Now())
+	gui.window.Invalidate()
+}
+
+//Message for a command, status is from before the system carried it out
+func commandToast(command chip8.Command, status *chip8.Status) string {
+	switch command {
+	case chip8.CommandPause:
+		if status.IsPaused {
+			return "resumed"
+		}
+		return "paused"
+	case chip8.CommandReset:
+		return "reset"
+	case chip8.CommandSaveState:
+		return "state saved"
+	case chip8.CommandLoadState:
+		if !status.HasSavedState {
+			return "no saved state"
+		}
+		return "state loaded"
+	case chip8.CommandSpeedUp:
+		if status.Speed > 1 {
+			return "normal speed"
+		}
+		return "speed up"
+	}
+	return fmt.Sprint(command)
+}
+
+func (gui *GChipGUI) SetScaleMode(mode ScaleMode) {
+	gui.scaleMode = mode
+	gui.scaledFrame = nil
+	gui.window.Invalidate()
+}
+
+func (gui *GChipGUI) SetFullscreen(fullscreen bool) {
+	gui.isFullscreen = fullscreen
+	if fullscreen {
+		gui.window.Option(app.Fullscreen.Option())
+	} else {
+		gui.window.Option(app.Windowed.Option())
+	}
+}
+
+//Use a palette that is not one of the built in themes, such as colours from the rom database
+func (gui *GChipGUI) SetPalette(palette Palette) {
+	gui.palette = palette
+	gui.redraw()
+}
+
+func (gui *GChipGUI) SetTheme(index int) {
+	gui.themeIndex = index
+	gui.SetPalette(Themes[index].Palette)
+}
+
+//Anti-flicker filter for the frames from the core, nil for none
+func (gui *GChipGUI) SetFilter(filter effects.Filter) {
+	gui.filter = filter
+}
+
+//CRT post-processing of the frame scaled up by crtDotPixels, nil turns it off
+func (gui *GChipGUI) SetCRT(config *effects.CRTConfig) {
+	gui.crt = nil
+	if config != nil {
+		gui.crt = config.Transform(gui.currentFrame.Bounds().Size())
+	}
+	gui.scaledFrame = nil
+	gui.window.Invalidate()
+}
+
+//Runs a frame through the filter and shows the result
+func (gui *GChipGUI) present(frame effects.Frame) {
+	gui.lastFrame = frame
+	if gui.filter != nil {
+		gui.lastLevels = gui.filter.Apply(frame)
+	} else {
+		gui.lastLevels = effects.DotLevels(&frame.Dots)
+	}
+	gui.redraw()
+}
+
+//Renders the last levels again, needed when anything other than the display changes
+func (gui *GChipGUI) redraw() {
+	gui.bufferedFrame = CreateImageFromLevels(gui.lastLevels, gui.palette)
+	gui.frameBuffered = true
+	gui.window.Invalidate()
+}
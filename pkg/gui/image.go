@@ -2,58 +2,38 @@ package gui
 
 import (
 	"image"
-	"image/color"
-	"image/draw"
 
 	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/gui/effects"
 )
 
-const (
-	defaultScale = 5
-)
-
-//Default color definitions
-var defaultOnColor color.Color = color.White
-var defaultOffColor color.Color = color.Black
+//Default theme is classic white on black
+var defaultPalette = Themes[0].Palette
 
-//pixel mask
-type pixelMask struct {
-	dots chip8.DotGrid
-
-	scale int
+//One pixel per dot, scaling to the window happens when the frame is drawn
+func CreateImageFromDisplay(display *chip8.Display, palette Palette) *image.RGBA {
+	dots := display.ToBoolArray()
+	return CreateImageFromLevels(effects.DotLevels(&dots), palette)
 }
 
-func (mask pixelMask) At(x, y int) color.Color {
-	if mask.dots[y/mask.scale][x/mask.scale] {
-		return color.Transparent
-	} else {
-		return color.Opaque
+//Dots between fully off and on, as left by the anti-flicker filters, mix the first two palette colours
+func CreateImageFromLevels(levels effects.Levels, palette Palette) *image.RGBA {
+	if len(palette) == 0 {
+		palette = defaultPalette
 	}
-}
 
-func (mask pixelMask) ColorModel() color.Model {
-	return color.AlphaModel
-}
-
-func (mask pixelMask) Bounds() image.Rectangle {
-	return image.Rect(0, 0, len(mask.dots[0])*mask.scale, len(mask.dots)*mask.scale)
-}
-
-func CreateImageFromDisplay(display *chip8.Display) *image.RGBA {
-	//these can be replaced later as arguments for more custom images
-	onImage, offImage := image.Uniform{defaultOnColor}, image.Uniform{defaultOffColor}
-	scale := defaultScale
-
-	//Create bounds for image and multiply by scale
-	x, y := display.GetSize()
-
-	//Initialize display as all off
-	result := image.NewRGBA(image.Rect(0, 0, x*scale, y*scale))
-	draw.Draw(result, result.Bounds(), &onImage, image.Point{}, draw.Src)
-
-	mask := pixelMask{display.ToBoolArray(), scale}
+	height, width := len(levels), 0
+	if height > 0 {
+		width = len(levels[0])
+	}
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
 
-	draw.DrawMask(result, result.Bounds(), &offImage, image.Point{}, mask, image.Point{}, draw.Src)
+	off, on := palette.At(0), palette.At(1)
+	for row := range levels {
+		for column, level := range levels[row] {
+			result.SetRGBA(column, row, effects.Mix(off, on, level))
+		}
+	}
 
 	return result
 }
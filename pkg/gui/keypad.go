@@ -0,0 +1,127 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+//Key positions of the COSMAC VIP keypad
+var keypadLayout = [4][4]byte{
+	{0x1, 0x2, 0x3, 0xC},
+	{0x4, 0x5, 0x6, 0xD},
+	{0x7, 0x8, 0x9, 0xE},
+	{0xA, 0x0, 0xB, 0xF},
+}
+
+var (
+	keypadBackground = color.NRGBA{0x20, 0x20, 0x20, 0xFF}
+	keypadKey        = color.NRGBA{0x50, 0x50, 0x50, 0xFF}
+	keypadHeld       = color.NRGBA{0xE0, 0x80, 0x20, 0xFF}
+	keypadLabel      = color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+//On screen keypad beside the display for pointer and touch input
+type keypad struct {
+	isVisible bool
+	pointers  map[pointer.ID]byte //Key under each pointer that is pressed
+	input     chip8.Input         //Keys held by pointers
+}
+
+//Splits the window into the display area and a square keypad on the right
+func keypadAreas(windowSize image.Point) (display, keys image.Rectangle) {
+	size := windowSize.X / 2
+	if windowSize.Y < size {
+		size = windowSize.Y
+	}
+	top := (windowSize.Y - size) / 2
+	keys = image.Rect(windowSize.X-size, top, windowSize.X, top+size)
+	return image.Rect(0, 0, windowSize.X-size, windowSize.Y), keys
+}
+
+//Key at a point in a keypad of the given size
+func keypadKeyAt(size image.Point, at image.Point) (byte, bool) {
+	if !at.In(image.Rectangle{Max: size}) {
+		return 0, false
+	}
+	return keypadLayout[at.Y*4/size.Y][at.X*4/size.X], true
+}
+
+func (keypad *keypad) handlePointer(event pointer.Event, size image.Point) {
+	if keypad.pointers == nil {
+		keypad.pointers = map[pointer.ID]byte{}
+	}
+
+	at := image.Pt(int(event.Position.X), int(event.Position.Y))
+	switch event.Type {
+	case pointer.Press, pointer.Drag:
+		_, isPressed := keypad.pointers[event.PointerID]
+		if event.Type == pointer.Drag && !isPressed {
+			break
+		}
+		//Sliding off the keypad lets go of the key
+		if key, ok := keypadKeyAt(size, at); ok {
+			keypad.pointers[event.PointerID] = key
+		} else {
+			delete(keypad.pointers, event.PointerID)
+		}
+	case pointer.Release, pointer.Cancel:
+		delete(keypad.pointers, event.PointerID)
+	}
+
+	keypad.input = 0
+	for _, key := range keypad.pointers {
+		keypad.input.PressKey(key)
+	}
+}
+
+//Draws the keypad into area and registers it for pointer events, held keys are highlighted
+func (keypad *keypad) draw(ops *op.Ops, area image.Rectangle, held chip8.Input) {
+	if area.Empty() {
+		return
+	}
+	defer op.Offset(f32.Pt(float32(area.Min.X), float32(area.Min.Y))).Push(ops).Pop()
+	defer clip.Rect(image.Rectangle{Max: area.Size()}).Push(ops).Pop()
+	paint.NewImageOp(renderKeypad(area.Size(), held)).Add(ops)
+	paint.PaintOp{}.Add(ops)
+
+	pointer.InputOp{
+		Tag:   keypad,
+		Types: pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel,
+		Grab:  true, //Keeps the events of a press that moves off the keypad
+	}.Add(ops)
+}
+
+func renderKeypad(size image.Point, held chip8.Input) *image.RGBA {
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(result, result.Bounds(), image.NewUniform(keypadBackground), image.Point{}, draw.Src)
+
+	gap := size.X / 40
+	scale := size.Y / 40
+	if scale < 1 {
+		scale = 1
+	}
+	for row := range keypadLayout {
+		for column, key := range keypadLayout[row] {
+			cell := image.Rect(column*size.X/4, row*size.Y/4, (column+1)*size.X/4, (row+1)*size.Y/4).Inset(gap)
+			fill := keypadKey
+			if held.IsPressed(key) {
+				fill = keypadHeld
+			}
+			draw.Draw(result, cell, image.NewUniform(fill), image.Point{}, draw.Src)
+
+			label := fmt.Sprintf("%X", key)
+			labelSize := textSize([]string{label}, scale)
+			drawText(result, cell.Min.Add(cell.Size().Sub(labelSize).Div(2)), label, scale, keypadLabel)
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package gui
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+func TestKeypadAreas(t *testing.T) {
+	display, keys := keypadAreas(image.Pt(900, 400))
+	if display != image.Rect(0, 0, 500, 400) || keys != image.Rect(500, 0, 900, 400) {
+		t.Errorf("FAIL display=%v keys=%v", display, keys)
+	}
+
+	//Narrow windows give the keypad half the width
+	display, keys = keypadAreas(image.Pt(400, 400))
+	if display != image.Rect(0, 0, 200, 400) || keys != image.Rect(200, 100, 400, 300) {
+		t.Errorf("FAIL display=%v keys=%v", display, keys)
+	}
+}
+
+func TestKeypadKeyAt(t *testing.T) {
+	size := image.Pt(400, 400)
+	tests := []struct {
+		at       image.Point
+		expected byte
+	}{
+		{image.Pt(0, 0), 0x1},
+		{image.Pt(399, 0), 0xC},
+		{image.Pt(150, 150), 0x5},
+		{image.Pt(150, 399), 0x0},
+		{image.Pt(399, 399), 0xF},
+	}
+	for _, test := range tests {
+		if key, ok := keypadKeyAt(size, test.at); !ok || key != test.expected {
+			t.Errorf("FAIL key at %v=%X (expected %X)", test.at, key, test.expected)
+		}
+	}
+	if _, ok := keypadKeyAt(size, image.Pt(400, 0)); ok {
+		t.Errorf("FAIL found a key outside the keypad")
+	}
+}
+
+func TestKeypadPointers(t *testing.T) {
+	size := image.Pt(400, 400)
+	keypad := keypad{}
+	send := func(id pointer.ID, eventType pointer.Type, x, y float32) {
+		keypad.handlePointer(pointer.Event{Type: eventType, PointerID: id, Position: f32.Pt(x, y)}, size)
+	}
+
+	send(1, pointer.Press, 150, 150) //5
+	send(2, pointer.Press, 350, 350) //F
+	if !keypad.input.IsPressed(0x5) || !keypad.input.IsPressed(0xF) {
+		t.Errorf("FAIL input=%016b (expected 5 and F)", keypad.input)
+	}
+
+	send(1, pointer.Drag, 250, 150) //Slides onto 6
+	send(2, pointer.Release, 350, 350)
+	if keypad.input != chip8.Input(1<<0x6) {
+		t.Errorf("FAIL input=%016b (expected only 6)", keypad.input)
+	}
+
+	send(3, pointer.Drag, 50, 50) //Hovering doesn't press
+	send(1, pointer.Drag, 500, 150)
+	if keypad.input != 0 {
+		t.Errorf("FAIL input=%016b (expected nothing after sliding off)", keypad.input)
+	}
+}
+
+func TestInputChanges(t *testing.T) {
+	from, to := chip8.Input(1<<0x1|1<<0x2), chip8.Input(1<<0x2|1<<0xA)
+	changes := inputChanges(from, to)
+	expected := []chip8.KeyEvent{{Key: 0x1, Pressed: false}, {Key: 0xA, Pressed: true}}
+	if len(changes) != len(expected) || changes[0] != expected[0] || changes[1] != expected[1] {
+		t.Errorf("FAIL changes=%v (expected %v)", changes, expected)
+	}
+}
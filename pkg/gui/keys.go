@@ -3,35 +3,35 @@ package gui
 import (
 	"gioui.org/io/key"
 	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/keymap"
 )
 
-var keymap = map[string]byte{
-	"X": 0x0,
-	"1": 0x1,
-	"2": 0x2,
-	"3": 0x3,
-	"Q": 0x4,
-	"W": 0x5,
-	"E": 0x6,
-	"A": 0x7,
-	"S": 0x8,
-	"D": 0x9,
-	"Z": 0xa,
-	"C": 0xb,
-	"4": 0xc,
-	"R": 0xd,
-	"F": 0xe,
-	"V": 0xf,
+//System commands for the emulator actions a keymap can bind
+var actionCommands = map[keymap.Action]chip8.Command{
+	keymap.ActionPause:     chip8.CommandPause,
+	keymap.ActionReset:     chip8.CommandReset,
+	keymap.ActionSaveState: chip8.CommandSaveState,
+	keymap.ActionLoadState: chip8.CommandLoadState,
+	keymap.ActionSpeedUp:   chip8.CommandSpeedUp,
 }
 
-func handleKeys(event key.Event, input *chip8.Input) {
-	eventKey, ok := keymap[event.Name]
-	if ok {
-		switch event.State {
-		case key.Press:
-			input.PressKey(eventKey)
-		case key.Release:
-			input.ReleaseKey(eventKey)
-		}
+//Converts a window key event into a chip8 key event, repeated presses are ignored
+func handleKeys(event key.Event, keys *keymap.Keymap, input *chip8.Input) (chip8.KeyEvent, bool) {
+	eventKey, ok := keys.Key(event.Name)
+	if !ok {
+		return chip8.KeyEvent{}, false
 	}
+
+	keyEvent := chip8.KeyEvent{Key: eventKey, Pressed: event.State == key.Press}
+	previous := *input
+	input.Apply(keyEvent)
+	return keyEvent, *input != previous
+}
+
+//Actions fire on press only
+func handleActions(event key.Event, keys *keymap.Keymap) (keymap.Action, bool) {
+	if event.State != key.Press {
+		return "", false
+	}
+	return keys.Action(event.Name)
 }
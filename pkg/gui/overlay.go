@@ -0,0 +1,173 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+const (
+	toastDuration = 2 * time.Second
+	rateInterval  = time.Second //How long FPS and instructions per second are averaged over
+)
+
+var (
+	overlayBackground = color.NRGBA{0x00, 0x00, 0x00, 0xB0}
+	overlayText       = color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}
+)
+
+//Emulator state drawn over the display while playtesting, toasts show even while it is hidden
+type overlay struct {
+	isVisible bool
+
+	status    chip8.Status
+	hasStatus bool
+
+	frames     int
+	framesFrom time.Time
+	fps        float64
+
+	cycles     uint64
+	cyclesFrom time.Time
+	ips        float64 //Instructions per second
+
+	toasts []toast
+}
+
+type toast struct {
+	text  string
+	until time.Time
+}
+
+//Needs redrawing every tick so the numbers and toasts stay current
+func (overlay *overlay) isActive(now time.Time) bool {
+	return overlay.isVisible || len(overlay.activeToasts(now)) > 0
+}
+
+func (overlay *overlay) observeStatus(status chip8.Status, now time.Time) {
+	if !overlay.hasStatus || status.Cycles < overlay.cycles {
+		overlay.cycles, overlay.cyclesFrom = status.Cycles, now
+	} else if elapsed := now.Sub(overlay.cyclesFrom); elapsed >= rateInterval {
+		overlay.ips = float64(status.Cycles-overlay.cycles) / elapsed.Seconds()
+		overlay.cycles, overlay.cyclesFrom = status.Cycles, now
+	}
+	overlay.status, overlay.hasStatus = status, true
+}
+
+//Called for every window frame
+func (overlay *overlay) countFrame(now time.Time) {
+	if overlay.framesFrom.IsZero() {
+		overlay.framesFrom = now
+	}
+	overlay.frames++
+	if elapsed := now.Sub(overlay.framesFrom); elapsed >= rateInterval {
+		overlay.fps = float64(overlay.frames) / elapsed.Seconds()
+		overlay.frames, overlay.framesFrom = 0, now
+	}
+}
+
+func (overlay *overlay) addToast(text string, now time.Time) {
+	overlay.toasts = append(overlay.activeToasts(now), toast{text, now.Add(toastDuration)})
+}
+
+func (overlay *overlay) activeToasts(now time.Time) []toast {
+	active := overlay.toasts[:0]
+	for _, toast := range overlay.toasts {
+		if now.Before(toast.until) {
+			active = append(active, toast)
+		}
+	}
+	overlay.toasts = active
+	return active
+}
+
+//Status in the top left corner and toasts in the bottom left
+func (overlay *overlay) draw(ops *op.Ops, windowSize image.Point, now time.Time) {
+	scale := overlayScale(windowSize)
+	if overlay.isVisible {
+		lines := []string{"waiting for the system"}
+		if overlay.hasStatus {
+			lines = overlayLines(&overlay.status, overlay.fps, overlay.ips)
+		}
+		drawPanel(ops, lines, scale, func(size image.Point) image.Point { return image.Point{} })
+	}
+
+	if toasts := overlay.activeToasts(now); len(toasts) > 0 {
+		lines := make([]string, len(toasts))
+		for i, toast := range toasts {
+			lines[i] = toast.text
+		}
+		drawPanel(ops, lines, scale, func(size image.Point) image.Point { return image.Pt(0, windowSize.Y-size.Y) })
+	}
+}
+
+//Text grows with the window so it stays readable fullscreen
+func overlayScale(windowSize image.Point) int {
+	scale := windowSize.Y / 200
+	if scale < 1 {
+		scale = 1
+	}
+	return scale
+}
+
+//position gets the size of the panel and returns where its top left corner goes
+func drawPanel(ops *op.Ops, lines []string, scale int, position func(size image.Point) image.Point) {
+	panel := renderPanel(lines, scale, overlayBackground, overlayText)
+	size := panel.Bounds().Size()
+	at := position(size)
+
+	defer op.Offset(f32.Pt(float32(at.X), float32(at.Y))).Push(ops).Pop()
+	defer clip.Rect(image.Rectangle{Max: size}).Push(ops).Pop()
+	paint.NewImageOp(panel).Add(ops)
+	paint.PaintOp{}.Add(ops)
+}
+
+func overlayLines(status *chip8.Status, fps, ips float64) []string {
+	rate := fmt.Sprintf("IPS %.0f", ips)
+	if status.Frequency > 0 {
+		rate += fmt.Sprintf("/%.0f (%.0f%%)", status.Frequency, 100*ips/status.Frequency)
+	}
+	lines := []string{
+		fmt.Sprintf("FPS %.1f", fps),
+		rate,
+		fmt.Sprintf("PC %.3X I %.3X SP %X DT %.2X ST %.2X", uint16(status.PC), uint16(status.I), status.SP, status.Delay, status.Sound),
+	}
+
+	for start := 0; start < len(status.Registers); start += 8 {
+		registers := make([]string, 8)
+		for i := range registers {
+			registers[i] = fmt.Sprintf("V%X %.2X", start+i, status.Registers[start+i])
+		}
+		lines = append(lines, strings.Join(registers, " "))
+	}
+
+	keys := []string{}
+	for key := byte(0); key <= 0xF; key++ {
+		if status.Input.IsPressed(key) {
+			keys = append(keys, fmt.Sprintf("%X", key))
+		}
+	}
+	if len(keys) == 0 {
+		keys = append(keys, "-")
+	}
+	lines = append(lines, "Keys "+strings.Join(keys, " "))
+
+	if status.WaitingForKey {
+		lines = append(lines, "WAITING FOR KEY")
+	}
+	if status.Speed > 1 {
+		lines = append(lines, fmt.Sprintf("SPEED x%d", status.Speed))
+	}
+	if status.IsPaused {
+		lines = append(lines, "PAUSED")
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+func TestOverlayLines(t *testing.T) {
+	status := chip8.Status{Frequency: 600, Speed: 4, IsPaused: true}
+	status.PC, status.I, status.SP, status.Delay = 0x20A, 0x300, 1, 0x3C
+	status.Registers[0xF] = 0x01
+	status.Input.PressKey(0x5)
+	status.Input.PressKey(0xB)
+
+	expected := []string{
+		"FPS 59.9",
+		"IPS 300/600 (50%)",
+		"PC 20A I 300 SP 1 DT 3C ST 00",
+		"V0 00 V1 00 V2 00 V3 00 V4 00 V5 00 V6 00 V7 00",
+		"V8 00 V9 00 VA 00 VB 00 VC 00 VD 00 VE 00 VF 01",
+		"Keys 5 B",
+		"SPEED x4",
+		"PAUSED",
+	}
+	lines := overlayLines(&status, 59.94, 300)
+	if len(lines) != len(expected) {
+		t.Fatalf("FAIL lines=%q (expected %q)", lines, expected)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("FAIL line %v=%q (expected %q)", i, lines[i], expected[i])
+		}
+	}
+}
+
+func TestOverlayLinesWithoutFrequency(t *testing.T) {
+	if lines := overlayLines(&chip8.Status{}, 60, 3000); lines[1] != "IPS 3000" {
+		t.Errorf("FAIL line 1=%q without a frequency (expected no target)", lines[1])
+	}
+}
+
+func TestOverlayRates(t *testing.T) {
+	overlay := overlay{}
+	start := time.Unix(0, 0)
+	for i := 0; i <= 60; i++ {
+		now := start.Add(time.Duration(i) * time.Second / 60)
+		overlay.countFrame(now)
+		overlay.observeStatus(chip8.Status{Cycles: uint64(i * 10)}, now)
+	}
+	if overlay.fps < 59 || overlay.fps > 61 || overlay.ips < 590 || overlay.ips > 610 {
+		t.Errorf("FAIL fps=%v ips=%v (expected 60 and 600)", overlay.fps, overlay.ips)
+	}
+}
+
+func TestToasts(t *testing.T) {
+	overlay := overlay{}
+	start := time.Unix(0, 0)
+	overlay.addToast("state saved", start)
+	overlay.addToast("paused", start.Add(toastDuration/2))
+
+	if toasts := overlay.activeToasts(start.Add(toastDuration)); len(toasts) != 1 || toasts[0].text != "paused" {
+		t.Errorf("FAIL toasts=%v (expected only paused)", toasts)
+	}
+	if overlay.isActive(start.Add(2 * toastDuration)) {
+		t.Errorf("FAIL overlay still active after the toasts expired")
+	}
+}
+
+func TestRenderPanel(t *testing.T) {
+	on, off := color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF}, color.NRGBA{0x00, 0x00, 0x00, 0xFF}
+	panel := renderPanel([]string{"1", "ab"}, 2, off, on)
+
+	//Two glyphs wide and two lines high with a margin of one dot
+	if size := panel.Bounds().Size(); size != image.Pt((2*glyphAdvance-1+2)*2, (2*lineAdvance-2+2)*2) {
+		t.Fatalf("FAIL size=%v", size)
+	}
+	//Top of the 1 is the middle dot
+	if panel.At(2, 2) != color.RGBA(off) || panel.At(2+2, 2) != color.RGBA(on) {
+		t.Errorf("FAIL top row of 1 is wrong")
+	}
+	//Unknown characters are boxes
+	if textSize([]string{"~"}, 1) != image.Pt(glyphWidth, glyphHeight) {
+		t.Errorf("FAIL size of one glyph=%v", textSize([]string{"~"}, 1))
+	}
+}
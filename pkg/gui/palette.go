@@ -0,0 +1,76 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+)
+
+//Colours indexed by the value of a pixel's bitplanes
+//Index 0 is the background, 2 colours cover one plane, 4 colours two planes and 16 colours four planes (XO-CHIP)
+type Palette []color.Color
+
+//Colour for a pixel, values past the end of the palette wrap so small palettes still work for more planes
+func (palette Palette) At(value int) color.Color {
+	return palette[value%len(palette)]
+}
+
+type Theme struct {
+	Name    string
+	Palette Palette
+}
+
+//Built in themes in the order they are cycled through
+//Every theme has 4 colours, background, plane 1, plane 2 and both planes
+var Themes = []Theme{
+	{"classic", Palette{
+		color.NRGBA{0x00, 0x00, 0x00, 0xFF}, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF},
+		color.NRGBA{0xAA, 0xAA, 0xAA, 0xFF}, color.NRGBA{0x55, 0x55, 0x55, 0xFF},
+	}},
+	{"green", Palette{ //Green phosphor
+		color.NRGBA{0x0A, 0x14, 0x0A, 0xFF}, color.NRGBA{0x33, 0xFF, 0x66, 0xFF},
+		color.NRGBA{0x1A, 0x99, 0x3D, 0xFF}, color.NRGBA{0x99, 0xFF, 0xB3, 0xFF},
+	}},
+	{"amber", Palette{ //Amber phosphor
+		color.NRGBA{0x14, 0x0C, 0x00, 0xFF}, color.NRGBA{0xFF, 0xB0, 0x00, 0xFF},
+		color.NRGBA{0x99, 0x66, 0x00, 0xFF}, color.NRGBA{0xFF, 0xD8, 0x80, 0xFF},
+	}},
+	{"lcd", Palette{ //Reflective green LCD, dark pixels on a light background
+		color.NRGBA{0x9B, 0xBC, 0x0F, 0xFF}, color.NRGBA{0x0F, 0x38, 0x0F, 0xFF},
+		color.NRGBA{0x30, 0x62, 0x30, 0xFF}, color.NRGBA{0x8B, 0xAC, 0x0F, 0xFF},
+	}},
+	{"high-contrast", Palette{
+		color.NRGBA{0x00, 0x00, 0x00, 0xFF}, color.NRGBA{0xFF, 0xFF, 0x00, 0xFF},
+		color.NRGBA{0x00, 0xFF, 0xFF, 0xFF}, color.NRGBA{0xFF, 0xFF, 0xFF, 0xFF},
+	}},
+	{"xo-chip", Palette{ //16 colours for four bitplanes, the Octo default followed by a CGA style ramp
+		color.NRGBA{0x99, 0x66, 0x00, 0xFF}, color.NRGBA{0xFF, 0xCC, 0x00, 0xFF},
+		color.NRGBA{0xFF, 0x66, 0x00, 0xFF}, color.NRGBA{0x66, 0x22, 0x00, 0xFF},
+		color.NRGBA{0x00, 0x00, 0xAA, 0xFF}, color.NRGBA{0x00, 0xAA, 0x00, 0xFF},
+		color.NRGBA{0x00, 0xAA, 0xAA, 0xFF}, color.NRGBA{0xAA, 0x00, 0x00, 0xFF},
+		color.NRGBA{0xAA, 0x00, 0xAA, 0xFF}, color.NRGBA{0xAA, 0x55, 0x00, 0xFF},
+		color.NRGBA{0xAA, 0xAA, 0xAA, 0xFF}, color.NRGBA{0x55, 0x55, 0xFF, 0xFF},
+		color.NRGBA{0x55, 0xFF, 0x55, 0xFF}, color.NRGBA{0x55, 0xFF, 0xFF, 0xFF},
+		color.NRGBA{0xFF, 0x55, 0x55, 0xFF}, color.NRGBA{0xFF, 0x55, 0xFF, 0xFF},
+	}},
+}
+
+func FindTheme(name string) (int, error) {
+	for i, theme := range Themes {
+		if theme.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("theme error: no theme named %q", name)
+}
+
+//Converts the colours from a rom database entry, nil if there are fewer than 2
+func NewPalette(colors []color.NRGBA) Palette {
+	if len(colors) < 2 {
+		return nil
+	}
+	result := make(Palette, len(colors))
+	for i, c := range colors {
+		result[i] = c
+	}
+	return result
+}
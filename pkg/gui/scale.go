@@ -0,0 +1,113 @@
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+//How the display is fitted into the window
+type ScaleMode int
+
+const (
+	ScaleInteger ScaleMode = iota //Largest whole multiple that fits, dots stay square and equal sized
+	ScaleFit                      //Largest size that keeps the aspect ratio
+	ScaleStretch                  //Fills the window
+)
+
+var scaleModeNames = []string{"integer", "fit", "stretch"}
+
+func (mode ScaleMode) String() string {
+	if mode < 0 || int(mode) >= len(scaleModeNames) {
+		return fmt.Sprintf("ScaleMode(%d)", int(mode))
+	}
+	return scaleModeNames[mode]
+}
+
+func ParseScaleMode(name string) (ScaleMode, error) {
+	for i, modeName := range scaleModeNames {
+		if modeName == name {
+			return ScaleMode(i), nil
+		}
+	}
+	return ScaleInteger, fmt.Errorf("scale error: no scale mode named %q", name)
+}
+
+//Area of the window the display is drawn in, centred so the rest is letterboxed
+//Sizes are recalculated every frame so a display that changes resolution is handled the same as a resized window
+func ScaleRect(mode ScaleMode, source, window image.Point) image.Rectangle {
+	if source.X <= 0 || source.Y <= 0 || window.X <= 0 || window.Y <= 0 {
+		return image.Rectangle{}
+	}
+
+	size := window
+	switch mode {
+	case ScaleInteger:
+		factor := min(window.X/source.X, window.Y/source.Y)
+		if factor < 1 {
+			//Window too small for whole dots, shrink instead of cutting the display off
+			return ScaleRect(ScaleFit, source, window)
+		}
+		size = source.Mul(factor)
+	case ScaleFit:
+		//Compare window.X/source.X with window.Y/source.Y without dividing
+		if window.X*source.Y < window.Y*source.X {
+			size = image.Pt(window.X, source.Y*window.X/source.X)
+		} else {
+			size = image.Pt(source.X*window.Y/source.Y, window.Y)
+		}
+	}
+
+	offset := window.Sub(size).Div(2)
+	return image.Rectangle{Min: offset, Max: offset.Add(size)}
+}
+
+//Nearest neighbour scaling, smoothing would blur the edges of the dots
+//It runs for every frame at the size of the window, so pixels are copied straight between the Pix slices
+func ScaleImage(source image.Image, size image.Point) *image.RGBA {
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	bounds := source.Bounds()
+	if bounds.Empty() || result.Bounds().Empty() {
+		return result
+	}
+	if bounds.Size() == size {
+		draw.Draw(result, result.Bounds(), source, bounds.Min, draw.Src)
+		return result
+	}
+	pixels, ok := source.(*image.RGBA)
+	if !ok {
+		pixels = image.NewRGBA(image.Rectangle{Max: bounds.Size()})
+		draw.Draw(pixels, pixels.Bounds(), source, bounds.Min, draw.Src)
+		bounds = pixels.Bounds()
+	}
+
+	//Offset within a source row of the pixel each column shows
+	columns := make([]int, size.X)
+	for x := range columns {
+		columns[x] = 4 * (x * bounds.Dx() / size.X)
+	}
+
+	previousY := bounds.Min.Y - 1
+	for y := 0; y < size.Y; y++ {
+		row := result.Pix[y*result.Stride : y*result.Stride+4*size.X]
+		sourceY := bounds.Min.Y + y*bounds.Dy()/size.Y
+		if sourceY == previousY {
+			//Same source row as the line above
+			copy(row, result.Pix[(y-1)*result.Stride:])
+			continue
+		}
+		previousY = sourceY
+		sourceRow := pixels.Pix[pixels.PixOffset(bounds.Min.X, sourceY):]
+		for x, offset := range columns {
+			copy(row[4*x:4*x+4], sourceRow[offset:offset+4])
+		}
+	}
+	return result
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
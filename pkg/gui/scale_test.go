@@ -0,0 +1,94 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScaleRect(t *testing.T) {
+	source := image.Pt(64, 32)
+	tests := []struct {
+		mode     ScaleMode
+		window   image.Point
+		expected image.Rectangle
+	}{
+		{ScaleInteger, image.Pt(640, 320), image.Rect(0, 0, 640, 320)},
+		{ScaleInteger, image.Pt(700, 400), image.Rect(30, 40, 670, 360)}, //10x with bars on every side
+		{ScaleInteger, image.Pt(1000, 200), image.Rect(308, 4, 692, 196)},
+		{ScaleInteger, image.Pt(32, 32), image.Rect(0, 8, 32, 24)}, //Too small for 1x, falls back to fit
+		{ScaleFit, image.Pt(700, 400), image.Rect(0, 25, 700, 375)},
+		{ScaleFit, image.Pt(1000, 200), image.Rect(300, 0, 700, 200)},
+		{ScaleStretch, image.Pt(700, 400), image.Rect(0, 0, 700, 400)},
+		{ScaleFit, image.Pt(0, 400), image.Rectangle{}},
+	}
+
+	for _, test := range tests {
+		result := ScaleRect(test.mode, source, test.window)
+		if result != test.expected {
+			t.Errorf("FAIL %v %v: %v (expected %v)", test.mode, test.window, result, test.expected)
+		}
+	}
+
+	//A larger display in the same window gets a smaller whole multiple
+	if result := ScaleRect(ScaleInteger, image.Pt(128, 64), image.Pt(700, 400)); result != image.Rect(30, 40, 670, 360) {
+		t.Errorf("FAIL 128x64: %v (expected 5x)", result)
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	on := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	source := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	source.Set(1, 0, on)
+
+	result := ScaleImage(source, image.Pt(6, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			expected := color.RGBA{}
+			if x >= 3 && y < 2 {
+				expected = on
+			}
+			if result.RGBAAt(x, y) != expected {
+				t.Errorf("FAIL (%v, %v)=%v (expected %v)", x, y, result.RGBAAt(x, y), expected)
+			}
+		}
+	}
+}
+
+//Images other than RGBA and ones that don't start at 0,0 scale the same
+func TestScaleImageSources(t *testing.T) {
+	on := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	whole := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	whole.Set(2, 1, on)
+	sources := map[string]image.Image{
+		"sub image": whole.SubImage(image.Rect(1, 0, 3, 2)),
+		"paletted":  image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.RGBA{}, on}),
+	}
+	sources["paletted"].(*image.Paletted).SetColorIndex(1, 1, 1)
+
+	for name, source := range sources {
+		result := ScaleImage(source, image.Pt(4, 6))
+		for y := 0; y < 6; y++ {
+			for x := 0; x < 4; x++ {
+				expected := color.RGBA{}
+				if x >= 2 && y >= 3 {
+					expected = on
+				}
+				if result.RGBAAt(x, y) != expected {
+					t.Errorf("FAIL %v (%v, %v)=%v (expected %v)", name, x, y, result.RGBAAt(x, y), expected)
+				}
+			}
+		}
+	}
+}
+
+func TestParseScaleMode(t *testing.T) {
+	for _, mode := range []ScaleMode{ScaleInteger, ScaleFit, ScaleStretch} {
+		if parsed, err := ParseScaleMode(mode.String()); err != nil || parsed != mode {
+			t.Errorf("FAIL %v parsed as %v, %v", mode, parsed, err)
+		}
+	}
+	if _, err := ParseScaleMode("zoom"); err == nil {
+		t.Errorf("FAIL zoom parsed (expected an error)")
+	}
+}
@@ -0,0 +1,207 @@
+package keymap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+//Emulator actions that can be bound to keys, frontends decide how to carry them out
+type Action string
+
+const (
+	ActionPause     Action = "pause"
+	ActionReset     Action = "reset"
+	ActionSaveState Action = "save-state"
+	ActionLoadState Action = "load-state"
+	ActionSpeedUp   Action = "speed-up"
+
+	ActionCycleTheme Action = "cycle-theme"
+	ActionCycleScale Action = "cycle-scale"
+	ActionFullscreen Action = "fullscreen"
+	ActionOverlay    Action = "overlay"
+	ActionKeypad     Action = "keypad"
+)
+
+var knownActions = map[Action]bool{
+	ActionPause: true, ActionReset: true, ActionSaveState: true, ActionLoadState: true, ActionSpeedUp: true,
+	ActionCycleTheme: true, ActionCycleScale: true, ActionFullscreen: true, ActionOverlay: true, ActionKeypad: true,
+}
+
+//Bindings as written in a keymap file
+//Keys are indexed by the chip8 key as a hex digit, every entry lists the window key names bound to it
+type Bindings struct {
+	Keys    map[string][]string `json:"keys,omitempty"`
+	Actions map[Action][]string `json:"actions,omitempty"`
+}
+
+//Layout of a keymap file, Roms holds overrides keyed by the rom's sha1
+type File struct {
+	Bindings
+	Roms map[string]Bindings `json:"roms,omitempty"`
+}
+
+//Resolved lookup from window key names
+type Keymap struct {
+	keys    map[string]byte
+	actions map[string]Action
+}
+
+//QWERTY layout of the COSMAC VIP keypad
+//1 2 3 C      1 2 3 4
+//4 5 6 D  ->  Q W E R
+//7 8 9 E      A S D F
+//A 0 B F      Z X C V
+func Default() *File {
+	return &File{
+		Bindings: Bindings{
+			Keys: map[string][]string{
+				"0": {"X"}, "1": {"1"}, "2": {"2"}, "3": {"3"},
+				"4": {"Q"}, "5": {"W"}, "6": {"E"}, "7": {"A"},
+				"8": {"S"}, "9": {"D"}, "A": {"Z"}, "B": {"C"},
+				"C": {"4"}, "D": {"R"}, "E": {"F"}, "F": {"V"},
+			},
+			Actions: map[Action][]string{
+				ActionPause:     {"P"},
+				ActionReset:     {"F5"},
+				ActionSaveState: {"F6"},
+				ActionLoadState: {"F9"},
+				ActionSpeedUp:   {"Tab"},
+
+				ActionCycleTheme: {"T"},
+				ActionCycleScale: {"F3"},
+				ActionFullscreen: {"F11"},
+				ActionOverlay:    {"F1"},
+				ActionKeypad:     {"F2"},
+			},
+		},
+	}
+}
+
+//Loads a json or toml keymap file, chosen by extension, on top of the default bindings
+func Load(filename string) (*File, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{}
+	if strings.EqualFold(filepath.Ext(filename), ".toml") {
+		err = parseTOML(data, file)
+	} else {
+		err = json.Unmarshal(data, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keymap error: %s: %v", filename, err)
+	}
+
+	result := Default()
+	result.Bindings = merge(result.Bindings, file.Bindings)
+	result.Roms = map[string]Bindings{}
+	for hash, bindings := range file.Roms {
+		result.Roms[strings.ToLower(hash)] = bindings
+	}
+	return result, nil
+}
+
+//Resolves the bindings for a rom, romHash may be empty to use only the base bindings
+func (file *File) Keymap(romHash string) (*Keymap, error) {
+	bindings := file.Bindings
+	if override, ok := file.Roms[strings.ToLower(romHash)]; ok {
+		bindings = merge(bindings, override)
+	}
+
+	keymap := &Keymap{keys: map[string]byte{}, actions: map[string]Action{}}
+	for digit, names := range bindings.Keys {
+		key, err := strconv.ParseUint(digit, 16, 8)
+		if err != nil || key > 0xF {
+			return nil, fmt.Errorf("keymap error: %q is not a chip8 key", digit)
+		}
+		for _, name := range names {
+			if err := keymap.bind(name); err != nil {
+				return nil, err
+			}
+			keymap.keys[normalize(name)] = byte(key)
+		}
+	}
+	for action, names := range bindings.Actions {
+		if !knownActions[action] {
+			return nil, fmt.Errorf("keymap error: %q is not an action", action)
+		}
+		for _, name := range names {
+			if err := keymap.bind(name); err != nil {
+				return nil, err
+			}
+			keymap.actions[normalize(name)] = action
+		}
+	}
+	return keymap, nil
+}
+
+func (keymap *Keymap) bind(name string) error {
+	_, isKey := keymap.keys[normalize(name)]
+	_, isAction := keymap.actions[normalize(name)]
+	if isKey || isAction {
+		return fmt.Errorf("keymap error: %q is bound more than once", name)
+	}
+	return nil
+}
+
+//Chip8 key bound to a window key name
+func (keymap *Keymap) Key(name string) (byte, bool) {
+	key, ok := keymap.keys[normalize(name)]
+	return key, ok
+}
+
+//Action bound to a window key name
+func (keymap *Keymap) Action(name string) (Action, bool) {
+	action, ok := keymap.actions[normalize(name)]
+	return action, ok
+}
+
+//Entries in override replace the entries for the same chip8 key or action in base
+//Window keys the override binds are taken away from whatever base bound them to
+func merge(base, override Bindings) Bindings {
+	taken := map[string]bool{}
+	for _, names := range override.Keys {
+		for _, name := range names {
+			taken[normalize(name)] = true
+		}
+	}
+	for _, names := range override.Actions {
+		for _, name := range names {
+			taken[normalize(name)] = true
+		}
+	}
+	untaken := func(names []string) []string {
+		kept := []string{}
+		for _, name := range names {
+			if !taken[normalize(name)] {
+				kept = append(kept, name)
+			}
+		}
+		return kept
+	}
+
+	result := Bindings{Keys: map[string][]string{}, Actions: map[Action][]string{}}
+	for digit, names := range base.Keys {
+		result.Keys[strings.ToUpper(digit)] = untaken(names)
+	}
+	for digit, names := range override.Keys {
+		result.Keys[strings.ToUpper(digit)] = names
+	}
+	for action, names := range base.Actions {
+		result.Actions[action] = untaken(names)
+	}
+	for action, names := range override.Actions {
+		result.Actions[action] = names
+	}
+	return result
+}
+
+func normalize(name string) string {
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,117 @@
+package keymap
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const romHash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestDefault(t *testing.T) {
+	keymap, err := Default().Keymap("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key, ok := keymap.Key("x"); !ok || key != 0x0 {
+		t.Errorf("FAIL x=0x%X,%v (expected 0x0)", key, ok)
+	}
+	if action, ok := keymap.Action("P"); !ok || action != ActionPause {
+		t.Errorf("FAIL P=%v,%v (expected pause)", action, ok)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	json := `{
+	"keys": {"5": ["Z", "Up"], "a": ["W"]},
+	"actions": {"speed-up": ["Space"]},
+	"roms": {"` + romHash + `": {"keys": {"5": ["Space"]}, "actions": {"speed-up": ["Tab"]}}}
+}`
+	toml := `# AZERTY
+[keys]
+5 = ["Z", "Up"]
+a = "W" # was Z
+
+[actions]
+speed-up = ["Space"]
+
+[roms.` + romHash + `.keys]
+5 = "Space"
+[roms."` + romHash + `".actions]
+"speed-up" = ["Tab",]
+`
+
+	for _, file := range []struct{ name, contents string }{{"keymap.json", json}, {"keymap.toml", toml}} {
+		t.Run(file.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), file.name)
+			if err := ioutil.WriteFile(filename, []byte(file.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := Load(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			base, err := loaded.Keymap("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectKey(t, base, "Z", 0x5)
+			expectKey(t, base, "Up", 0x5)
+			expectKey(t, base, "W", 0xA)
+			expectKey(t, base, "X", 0x0) //Unchanged default
+			if action, _ := base.Action("Space"); action != ActionSpeedUp {
+				t.Errorf("FAIL Space=%v (expected speed-up)", action)
+			}
+
+			rom, err := loaded.Keymap(romHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectKey(t, rom, "Space", 0x5)
+			if _, ok := rom.Key("Z"); ok {
+				t.Errorf("FAIL Z is still bound in the rom override")
+			}
+			if action, _ := rom.Action("Tab"); action != ActionSpeedUp {
+				t.Errorf("FAIL Tab=%v (expected speed-up)", action)
+			}
+		})
+	}
+}
+
+func TestDuplicateBinding(t *testing.T) {
+	file := Default()
+	file.Keys["5"] = []string{"X"} //X is already key 0
+
+	if _, err := file.Keymap(""); err == nil {
+		t.Errorf("FAIL expected an error for a key bound twice")
+	}
+}
+
+func TestOverrideWins(t *testing.T) {
+	file := Default()
+	file.Roms = map[string]Bindings{romHash: {Keys: map[string][]string{"5": {"P", "X"}}}}
+
+	rom, err := file.Keymap(romHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectKey(t, rom, "P", 0x5)
+	expectKey(t, rom, "X", 0x5)
+	if _, ok := rom.Action("P"); ok {
+		t.Errorf("FAIL P is still bound to pause in the rom override")
+	}
+
+	file.Roms[romHash] = Bindings{Actions: map[Action][]string{"pause-game": {"Escape"}}}
+	if _, err := file.Keymap(romHash); err == nil {
+		t.Errorf("FAIL expected an error for an unknown action")
+	}
+}
+
+func expectKey(t *testing.T, keymap *Keymap, name string, expected byte) {
+	t.Helper()
+	if key, ok := keymap.Key(name); !ok || key != expected {
+		t.Errorf("FAIL %s=0x%X,%v (expected 0x%X)", name, key, ok, expected)
+	}
+}
@@ -0,0 +1,137 @@
+package keymap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//Reads the subset of toml used by keymap files
+//
+//	[keys]
+//	5 = ["W", "Up"]
+//	[actions]
+//	pause = "P"
+//	[roms.<sha1>.keys]
+//	5 = "Space"
+func parseTOML(data []byte, file *File) error {
+	var current *Bindings //Maps in current are always allocated so rom tables can be copies
+	table := ""
+
+	for number, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %v: unterminated table header", number+1)
+			}
+			path := splitPath(line[1 : len(line)-1])
+
+			current = allocate(&file.Bindings)
+			if len(path) == 3 && path[0] == "roms" {
+				if file.Roms == nil {
+					file.Roms = map[string]Bindings{}
+				}
+				rom := file.Roms[path[1]]
+				current = allocate(&rom)
+				file.Roms[path[1]] = rom
+				path = path[2:]
+			}
+			if len(path) != 1 || (path[0] != "keys" && path[0] != "actions") {
+				return fmt.Errorf("line %v: unknown table [%s]", number+1, strings.Join(path, "."))
+			}
+			table = path[0]
+			continue
+		}
+
+		if current == nil {
+			return fmt.Errorf("line %v: value outside of a table", number+1)
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("line %v: expected key = value", number+1)
+		}
+		name := unquote(strings.TrimSpace(parts[0]))
+		values, err := parseValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return fmt.Errorf("line %v: %v", number+1, err)
+		}
+
+		if table == "keys" {
+			current.Keys[name] = values
+		} else {
+			current.Actions[Action(name)] = values
+		}
+	}
+	return nil
+}
+
+func allocate(bindings *Bindings) *Bindings {
+	if bindings.Keys == nil {
+		bindings.Keys = map[string][]string{}
+	}
+	if bindings.Actions == nil {
+		bindings.Actions = map[Action][]string{}
+	}
+	return bindings
+}
+
+//A single string or an array of strings
+func parseValue(text string) ([]string, error) {
+	if !strings.HasPrefix(text, "[") {
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", text)
+		}
+		return []string{value}, nil
+	}
+
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("unterminated array %s", text)
+	}
+	values := []string{}
+	for _, item := range strings.Split(text[1:len(text)-1], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue //Trailing comma
+		}
+		value, err := strconv.Unquote(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+//Removes a # comment that is not inside a string
+func stripComment(line string) string {
+	inString := false
+	for i, char := range line {
+		switch {
+		case char == '"' && (i == 0 || line[i-1] != '\\'):
+			inString = !inString
+		case char == '#' && !inString:
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitPath(path string) []string {
+	parts := []string{}
+	for _, part := range strings.Split(path, ".") {
+		parts = append(parts, unquote(strings.TrimSpace(part)))
+	}
+	return parts
+}
+
+func unquote(text string) string {
+	if value, err := strconv.Unquote(text); err == nil {
+		return value
+	}
+	return text
+}
@@ -0,0 +1,52 @@
+//Downloads the community CHIP-8 database into the romdb data directory
+//Run with go generate ./pkg/romdb
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var (
+	source    = flag.String("source", "https://raw.githubusercontent.com/chip-8/chip-8-database/master/database", "`url` of the database directory")
+	directory = flag.String("out", "data", "`directory` the files are written to")
+)
+
+//platforms.json is kept as it is, it only holds the quirks this interpreter understands
+var files = []string{"programs.json", "hashes.json"}
+
+func main() {
+	flag.Parse()
+	for _, name := range files {
+		if err := fetch(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func fetch(name string) error {
+	response, err := http.Get(*source + "/" + name)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch error: %s: %s", name, response.Status)
+	}
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	var check interface{}
+	if err := json.Unmarshal(contents, &check); err != nil {
+		return fmt.Errorf("fetch error: %s: %v", name, err)
+	}
+	return ioutil.WriteFile(filepath.Join(*directory, name), contents, 0644)
+}
@@ -0,0 +1,322 @@
+package romdb
+
+import (
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/keymap"
+)
+
+//The data directory uses the file layout of the community CHIP-8 database
+//programs.json lists the programs, hashes.json maps rom sha1 to an index into programs
+//and platforms.json holds the quirks of each platform
+//programs.json and hashes.json are copied from github.com/chip-8/chip-8-database by go generate
+//The checked in copy at least lists the test suite roms in pkg/chip8/testdata, go generate brings in the rest
+//
+//go:embed data/*.json
+var data embed.FS
+
+//go:generate go run ./internal/fetch
+
+//Quirk names from the community database, nil means unset
+type Quirks struct {
+	Shift                 *bool `json:"shift,omitempty"`
+	MemoryIncrementByX    *bool `json:"memoryIncrementByX,omitempty"`
+	MemoryLeaveIUnchanged *bool `json:"memoryLeaveIUnchanged,omitempty"`
+	Wrap                  *bool `json:"wrap,omitempty"`
+	Jump                  *bool `json:"jump,omitempty"`
+	VBlank                *bool `json:"vblank,omitempty"`
+	Logic                 *bool `json:"logic,omitempty"`
+}
+
+type Colors struct {
+	Pixels  []string `json:"pixels,omitempty"` //#rrggbb, index 0 is the background
+	Buzzer  string   `json:"buzzer,omitempty"`
+	Silence string   `json:"silence,omitempty"`
+}
+
+type Rom struct {
+	File            string            `json:"file,omitempty"`
+	Tickrate        int               `json:"tickrate,omitempty"`
+	Platforms       []string          `json:"platforms,omitempty"`
+	QuirkyPlatforms map[string]Quirks `json:"quirkyPlatforms,omitempty"`
+	Colors          *Colors           `json:"colors,omitempty"`
+	Keys            map[string]byte   `json:"keys,omitempty"` //Names such as up or a to chip8 keys
+}
+
+type Program struct {
+	Title   string         `json:"title"`
+	Authors []string       `json:"authors,omitempty"`
+	Roms    map[string]Rom `json:"roms"`
+}
+
+type Platform struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	DefaultTickrate int    `json:"defaultTickrate"`
+	Quirks          Quirks `json:"quirks"`
+}
+
+//Entry in a local override file, keyed by rom sha1
+//Set fields replace the database values and unknown roms are added
+type Override struct {
+	Title    string          `json:"title,omitempty"`
+	Authors  []string        `json:"authors,omitempty"`
+	Platform string          `json:"platform,omitempty"`
+	Tickrate int             `json:"tickrate,omitempty"`
+	Quirks   Quirks          `json:"quirks,omitempty"`
+	Colors   *Colors         `json:"colors,omitempty"`
+	Keys     map[string]byte `json:"keys,omitempty"`
+}
+
+type Database struct {
+	programs  []Program
+	hashes    map[string]int
+	platforms map[string]Platform
+	overrides map[string]Override
+}
+
+//Settings for one rom with the platform defaults applied
+type Entry struct {
+	Hash     string
+	Title    string
+	Authors  []string
+	Platform string
+	Tickrate int
+	Quirks   Quirks
+	Colors   Colors
+	Keys     map[string]byte
+}
+
+//Opens the embedded database
+func Open() (*Database, error) {
+	db := &Database{hashes: map[string]int{}, platforms: map[string]Platform{}, overrides: map[string]Override{}}
+
+	if err := readEmbedded("data/programs.json", &db.programs); err != nil {
+		return nil, err
+	}
+	if err := readEmbedded("data/hashes.json", &db.hashes); err != nil {
+		return nil, err
+	}
+	platforms := []Platform{}
+	if err := readEmbedded("data/platforms.json", &platforms); err != nil {
+		return nil, err
+	}
+	for _, platform := range platforms {
+		db.platforms[platform.ID] = platform
+	}
+	return db, nil
+}
+
+func readEmbedded(filename string, value interface{}) error {
+	contents, err := data.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(contents, value); err != nil {
+		return fmt.Errorf("romdb error: %s: %v", filename, err)
+	}
+	return nil
+}
+
+//Loads a local override file, later files replace earlier ones
+func (db *Database) LoadOverrides(filename string) error {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	overrides := map[string]Override{}
+	if err := json.Unmarshal(contents, &overrides); err != nil {
+		return fmt.Errorf("romdb error: %s: %v", filename, err)
+	}
+	for hash, override := range overrides {
+		db.overrides[strings.ToLower(hash)] = override
+	}
+	return nil
+}
+
+func Hash(program []byte) string {
+	sum := sha1.Sum(program)
+	return hex.EncodeToString(sum[:])
+}
+
+//Looks up a rom by the sha1 of its contents
+func (db *Database) Lookup(program []byte) (Entry, bool) {
+	return db.LookupHash(Hash(program))
+}
+
+func (db *Database) LookupHash(hash string) (Entry, bool) {
+	hash = strings.ToLower(hash)
+	entry := Entry{Hash: hash}
+	found := false
+
+	if index, ok := db.hashes[hash]; ok && index >= 0 && index < len(db.programs) {
+		program := db.programs[index]
+		rom := program.Roms[hash]
+		found = true
+
+		entry.Title, entry.Authors = program.Title, program.Authors
+		if len(rom.Platforms) > 0 {
+			entry.Platform = rom.Platforms[0]
+		}
+		entry.Tickrate = rom.Tickrate
+		entry.Quirks = db.platforms[entry.Platform].Quirks.merge(rom.QuirkyPlatforms[entry.Platform])
+		if rom.Colors != nil {
+			entry.Colors = *rom.Colors
+		}
+		entry.Keys = rom.Keys
+	}
+
+	if override, ok := db.overrides[hash]; ok {
+		found = true
+		entry.apply(override, db.platforms)
+	}
+
+	if found && entry.Tickrate == 0 {
+		entry.Tickrate = db.platforms[entry.Platform].DefaultTickrate
+	}
+	return entry, found
+}
+
+func (entry *Entry) apply(override Override, platforms map[string]Platform) {
+	if override.Title != "" {
+		entry.Title = override.Title
+	}
+	if override.Authors != nil {
+		entry.Authors = override.Authors
+	}
+	if override.Platform != "" && override.Platform != entry.Platform {
+		entry.Platform = override.Platform
+		entry.Quirks = platforms[entry.Platform].Quirks
+	}
+	if override.Tickrate != 0 {
+		entry.Tickrate = override.Tickrate
+	}
+	entry.Quirks = entry.Quirks.merge(override.Quirks)
+	if override.Colors != nil {
+		entry.Colors = *override.Colors
+	}
+	if override.Keys != nil {
+		entry.Keys = override.Keys
+	}
+}
+
+//Set quirks in other replace those in quirks
+func (quirks Quirks) merge(other Quirks) Quirks {
+	pick := func(value, override *bool) *bool {
+		if override != nil {
+			return override
+		}
+		return value
+	}
+	return Quirks{
+		Shift:                 pick(quirks.Shift, other.Shift),
+		MemoryIncrementByX:    pick(quirks.MemoryIncrementByX, other.MemoryIncrementByX),
+		MemoryLeaveIUnchanged: pick(quirks.MemoryLeaveIUnchanged, other.MemoryLeaveIUnchanged),
+		Wrap:                  pick(quirks.Wrap, other.Wrap),
+		Jump:                  pick(quirks.Jump, other.Jump),
+		VBlank:                pick(quirks.VBlank, other.VBlank),
+		Logic:                 pick(quirks.Logic, other.Logic),
+	}
+}
+
+func isSet(value *bool) bool {
+	return value != nil && *value
+}
+
+//System settings for the entry, unset quirks keep the defaults of this interpreter
+func (entry Entry) Config() chip8.Config {
+	config := chip8.DefaultConfig()
+	if entry.Tickrate > 0 {
+		config.TickRate = entry.Tickrate
+	}
+
+	quirks := &config.Quirks
+	if entry.Quirks.Shift != nil {
+		quirks.ShiftVY = !*entry.Quirks.Shift
+	}
+	if entry.Quirks.MemoryLeaveIUnchanged != nil || entry.Quirks.MemoryIncrementByX != nil {
+		quirks.IncrementIByX = isSet(entry.Quirks.MemoryIncrementByX)
+		quirks.IncrementI = !quirks.IncrementIByX && !isSet(entry.Quirks.MemoryLeaveIUnchanged)
+	}
+	quirks.ResetVF = isSet(entry.Quirks.Logic)
+	quirks.JumpVX = isSet(entry.Quirks.Jump)
+	quirks.DisplayWait = isSet(entry.Quirks.VBlank)
+	quirks.WrapSprites = isSet(entry.Quirks.Wrap)
+	return config
+}
+
+//Window keys for the named keys of the database
+var namedKeys = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+	"a":     "Space",
+	"b":     "⏎",
+}
+
+//Per rom keymap override that adds the rom's named keys to the keys already bound in base
+func (entry Entry) Bindings(base keymap.Bindings) keymap.Bindings {
+	bindings := keymap.Bindings{Keys: map[string][]string{}}
+	for name, key := range entry.Keys {
+		windowKey, ok := namedKeys[name]
+		if !ok || key > 0xF {
+			continue
+		}
+		digit := fmt.Sprintf("%X", key)
+		if _, ok := bindings.Keys[digit]; !ok {
+			bindings.Keys[digit] = append(baseKeys(base, digit), windowKey)
+		} else {
+			bindings.Keys[digit] = append(bindings.Keys[digit], windowKey)
+		}
+	}
+	return bindings
+}
+
+//Names base binds to a chip8 key whatever case its digit is written in
+func baseKeys(base keymap.Bindings, digit string) []string {
+	for baseDigit, names := range base.Keys {
+		if strings.EqualFold(baseDigit, digit) {
+			return append([]string{}, names...)
+		}
+	}
+	return nil
+}
+
+//Parses the pixel colours, nil if the entry has none
+func (colors Colors) PixelColors() ([]color.NRGBA, error) {
+	if len(colors.Pixels) == 0 {
+		return nil, nil
+	}
+	result := make([]color.NRGBA, len(colors.Pixels))
+	for i, text := range colors.Pixels {
+		parsed, err := ParseColor(text)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = parsed
+	}
+	return result, nil
+}
+
+//Parses #rgb or #rrggbb
+func ParseColor(text string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(text, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	value, err := hex.DecodeString(digits)
+	if err != nil || len(value) != 3 {
+		return color.NRGBA{}, fmt.Errorf("romdb error: %q is not a colour", text)
+	}
+	return color.NRGBA{R: value[0], G: value[1], B: value[2], A: 0xFF}, nil
+}
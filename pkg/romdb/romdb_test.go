@@ -0,0 +1,149 @@
+package romdb
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/chip8"
+	"gongaware.org/gChip8/pkg/keymap"
+)
+
+var program = []byte{0x12, 0x00}
+
+func TestPlatformQuirks(t *testing.T) {
+	db := openWithProgram(t, Rom{Platforms: []string{"originalChip8"}})
+
+	entry, ok := db.Lookup(program)
+	if !ok {
+		t.Fatalf("FAIL %v not found", Hash(program))
+	}
+	config := entry.Config()
+	if config.TickRate != 15 {
+		t.Errorf("FAIL tickrate=%v (expected the platform default 15)", config.TickRate)
+	}
+	if config.Quirks != chip8.VIPQuirks() {
+		t.Errorf("FAIL quirks=%+v (expected %+v)", config.Quirks, chip8.VIPQuirks())
+	}
+}
+
+func TestQuirkyPlatform(t *testing.T) {
+	db := openWithProgram(t, Rom{
+		Platforms:       []string{"superchip"},
+		Tickrate:        20,
+		QuirkyPlatforms: map[string]Quirks{"superchip": {Jump: new(bool)}},
+		Keys:            map[string]byte{"up": 5, "a": 6},
+	})
+
+	entry, _ := db.Lookup(program)
+	config := entry.Config()
+	expected := chip8.Quirks{}
+	if config.TickRate != 20 || config.Quirks != expected {
+		t.Errorf("FAIL tickrate=%v quirks=%+v (expected 20 and no jump quirk)", config.TickRate, config.Quirks)
+	}
+
+	bindings := entry.Bindings(keymap.Default().Bindings)
+	if len(bindings.Keys["5"]) != 2 || bindings.Keys["5"][0] != "W" || bindings.Keys["5"][1] != "↑" || bindings.Keys["6"][1] != "Space" {
+		t.Errorf("FAIL bindings=%v (expected the default keys then the rom's)", bindings.Keys)
+	}
+
+	//The defaults for the key stay bound in the resolved keymap
+	file := keymap.Default()
+	file.Roms = map[string]keymap.Bindings{entry.Hash: bindings}
+	keys, err := file.Keymap(entry.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"W", "↑"} {
+		if key, ok := keys.Key(name); !ok || key != 5 {
+			t.Errorf("FAIL %v=0x%X,%v (expected key 5)", name, key, ok)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	db := openWithProgram(t, Rom{Platforms: []string{"originalChip8"}})
+
+	override := `{"` + Hash(program) + `": {"title": "Local", "platform": "modernChip8", "quirks": {"vblank": true}, "colors": {"pixels": ["#000", "#ffb000"]}}}`
+	filename := filepath.Join(t.TempDir(), "override.json")
+	if err := ioutil.WriteFile(filename, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LoadOverrides(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, _ := db.Lookup(program)
+	config := entry.Config()
+	expected := chip8.Quirks{ShiftVY: true, IncrementI: true, DisplayWait: true} //Modern platform with vblank turned on
+	if entry.Title != "Local" || config.TickRate != 12 || config.Quirks != expected {
+		t.Errorf("FAIL title=%v tickrate=%v quirks=%+v (expected Local, 12 and %+v)", entry.Title, config.TickRate, config.Quirks, expected)
+	}
+
+	colors, err := entry.Colors.PixelColors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 2 || colors[1] != (color.NRGBA{0xFF, 0xB0, 0x00, 0xFF}) {
+		t.Errorf("FAIL colors=%v", colors)
+	}
+}
+
+func TestUnknownRom(t *testing.T) {
+	db, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Lookup([]byte{0x00, 0xE0}); ok {
+		t.Errorf("FAIL unknown rom was found")
+	}
+}
+
+//Every rom in the embedded data is found under its own hash
+func TestEmbeddedDatabase(t *testing.T) {
+	db, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.hashes) == 0 || len(db.programs) == 0 {
+		t.Fatalf("FAIL embedded database is empty (expected the data written by go generate ./pkg/romdb)")
+	}
+	for hash, index := range db.hashes {
+		entry, ok := db.LookupHash(hash)
+		if !ok || entry.Title == "" || entry.Title != db.programs[index].Title {
+			t.Errorf("FAIL LookupHash(%v)=%+v,%v (expected %q)", hash, entry, ok, db.programs[index].Title)
+		}
+	}
+}
+
+//The test suite roms are in the community database, so a missing one means the data wasn't embedded
+func TestEmbeddedSuiteRoms(t *testing.T) {
+	db, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filenames, err := filepath.Glob("../chip8/testdata/roms/suite/*.ch8")
+	if err != nil || len(filenames) == 0 {
+		t.Fatalf("FAIL no suite roms found: %v", err)
+	}
+	for _, filename := range filenames {
+		rom, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry, ok := db.Lookup(rom); !ok || entry.Title == "" || entry.Platform == "" {
+			t.Errorf("FAIL Lookup(%v)=%+v,%v (expected a titled entry)", filepath.Base(filename), entry, ok)
+		}
+	}
+}
+
+func openWithProgram(t *testing.T, rom Rom) *Database {
+	db, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.programs = append(db.programs, Program{Title: "Test", Roms: map[string]Rom{Hash(program): rom}})
+	db.hashes[Hash(program)] = len(db.programs) - 1
+	return db
+}
@@ -0,0 +1,1126 @@
+package symbols
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+OCTO
+Octo writes roms without symbol files, so the source is assembled again here to find the address of every label and line
+Only the CHIP-8 and SCHIP parts of the language are known, XO-CHIP instructions and :next, :call and :include are errors
+Instructions from a macro are put on the line the macro is used on, data gets no line entries
+*/
+
+const programStart = 0x200
+
+//Assembles an Octo source file, the rom is compared with the one Octo wrote to check the symbols belong to it
+func AssembleOcto(filename string) ([]byte, *Table, error) {
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assembler := &octoAssembler{
+		file:        filepath.Clean(file),
+		tokens:      tokenizeOcto(string(source)),
+		here:        programStart,
+		labels:      map[string]int{},
+		constants:   map[string]float64{},
+		aliases:     map[string]byte{},
+		macros:      map[string]*octoMacro{},
+		stringModes: map[string][]*octoStringMode{},
+	}
+	if err := assembler.assemble(); err != nil {
+		return nil, nil, err
+	}
+	return assembler.program(), assembler.table(), nil
+}
+
+type octoToken struct {
+	text     string
+	line     int
+	isString bool
+}
+
+//Splits on whitespace, # starts a comment and double quotes hold a string
+func tokenizeOcto(source string) []octoToken {
+	tokens := []octoToken{}
+	line := 1
+	for i := 0; i < len(source); {
+		switch c := source[i]; {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			text := strings.Builder{}
+			for i++; i < len(source) && source[i] != '"'; i++ {
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+					text.WriteByte(unescape(source[i]))
+					continue
+				}
+				if source[i] == '\n' {
+					line++
+				}
+				text.WriteByte(source[i])
+			}
+			tokens = append(tokens, octoToken{text.String(), line, true})
+			i++
+		default:
+			start := i
+			for i < len(source) && !strings.ContainsRune(" \t\r\n", rune(source[i])) {
+				i++
+			}
+			tokens = append(tokens, octoToken{source[start:i], line, false})
+		}
+	}
+	return tokens
+}
+
+//Character a backslash escape in a string stands for
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return c
+}
+
+type octoMacro struct {
+	parameters []string
+	body       []octoToken
+	calls      int
+}
+
+type octoStringMode struct {
+	alphabet string
+	body     []octoToken
+}
+
+//Place in the rom to fill in once a name is defined
+type octoFixup struct {
+	address int
+	kind    fixupKind
+	name    string
+	line    int
+}
+
+type fixupKind int
+
+const (
+	fixupAddress fixupKind = iota //Low 12 bits of an instruction
+	fixupPointer                  //16 bit big endian value
+	fixupUnpack                   //Low nibble of v0 := and byte of v1 := after :unpack
+)
+
+type octoLoop struct {
+	start  int
+	breaks []int //Jumps out of the loop from while
+}
+
+type octoAssembler struct {
+	file   string
+	tokens []octoToken
+	line   int //Of the statement being assembled
+
+	rom  [chip8.RamSize]byte
+	here int
+	end  int //Past the last byte written
+
+	labels      map[string]int
+	labelOrder  []Label
+	constants   map[string]float64
+	aliases     map[string]byte
+	macros      map[string]*octoMacro
+	stringModes map[string][]*octoStringMode
+	fixups      []octoFixup
+	blocks      []int //Jumps of open if begin and else blocks
+	loops       []octoLoop
+	lines       []Line
+	hasMain     bool
+}
+
+func (assembler *octoAssembler) assemble() error {
+	//Room for a jump to main, dropped when main comes first
+	assembler.emit(0x00, 0x00)
+	for len(assembler.tokens) > 0 {
+		assembler.line = assembler.tokens[0].line
+		if err := assembler.statement(); err != nil {
+			return fmt.Errorf("octo error: %s: line %v: %v", filepath.Base(assembler.file), assembler.line, err)
+		}
+	}
+	if !assembler.hasMain {
+		return fmt.Errorf("octo error: %s: no main label", filepath.Base(assembler.file))
+	}
+	if len(assembler.blocks) > 0 || len(assembler.loops) > 0 {
+		return fmt.Errorf("octo error: %s: begin or loop without end or again", filepath.Base(assembler.file))
+	}
+
+	for _, fixup := range assembler.fixups {
+		address, ok := assembler.labels[fixup.name]
+		if !ok {
+			return fmt.Errorf("octo error: %s: line %v: undefined name %q", filepath.Base(assembler.file), fixup.line, fixup.name)
+		}
+		assembler.patch(fixup.address, fixup.kind, address)
+	}
+	return nil
+}
+
+func (assembler *octoAssembler) program() []byte {
+	return append([]byte{}, assembler.rom[programStart:assembler.end]...)
+}
+
+func (assembler *octoAssembler) table() *Table {
+	table := &Table{labels: assembler.labelOrder, lines: assembler.lines}
+	sortTable(table)
+	return table
+}
+
+/*
+TOKENS
+*/
+
+func (assembler *octoAssembler) next() (octoToken, error) {
+	if len(assembler.tokens) == 0 {
+		return octoToken{}, fmt.Errorf("unexpected end of file")
+	}
+	token := assembler.tokens[0]
+	assembler.tokens = assembler.tokens[1:]
+	return token, nil
+}
+
+func (assembler *octoAssembler) peek() string {
+	if len(assembler.tokens) == 0 {
+		return ""
+	}
+	return assembler.tokens[0].text
+}
+
+func (assembler *octoAssembler) expect(text string) error {
+	token, err := assembler.next()
+	if err != nil {
+		return err
+	}
+	if token.text != text || token.isString {
+		return fmt.Errorf("expected %q but found %q", text, token.text)
+	}
+	return nil
+}
+
+func (assembler *octoAssembler) name() (string, error) {
+	token, err := assembler.next()
+	if err != nil {
+		return "", err
+	}
+	if token.isString {
+		return "", fmt.Errorf("expected a name but found a string")
+	}
+	return token.text, nil
+}
+
+//Tokens up to the brace closing the one already read
+func (assembler *octoAssembler) block() ([]octoToken, error) {
+	body := []octoToken{}
+	for depth := 1; ; {
+		token, err := assembler.next()
+		if err != nil {
+			return nil, err
+		}
+		if !token.isString && token.text == "{" {
+			depth++
+		} else if !token.isString && token.text == "}" {
+			if depth--; depth == 0 {
+				return body, nil
+			}
+		}
+		body = append(body, token)
+	}
+}
+
+//Puts the tokens of a macro in front of the rest, on the line it was used on
+func (assembler *octoAssembler) expand(body []octoToken, bindings map[string]octoToken) {
+	expanded := make([]octoToken, 0, len(body)+len(assembler.tokens))
+	for _, token := range body {
+		if bound, ok := bindings[token.text]; ok && !token.isString {
+			token = bound
+		}
+		token.line = assembler.line
+		expanded = append(expanded, token)
+	}
+	assembler.tokens = append(expanded, assembler.tokens...)
+}
+
+/*
+OUTPUT
+*/
+
+func (assembler *octoAssembler) emit(values ...byte) error {
+	for _, value := range values {
+		if assembler.here < programStart || assembler.here >= chip8.RamSize {
+			return fmt.Errorf("address 0x%X is outside the program memory", assembler.here)
+		}
+		assembler.rom[assembler.here] = value
+		assembler.here++
+		if assembler.here > assembler.end {
+			assembler.end = assembler.here
+		}
+	}
+	return nil
+}
+
+func (assembler *octoAssembler) instruction(high, low byte) error {
+	assembler.lines = append(assembler.lines, Line{chip8.Address(assembler.here), assembler.file, assembler.line})
+	return assembler.emit(high, low)
+}
+
+//Instruction with a 12 bit address, filled in later when the name isn't defined yet
+func (assembler *octoAssembler) addressInstruction(opcode byte) error {
+	token, err := assembler.next()
+	if err != nil {
+		return err
+	}
+	address, err := assembler.reference(token, fixupAddress)
+	if err != nil {
+		return err
+	}
+	if address > 0xFFF {
+		return fmt.Errorf("address 0x%X doesn't fit in 12 bits", address)
+	}
+	return assembler.instruction(opcode<<4|byte(address>>8), byte(address))
+}
+
+//Value of a defined name or number, or 0 and a fixup for a label defined later
+func (assembler *octoAssembler) reference(token octoToken, kind fixupKind) (int, error) {
+	if value, ok, err := assembler.constant(token); ok || err != nil {
+		return int(value), err
+	}
+	if token.isString || isRegister(token.text) {
+		return 0, fmt.Errorf("expected an address but found %q", token.text)
+	}
+	if address, ok := assembler.labels[token.text]; ok {
+		return address, nil
+	}
+	assembler.fixups = append(assembler.fixups, octoFixup{assembler.here, kind, token.text, assembler.line})
+	return 0, nil
+}
+
+func (assembler *octoAssembler) patch(at int, kind fixupKind, address int) {
+	switch kind {
+	case fixupAddress:
+		assembler.rom[at] = assembler.rom[at]&0xF0 | byte(address>>8)&0x0F
+		assembler.rom[at+1] = byte(address)
+	case fixupPointer:
+		assembler.rom[at] = byte(address >> 8)
+		assembler.rom[at+1] = byte(address)
+	case fixupUnpack:
+		assembler.rom[at+1] = assembler.rom[at+1]&0xF0 | byte(address>>8)&0x0F
+		assembler.rom[at+3] = byte(address)
+	}
+}
+
+func (assembler *octoAssembler) defineLabel(name string) error {
+	if _, ok := assembler.labels[name]; ok {
+		return fmt.Errorf("%q is already defined", name)
+	}
+	if name == "main" {
+		assembler.hasMain = true
+		if assembler.here == programStart+2 {
+			assembler.here = programStart
+		} else {
+			assembler.patch(programStart, fixupAddress, assembler.here)
+			assembler.rom[programStart] |= 0x10
+		}
+	}
+	assembler.labels[name] = assembler.here
+	assembler.labelOrder = append(assembler.labelOrder, Label{chip8.Address(assembler.here), name})
+	return nil
+}
+
+/*
+VALUES
+*/
+
+func isRegister(text string) bool {
+	return len(text) == 2 && (text[0] == 'v' || text[0] == 'V') && strings.ContainsRune("0123456789abcdefABCDEF", rune(text[1]))
+}
+
+func (assembler *octoAssembler) isRegister(text string) bool {
+	_, ok := assembler.aliases[text]
+	return ok || isRegister(text)
+}
+
+func (assembler *octoAssembler) register() (byte, error) {
+	token, err := assembler.next()
+	if err != nil {
+		return 0, err
+	}
+	if register, ok := assembler.aliases[token.text]; ok && !token.isString {
+		return register, nil
+	}
+	if token.isString || !isRegister(token.text) {
+		return 0, fmt.Errorf("expected a register but found %q", token.text)
+	}
+	register, _ := strconv.ParseUint(token.text[1:], 16, 4)
+	return byte(register), nil
+}
+
+func parseNumber(text string) (float64, bool) {
+	digits, negative := strings.TrimPrefix(text, "-"), strings.HasPrefix(text, "-")
+	var value int64
+	var err error
+	switch {
+	case strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X"):
+		value, err = strconv.ParseInt(digits[2:], 16, 32)
+	case strings.HasPrefix(digits, "0b") || strings.HasPrefix(digits, "0B"):
+		value, err = strconv.ParseInt(digits[2:], 2, 32)
+	default:
+		value, err = strconv.ParseInt(digits, 10, 32)
+	}
+	if err != nil {
+		return 0, false
+	}
+	if negative {
+		value = -value
+	}
+	return float64(value), true
+}
+
+//A number, constant, defined label or calculation, ok is false for anything else
+func (assembler *octoAssembler) constant(token octoToken) (float64, bool, error) {
+	if token.isString {
+		return 0, false, nil
+	}
+	if token.text == "{" {
+		value, err := assembler.calculation()
+		return value, true, err
+	}
+	if value, ok := parseNumber(token.text); ok {
+		return value, true, nil
+	}
+	if value, ok := assembler.constants[token.text]; ok {
+		return value, true, nil
+	}
+	return 0, false, nil
+}
+
+//A value that fits in a byte, negative numbers count down from 256
+func (assembler *octoAssembler) byteValue() (byte, error) {
+	token, err := assembler.next()
+	if err != nil {
+		return 0, err
+	}
+	value, ok, err := assembler.constant(token)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if address, isLabel := assembler.labels[token.text]; isLabel {
+			value, ok = float64(address), true
+		}
+	}
+	if !ok {
+		return 0, fmt.Errorf("expected a number but found %q", token.text)
+	}
+	if value < -128 || value > 255 {
+		return 0, fmt.Errorf("%v doesn't fit in a byte", value)
+	}
+	return byte(int(value)), nil
+}
+
+func (assembler *octoAssembler) nibbleValue() (byte, error) {
+	value, err := assembler.byteValue()
+	if err != nil {
+		return 0, err
+	}
+	if value > 0xF {
+		return 0, fmt.Errorf("%v doesn't fit in a nibble", value)
+	}
+	return value, nil
+}
+
+/*
+CALCULATIONS
+Between braces, every operator has the same precedence and they are worked out right to left as in Octo
+*/
+
+var binaryOperators = map[string]func(a, b float64) float64{
+	"+":   func(a, b float64) float64 { return a + b },
+	"-":   func(a, b float64) float64 { return a - b },
+	"*":   func(a, b float64) float64 { return a * b },
+	"/":   func(a, b float64) float64 { return a / b },
+	"%":   func(a, b float64) float64 { return float64(int64(a) % int64(b)) },
+	"&":   func(a, b float64) float64 { return float64(int64(a) & int64(b)) },
+	"|":   func(a, b float64) float64 { return float64(int64(a) | int64(b)) },
+	"^":   func(a, b float64) float64 { return float64(int64(a) ^ int64(b)) },
+	"<<":  func(a, b float64) float64 { return float64(int64(a) << uint(b)) },
+	">>":  func(a, b float64) float64 { return float64(int64(a) >> uint(b)) },
+	"pow": math.Pow,
+	"min": math.Min,
+	"max": math.Max,
+	"<":   func(a, b float64) float64 { return boolValue(a < b) },
+	"<=":  func(a, b float64) float64 { return boolValue(a <= b) },
+	">":   func(a, b float64) float64 { return boolValue(a > b) },
+	">=":  func(a, b float64) float64 { return boolValue(a >= b) },
+	"==":  func(a, b float64) float64 { return boolValue(a == b) },
+	"!=":  func(a, b float64) float64 { return boolValue(a != b) },
+}
+
+var unaryOperators = map[string]func(a float64) float64{
+	"-":     func(a float64) float64 { return -a },
+	"~":     func(a float64) float64 { return float64(^int64(a)) },
+	"!":     func(a float64) float64 { return boolValue(a == 0) },
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"exp":   math.Exp,
+	"log":   math.Log,
+	"sign": func(a float64) float64 {
+		if a == 0 {
+			return 0
+		}
+		return math.Copysign(1, a)
+	},
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+//Reads a calculation up to its closing brace, the opening one is already read
+func (assembler *octoAssembler) calculation() (float64, error) {
+	tokens, err := assembler.block()
+	if err != nil {
+		return 0, err
+	}
+	value, rest, err := assembler.expression(tokens)
+	if err != nil {
+		return 0, err
+	}
+	if len(rest) > 0 {
+		return 0, fmt.Errorf("unexpected %q in calculation", rest[0].text)
+	}
+	return value, nil
+}
+
+func (assembler *octoAssembler) expression(tokens []octoToken) (float64, []octoToken, error) {
+	left, rest, err := assembler.term(tokens)
+	if err != nil || len(rest) == 0 || rest[0].text == ")" {
+		return left, rest, err
+	}
+	operator, ok := binaryOperators[rest[0].text]
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown operator %q in calculation", rest[0].text)
+	}
+	right, rest, err := assembler.expression(rest[1:])
+	return operator(left, right), rest, err
+}
+
+func (assembler *octoAssembler) term(tokens []octoToken) (float64, []octoToken, error) {
+	if len(tokens) == 0 {
+		return 0, nil, fmt.Errorf("calculation ends early")
+	}
+	token := tokens[0]
+	if token.text == "(" {
+		value, rest, err := assembler.expression(tokens[1:])
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(rest) == 0 || rest[0].text != ")" {
+			return 0, nil, fmt.Errorf("missing ) in calculation")
+		}
+		return value, rest[1:], nil
+	}
+	if operator, ok := unaryOperators[token.text]; ok {
+		value, rest, err := assembler.term(tokens[1:])
+		return operator(value), rest, err
+	}
+
+	switch token.text {
+	case "HERE":
+		return float64(assembler.here), tokens[1:], nil
+	case "PI":
+		return math.Pi, tokens[1:], nil
+	case "E":
+		return math.E, tokens[1:], nil
+	}
+	if value, ok := parseNumber(token.text); ok {
+		return value, tokens[1:], nil
+	}
+	if value, ok := assembler.constants[token.text]; ok {
+		return value, tokens[1:], nil
+	}
+	if address, ok := assembler.labels[token.text]; ok {
+		return float64(address), tokens[1:], nil
+	}
+	return 0, nil, fmt.Errorf("%q isn't defined before the calculation", token.text)
+}
+
+/*
+STATEMENTS
+*/
+
+//Instructions without operands
+var plainInstructions = map[string][2]byte{
+	"clear":        {0x00, 0xE0},
+	"return":       {0x00, 0xEE},
+	";":            {0x00, 0xEE},
+	"exit":         {0x00, 0xFD},
+	"lores":        {0x00, 0xFE},
+	"hires":        {0x00, 0xFF},
+	"scroll-left":  {0x00, 0xFB},
+	"scroll-right": {0x00, 0xFC},
+}
+
+//Instructions taking one register, FX.. with the low byte given
+var registerInstructions = map[string]byte{
+	"bcd":       0x33,
+	"save":      0x55,
+	"load":      0x65,
+	"saveflags": 0x75,
+	"loadflags": 0x85,
+}
+
+func (assembler *octoAssembler) statement() error {
+	token, err := assembler.next()
+	if err != nil {
+		return err
+	}
+	if token.isString {
+		return fmt.Errorf("unexpected string %q", token.text)
+	}
+	text := token.text
+
+	if opcode, ok := plainInstructions[text]; ok {
+		return assembler.instruction(opcode[0], opcode[1])
+	}
+	if low, ok := registerInstructions[text]; ok {
+		x, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0xF0|x, low)
+	}
+	if strings.HasPrefix(text, ":") && text != ":" {
+		return assembler.directive(text)
+	}
+	if assembler.isRegister(text) {
+		assembler.tokens = append([]octoToken{token}, assembler.tokens...)
+		return assembler.assignment()
+	}
+
+	switch text {
+	case ":":
+		name, err := assembler.name()
+		if err != nil {
+			return err
+		}
+		return assembler.defineLabel(name)
+	case "jump":
+		return assembler.addressInstruction(0x1)
+	case "jump0":
+		return assembler.addressInstruction(0xB)
+	case "native":
+		return assembler.addressInstruction(0x0)
+	case "sprite":
+		x, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		y, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		n, err := assembler.nibbleValue()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0xD0|x, y<<4|n)
+	case "scroll-down":
+		n, err := assembler.nibbleValue()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0x00, 0xC0|n)
+	case "delay", "buzzer":
+		if err := assembler.expect(":="); err != nil {
+			return err
+		}
+		x, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0xF0|x, map[string]byte{"delay": 0x15, "buzzer": 0x18}[text])
+	case "i":
+		return assembler.indexStatement()
+	case "if":
+		return assembler.ifStatement()
+	case "else":
+		if len(assembler.blocks) == 0 {
+			return fmt.Errorf("else without begin")
+		}
+		begin := assembler.blocks[len(assembler.blocks)-1]
+		assembler.blocks[len(assembler.blocks)-1] = assembler.here
+		if err := assembler.instruction(0x10, 0x00); err != nil {
+			return err
+		}
+		assembler.patch(begin, fixupAddress, assembler.here)
+		return nil
+	case "end":
+		if len(assembler.blocks) == 0 {
+			return fmt.Errorf("end without begin")
+		}
+		assembler.patch(assembler.blocks[len(assembler.blocks)-1], fixupAddress, assembler.here)
+		assembler.blocks = assembler.blocks[:len(assembler.blocks)-1]
+		return nil
+	case "loop":
+		assembler.loops = append(assembler.loops, octoLoop{start: assembler.here})
+		return nil
+	case "while":
+		if len(assembler.loops) == 0 {
+			return fmt.Errorf("while outside a loop")
+		}
+		if err := assembler.condition(true); err != nil {
+			return err
+		}
+		loop := &assembler.loops[len(assembler.loops)-1]
+		loop.breaks = append(loop.breaks, assembler.here)
+		return assembler.instruction(0x10, 0x00)
+	case "again":
+		if len(assembler.loops) == 0 {
+			return fmt.Errorf("again without loop")
+		}
+		loop := assembler.loops[len(assembler.loops)-1]
+		assembler.loops = assembler.loops[:len(assembler.loops)-1]
+		if err := assembler.instruction(0x10|byte(loop.start>>8), byte(loop.start)); err != nil {
+			return err
+		}
+		for _, at := range loop.breaks {
+			assembler.patch(at, fixupAddress, assembler.here)
+		}
+		return nil
+	}
+
+	if macro, ok := assembler.macros[text]; ok {
+		bindings := map[string]octoToken{"CALLS": {text: strconv.Itoa(macro.calls)}}
+		macro.calls++
+		for _, parameter := range macro.parameters {
+			if bindings[parameter], err = assembler.next(); err != nil {
+				return err
+			}
+		}
+		assembler.expand(macro.body, bindings)
+		return nil
+	}
+	if modes, ok := assembler.stringModes[text]; ok {
+		return assembler.stringStatement(text, modes)
+	}
+	if value, ok, err := assembler.constant(token); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		if value < -128 || value > 255 {
+			return fmt.Errorf("%v doesn't fit in a byte", value)
+		}
+		return assembler.emit(byte(int(value)))
+	}
+
+	//Anything else calls a subroutine
+	assembler.tokens = append([]octoToken{token}, assembler.tokens...)
+	return assembler.addressInstruction(0x2)
+}
+
+func (assembler *octoAssembler) directive(text string) error {
+	switch text {
+	case ":alias":
+		name, err := assembler.name()
+		if err != nil {
+			return err
+		}
+		register, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		assembler.aliases[name] = register
+		return nil
+	case ":const", ":calc":
+		name, err := assembler.name()
+		if err != nil {
+			return err
+		}
+		token, err := assembler.next()
+		if err != nil {
+			return err
+		}
+		value, ok, err := assembler.constant(token)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			address, isLabel := assembler.labels[token.text]
+			if !isLabel {
+				return fmt.Errorf("expected a value for %v but found %q", name, token.text)
+			}
+			value = float64(address)
+		}
+		assembler.constants[name] = value
+		return nil
+	case ":byte":
+		value, err := assembler.byteValue()
+		if err != nil {
+			return err
+		}
+		return assembler.emit(value)
+	case ":org":
+		token, err := assembler.next()
+		if err != nil {
+			return err
+		}
+		value, ok, err := assembler.constant(token)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("expected an address but found %q", token.text)
+		}
+		assembler.here = int(value)
+		return nil
+	case ":pointer":
+		token, err := assembler.next()
+		if err != nil {
+			return err
+		}
+		address, err := assembler.reference(token, fixupPointer)
+		if err != nil {
+			return err
+		}
+		return assembler.emit(byte(address>>8), byte(address))
+	case ":unpack":
+		nibble, err := assembler.nibbleValue()
+		if err != nil {
+			return err
+		}
+		token, err := assembler.next()
+		if err != nil {
+			return err
+		}
+		address, err := assembler.reference(token, fixupUnpack)
+		if err != nil {
+			return err
+		}
+		if err := assembler.instruction(0x60, nibble<<4|byte(address>>8)&0x0F); err != nil {
+			return err
+		}
+		return assembler.instruction(0x61, byte(address))
+	case ":macro":
+		name, err := assembler.name()
+		if err != nil {
+			return err
+		}
+		macro := &octoMacro{}
+		for assembler.peek() != "{" {
+			parameter, err := assembler.name()
+			if err != nil {
+				return err
+			}
+			macro.parameters = append(macro.parameters, parameter)
+		}
+		assembler.next()
+		if macro.body, err = assembler.block(); err != nil {
+			return err
+		}
+		assembler.macros[name] = macro
+		return nil
+	case ":stringmode":
+		name, err := assembler.name()
+		if err != nil {
+			return err
+		}
+		alphabet, err := assembler.next()
+		if err != nil {
+			return err
+		}
+		if !alphabet.isString {
+			return fmt.Errorf("expected the characters of %v as a string", name)
+		}
+		if err := assembler.expect("{"); err != nil {
+			return err
+		}
+		body, err := assembler.block()
+		if err != nil {
+			return err
+		}
+		assembler.stringModes[name] = append(assembler.stringModes[name], &octoStringMode{alphabet.text, body})
+		return nil
+	case ":breakpoint":
+		_, err := assembler.name()
+		return err
+	case ":monitor":
+		for i := 0; i < 2; i++ {
+			if _, err := assembler.next(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%v isn't supported", text)
+}
+
+//Runs the body of the string mode with the character's place in its alphabet for every character of the string
+func (assembler *octoAssembler) stringStatement(name string, modes []*octoStringMode) error {
+	text, err := assembler.next()
+	if err != nil {
+		return err
+	}
+	if !text.isString {
+		return fmt.Errorf("expected a string after %v", name)
+	}
+
+	body := []octoToken{}
+	for index, char := range []byte(text.text) {
+		var mode *octoStringMode
+		for _, candidate := range modes {
+			if strings.IndexByte(candidate.alphabet, char) >= 0 {
+				mode = candidate
+			}
+		}
+		if mode == nil {
+			return fmt.Errorf("%q isn't in the characters of %v", char, name)
+		}
+		bindings := map[string]octoToken{
+			"CHAR":  {text: strconv.Itoa(int(char))},
+			"INDEX": {text: strconv.Itoa(index)},
+			"VALUE": {text: strconv.Itoa(strings.IndexByte(mode.alphabet, char))},
+		}
+		for _, token := range mode.body {
+			if bound, ok := bindings[token.text]; ok && !token.isString {
+				token = bound
+			}
+			body = append(body, token)
+		}
+	}
+	assembler.expand(body, nil)
+	return nil
+}
+
+func (assembler *octoAssembler) indexStatement() error {
+	operator, err := assembler.name()
+	if err != nil {
+		return err
+	}
+	switch operator {
+	case "+=":
+		x, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0xF0|x, 0x1E)
+	case ":=":
+		switch assembler.peek() {
+		case "hex", "bighex":
+			kind, _ := assembler.next()
+			x, err := assembler.register()
+			if err != nil {
+				return err
+			}
+			return assembler.instruction(0xF0|x, map[string]byte{"hex": 0x29, "bighex": 0x30}[kind.text])
+		case "long":
+			return fmt.Errorf("i := long is XO-CHIP and isn't supported")
+		}
+		return assembler.addressInstruction(0xA)
+	}
+	return fmt.Errorf("unknown operator %q for i", operator)
+}
+
+//Operators of vx op vy, 8XY_ with the low nibble given
+var registerOperators = map[string]byte{
+	":=":  0x0,
+	"|=":  0x1,
+	"&=":  0x2,
+	"^=":  0x3,
+	"+=":  0x4,
+	"-=":  0x5,
+	">>=": 0x6,
+	"=-":  0x7,
+	"<<=": 0xE,
+}
+
+func (assembler *octoAssembler) assignment() error {
+	x, err := assembler.register()
+	if err != nil {
+		return err
+	}
+	operator, err := assembler.name()
+	if err != nil {
+		return err
+	}
+
+	if operator == ":=" {
+		switch assembler.peek() {
+		case "key":
+			assembler.next()
+			return assembler.instruction(0xF0|x, 0x0A)
+		case "delay":
+			assembler.next()
+			return assembler.instruction(0xF0|x, 0x07)
+		case "random":
+			assembler.next()
+			mask, err := assembler.byteValue()
+			if err != nil {
+				return err
+			}
+			return assembler.instruction(0xC0|x, mask)
+		}
+	}
+	if assembler.isRegister(assembler.peek()) {
+		low, ok := registerOperators[operator]
+		if !ok {
+			return fmt.Errorf("unknown operator %q", operator)
+		}
+		y, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(0x80|x, y<<4|low)
+	}
+
+	value, err := assembler.byteValue()
+	if err != nil {
+		return err
+	}
+	switch operator {
+	case ":=":
+		return assembler.instruction(0x60|x, value)
+	case "+=":
+		return assembler.instruction(0x70|x, value)
+	case "-=":
+		return assembler.instruction(0x70|x, -value)
+	}
+	return fmt.Errorf("operator %q needs a register", operator)
+}
+
+//if then skips the next statement unless the condition holds, if begin runs the block up to else or end when it does
+func (assembler *octoAssembler) ifStatement() error {
+	tokens := assembler.tokens
+	for len(tokens) > 0 && tokens[0].text != "then" && tokens[0].text != "begin" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("if without then or begin")
+	}
+	isBlock := tokens[0].text == "begin"
+
+	if err := assembler.condition(isBlock); err != nil {
+		return err
+	}
+	assembler.next()
+	if isBlock {
+		assembler.blocks = append(assembler.blocks, assembler.here)
+		return assembler.instruction(0x10, 0x00)
+	}
+	return nil
+}
+
+var negatedComparisons = map[string]string{
+	"==": "!=", "!=": "==", "key": "-key", "-key": "key",
+	"<": ">=", ">=": "<", ">": "<=", "<=": ">",
+}
+
+//Emits the instructions that skip the next one unless the condition holds, or when negated when it holds
+func (assembler *octoAssembler) condition(negated bool) error {
+	x, err := assembler.register()
+	if err != nil {
+		return err
+	}
+	comparison, err := assembler.name()
+	if err != nil {
+		return err
+	}
+	if _, ok := negatedComparisons[comparison]; !ok {
+		return fmt.Errorf("unknown comparison %q", comparison)
+	}
+	if negated {
+		comparison = negatedComparisons[comparison]
+	}
+
+	switch comparison {
+	case "key":
+		return assembler.instruction(0xE0|x, 0xA1)
+	case "-key":
+		return assembler.instruction(0xE0|x, 0x9E)
+	case "==", "!=":
+		if assembler.isRegister(assembler.peek()) {
+			y, err := assembler.register()
+			if err != nil {
+				return err
+			}
+			return assembler.instruction(map[string]byte{"==": 0x90, "!=": 0x50}[comparison]|x, y<<4)
+		}
+		value, err := assembler.byteValue()
+		if err != nil {
+			return err
+		}
+		return assembler.instruction(map[string]byte{"==": 0x40, "!=": 0x30}[comparison]|x, value)
+	}
+
+	//Other comparisons subtract in a temporary register and test the flag
+	temporary := byte(0xF)
+	if register, ok := assembler.aliases["compare-temp"]; ok {
+		temporary = register
+	}
+	if assembler.isRegister(assembler.peek()) {
+		y, err := assembler.register()
+		if err != nil {
+			return err
+		}
+		err = assembler.instruction(0x80|temporary, y<<4)
+		if err != nil {
+			return err
+		}
+	} else {
+		value, err := assembler.byteValue()
+		if err != nil {
+			return err
+		}
+		if err := assembler.instruction(0x60|temporary, value); err != nil {
+			return err
+		}
+	}
+	subtract := map[string]byte{">": 0x5, "<=": 0x5, "<": 0x7, ">=": 0x7}[comparison]
+	if err := assembler.instruction(0x80|temporary, x<<4|subtract); err != nil {
+		return err
+	}
+	if comparison == ">" || comparison == "<" {
+		return assembler.instruction(0x4F, 0x00)
+	}
+	return assembler.instruction(0x3F, 0x00)
+}
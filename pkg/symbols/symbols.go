@@ -0,0 +1,215 @@
+//Debug information for a rom, read from a symbol file or worked out from the rom's Octo source
+package symbols
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+/*
+SYMBOL FILES
+Plain text, one entry a line, blank lines and lines starting with # are skipped
+	label <address> <name>			name is defined at address
+	line <address> <file> <line>	the instruction at address came from line of the source file
+Addresses are hex with or without 0x, relative source paths are relative to the symbol file
+A source path can hold spaces as the line number is always the last field
+It goes beside the rom with a .sym extension, octo-symbols writes it for roms made with Octo
+*/
+
+//Source line an instruction was assembled from
+type Line struct {
+	Address chip8.Address
+	File    string
+	Line    int
+}
+
+type Label struct {
+	Address chip8.Address
+	Name    string
+}
+
+type Table struct {
+	labels []Label //Sorted by address
+	lines  []Line  //Sorted by address
+}
+
+func Load(filename string) (*Table, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	directory, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(file, directory)
+}
+
+//Loads the symbol file beside a rom, nil when the rom doesn't have one
+func LoadFor(romFilename string) (*Table, error) {
+	filename := strings.TrimSuffix(romFilename, filepath.Ext(romFilename)) + ".sym"
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return Load(filename)
+}
+
+//directory is where relative source paths start from
+func Parse(reader io.Reader, directory string) (*Table, error) {
+	table := &Table{}
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "label":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("symbols error: line %v: expected label <address> <name>", lineNumber)
+			}
+			address, err := parseAddress(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("symbols error: line %v: %v", lineNumber, err)
+			}
+			table.labels = append(table.labels, Label{address, fields[2]})
+		case "line":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("symbols error: line %v: expected line <address> <file> <line>", lineNumber)
+			}
+			address, err := parseAddress(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("symbols error: line %v: %v", lineNumber, err)
+			}
+			last := fields[len(fields)-1]
+			sourceLine, err := strconv.Atoi(last)
+			if err != nil {
+				return nil, fmt.Errorf("symbols error: line %v: bad line number %q", lineNumber, last)
+			}
+			source := sourcePath(scanner.Text(), fields)
+			if !filepath.IsAbs(source) {
+				source = filepath.Join(directory, source)
+			}
+			table.lines = append(table.lines, Line{address, filepath.Clean(source), sourceLine})
+		default:
+			return nil, fmt.Errorf("symbols error: line %v: unknown entry %q", lineNumber, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sortTable(table)
+	return table, nil
+}
+
+//The file runs from after the address to before the line number, so it can hold spaces
+func sourcePath(text string, fields []string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+	text = strings.TrimSpace(strings.TrimPrefix(text, fields[1]))
+	return strings.TrimSpace(strings.TrimSuffix(text, fields[len(fields)-1]))
+}
+
+func sortTable(table *Table) {
+	sort.SliceStable(table.labels, func(i, j int) bool { return table.labels[i].Address < table.labels[j].Address })
+	sort.SliceStable(table.lines, func(i, j int) bool { return table.lines[i].Address < table.lines[j].Address })
+}
+
+//Writes the table as a symbol file, source paths inside directory are written relative to it
+func (table *Table) Write(writer io.Writer, directory string) error {
+	buffered := bufio.NewWriter(writer)
+	for _, label := range table.labels {
+		fmt.Fprintf(buffered, "label %.3X %s\n", label.Address, label.Name)
+	}
+	for _, line := range table.lines {
+		source := line.File
+		if relative, err := filepath.Rel(directory, source); err == nil && !strings.HasPrefix(relative, "..") {
+			source = relative
+		}
+		fmt.Fprintf(buffered, "line %.3X %s %v\n", line.Address, source, line.Line)
+	}
+	return buffered.Flush()
+}
+
+func parseAddress(text string) (chip8.Address, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(text), "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("bad address %q", text)
+	}
+	return chip8.Address(value), nil
+}
+
+//Closest label at or before address, the first one wins when an address has several
+func (table *Table) Label(address chip8.Address) (string, chip8.Address, bool) {
+	if table == nil {
+		return "", 0, false
+	}
+	i := sort.Search(len(table.labels), func(i int) bool { return table.labels[i].Address > address })
+	if i == 0 {
+		return "", 0, false
+	}
+	start := table.labels[i-1].Address
+	for i > 1 && table.labels[i-2].Address == start {
+		i--
+	}
+	return table.labels[i-1].Name, start, true
+}
+
+func (table *Table) Lookup(name string) (chip8.Address, bool) {
+	if table == nil {
+		return 0, false
+	}
+	for _, label := range table.labels {
+		if label.Name == name {
+			return label.Address, true
+		}
+	}
+	return 0, false
+}
+
+//Source line of the instruction at address
+func (table *Table) LineAt(address chip8.Address) (Line, bool) {
+	if table == nil {
+		return Line{}, false
+	}
+	i := sort.Search(len(table.lines), func(i int) bool { return table.lines[i].Address >= address })
+	if i < len(table.lines) && table.lines[i].Address == address {
+		return table.lines[i], true
+	}
+	return Line{}, false
+}
+
+//First instruction of a source line, or of the next line with code when that line has none
+//Files match by full path or, failing that, by name alone
+func (table *Table) AddressOf(file string, line int) (Line, bool) {
+	if table == nil {
+		return Line{}, false
+	}
+	best, isFound := Line{}, false
+	for _, candidate := range table.lines {
+		if !sameFile(candidate.File, file) || candidate.Line < line {
+			continue
+		}
+		if !isFound || candidate.Line < best.Line || (candidate.Line == best.Line && candidate.Address < best.Address) {
+			best, isFound = candidate, true
+		}
+	}
+	return best, isFound
+}
+
+func sameFile(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	return a == b || (filepath.Base(a) == filepath.Base(b) && (!filepath.IsAbs(a) || !filepath.IsAbs(b)))
+}
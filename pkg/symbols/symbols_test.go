@@ -0,0 +1,224 @@
+package symbols
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gongaware.org/gChip8/pkg/chip8"
+)
+
+const symbolFile = `# assembled from game.8o
+label 200 main
+label 0x204 draw_player
+label 204 sprites
+line 0x200 game.8o 3
+line 202 game.8o 3
+line 204 lib/sprites.8o 10
+
+line 206 game.8o 8
+`
+
+func TestParse(t *testing.T) {
+	directory := filepath.FromSlash("/src/game")
+	table, err := Parse(strings.NewReader(symbolFile), directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if line, ok := table.LineAt(0x204); !ok || line.File != filepath.Join(directory, "lib", "sprites.8o") || line.Line != 10 {
+		t.Errorf("FAIL LineAt(0x204)=%v,%v (expected lib/sprites.8o line 10)", line, ok)
+	}
+	if line, ok := table.LineAt(0x203); ok {
+		t.Errorf("FAIL LineAt(0x203)=%v (expected no line)", line)
+	}
+
+	tests := []struct {
+		file    string
+		line    int
+		address chip8.Address
+		ok      bool
+	}{
+		{filepath.Join(directory, "game.8o"), 3, 0x200, true},
+		{"game.8o", 4, 0x206, true},
+		{"game.8o", 9, 0, false},
+		{"other.8o", 3, 0, false},
+	}
+	for _, test := range tests {
+		line, ok := table.AddressOf(test.file, test.line)
+		if ok != test.ok || (ok && line.Address != test.address) {
+			t.Errorf("FAIL AddressOf(%v, %v)=%v,%v (expected 0x%X,%v)", test.file, test.line, line, ok, test.address, test.ok)
+		}
+	}
+
+	labels := []struct {
+		address chip8.Address
+		name    string
+		start   chip8.Address
+		ok      bool
+	}{
+		{0x1FE, "", 0, false},
+		{0x200, "main", 0x200, true},
+		{0x202, "main", 0x200, true},
+		{0x206, "draw_player", 0x204, true},
+	}
+	for _, test := range labels {
+		name, start, ok := table.Label(test.address)
+		if name != test.name || start != test.start || ok != test.ok {
+			t.Errorf("FAIL Label(0x%.3X)=%v,0x%.3X,%v (expected %v,0x%.3X,%v)", test.address, name, start, ok, test.name, test.start, test.ok)
+		}
+	}
+	if address, ok := table.Lookup("sprites"); !ok || address != 0x204 {
+		t.Errorf("FAIL Lookup(sprites)=0x%.3X,%v (expected 0x204)", address, ok)
+	}
+	if trace := chip8.StackTrace(table, chip8.State{PC: 0x206, SP: 1, Stack: [16]chip8.Address{0x204}}); trace != "draw_player+0x2 ← main+0x2" {
+		t.Errorf("FAIL StackTrace=%v (expected draw_player+0x2 ← main+0x2)", trace)
+	}
+
+	var empty *Table
+	if _, ok := empty.LineAt(0x200); ok {
+		t.Errorf("FAIL LineAt without symbols found a line")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{"line 200 game.8o", "line xyz game.8o 1", "line 200 game.8o one", "label 200", "symbol 200 main"} {
+		if _, err := Parse(strings.NewReader(text), "/"); err == nil {
+			t.Errorf("FAIL Parse(%q) (expected an error)", text)
+		}
+	}
+}
+
+//Source paths with spaces are kept whole, the line number is always the last field
+func TestSourcePathWithSpaces(t *testing.T) {
+	directory := t.TempDir()
+	source := filepath.Join(directory, "my game", "main file.8o")
+	table := &Table{lines: []Line{{0x200, source, 3}}}
+
+	written := bytes.Buffer{}
+	if err := table.Write(&written, directory); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Parse(&written, directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, ok := read.LineAt(0x200); !ok || line.File != source || line.Line != 3 {
+		t.Errorf("FAIL LineAt(0x200)=%v,%v (expected line 3 of %v)", line, ok, source)
+	}
+}
+
+//The roms of the test suite were written by Octo from the sources beside them
+func TestAssembleOcto(t *testing.T) {
+	suite := filepath.Join("..", "chip8", "testdata", "roms", "suite")
+	for _, name := range []string{"1-chip8-logo", "2-ibm-logo", "3-corax+", "4-flags", "5-quirks", "6-keypad"} {
+		program, _, err := AssembleOcto(filepath.Join(suite, name+".8o"))
+		if err != nil {
+			t.Errorf("FAIL %v", err)
+			continue
+		}
+		rom, err := ioutil.ReadFile(filepath.Join(suite, name+".ch8"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(program, rom) {
+			t.Errorf("FAIL %v assembled to %v bytes that don't match Octo's %v", name, len(program), len(rom))
+		}
+	}
+
+	source := filepath.Join(suite, "3-corax+.8o")
+	_, table, err := AssembleOcto(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, _ = filepath.Abs(source)
+	if address, ok := table.Lookup("main"); !ok || address != 0x20A {
+		t.Errorf("FAIL Lookup(main)=0x%.3X,%v (expected 0x20A after the jump to main)", address, ok)
+	}
+	if name, start, _ := table.Label(0x204); name != "test-2X-0E" || start != 0x202 {
+		t.Errorf("FAIL Label(0x204)=%v,0x%.3X (expected test-2X-0E at 0x202)", name, start)
+	}
+	if line, ok := table.LineAt(0x202); !ok || line.File != source || line.Line != 41 {
+		t.Errorf("FAIL LineAt(0x202)=%v,%v (expected line 41 of %v)", line, ok, source)
+	}
+
+	//Symbols survive writing a symbol file and reading it again
+	written := bytes.Buffer{}
+	if err := table.Write(&written, filepath.Dir(source)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(written.String(), "line 202 3-corax+.8o 41\n") {
+		t.Errorf("FAIL written symbols don't have line 202 3-corax+.8o 41")
+	}
+	read, err := Parse(&written, filepath.Dir(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, ok := read.AddressOf(source, 48); !ok || line.Address != 0x20A {
+		t.Errorf("FAIL AddressOf(line 48)=%v,%v (expected clear at 0x20A)", line, ok)
+	}
+
+	//The chip8 logo's show macro puts its sprite on the line it's used on
+	_, table, err = AssembleOcto(filepath.Join(suite, "1-chip8-logo.8o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, ok := table.LineAt(0x208); !ok || line.Line != 27 {
+		t.Errorf("FAIL LineAt(0x208)=%v,%v (expected the show on line 27)", line, ok)
+	}
+}
+
+//Every directive the assembler takes, with the bytes Octo writes for it
+func TestAssembleOctoDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []byte
+	}{
+		{"jump to main", ": start\n  clear\n: main\n  jump start\n", []byte{0x12, 0x04, 0x00, 0xE0, 0x12, 0x02}},
+		{":alias", ":alias x v3\n: main\n  x := 5\n", []byte{0x63, 0x05}},
+		{":const", ":const lives 3\n: main\n  v0 := lives\n", []byte{0x60, 0x03}},
+		{":calc", ": main\n  :calc double { 2 * 3 }\n  v1 := double\n", []byte{0x61, 0x06}},
+		{":byte", ": main\n  :byte 0xAB\n  :byte { 1 + 1 }\n", []byte{0xAB, 0x02}},
+		{":org", ": main\n  jump end\n:org 0x206\n: end\n  clear\n", []byte{0x12, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0xE0}},
+		{":pointer", ": main\n  :pointer data\n: data\n  0x12\n", []byte{0x02, 0x02, 0x12}},
+		{":unpack", ": main\n  :unpack 0xA data\n: data\n  0x12\n", []byte{0x60, 0xA2, 0x61, 0x04, 0x12}},
+		{":macro", ":macro twice R { R += 1 R += 1 }\n: main\n  twice v2\n", []byte{0x72, 0x01, 0x72, 0x01}},
+		{":stringmode", ":stringmode digits \"0123\" { VALUE }\n: main\n  digits \"31\"\n", []byte{0x03, 0x01}},
+		{":breakpoint", ": main\n  :breakpoint here\n  clear\n", []byte{0x00, 0xE0}},
+		{":monitor", ": main\n  :monitor main 4\n  clear\n", []byte{0x00, 0xE0}},
+	}
+	for _, test := range tests {
+		filename := filepath.Join(t.TempDir(), "test.8o")
+		if err := ioutil.WriteFile(filename, []byte(test.source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		program, _, err := AssembleOcto(filename)
+		if err != nil || !bytes.Equal(program, test.expected) {
+			t.Errorf("FAIL %v assembled to % X,%v (expected % X)", test.name, program, err, test.expected)
+		}
+	}
+}
+
+func TestAssembleOctoErrors(t *testing.T) {
+	tests := map[string]string{
+		"no main":   ": start\n  clear\n",
+		"undefined": ": main\n  jump nowhere\n",
+		"xo-chip":   ": main\n  i := long main\n",
+		"open if":   ": main\n  if v0 == 1 begin\n  clear\n",
+		"too big":   ": main\n  v0 := 256\n",
+		":include":  ":include \"other.8o\"\n: main\n  clear\n",
+		":next":     ": main\n  :next data v0 := 1\n: data\n",
+	}
+	for name, source := range tests {
+		filename := filepath.Join(t.TempDir(), "test.8o")
+		if err := ioutil.WriteFile(filename, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := AssembleOcto(filename); err == nil {
+			t.Errorf("FAIL AssembleOcto with %v (expected an error)", name)
+		}
+	}
+}